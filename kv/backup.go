@@ -0,0 +1,331 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+)
+
+// A backup is a stream of bytes that begins with a header, continues with one
+// record for each key-value pair, and ends with a trailer:
+//
+//   header:  backupMagic, version (uint16)
+//   pair:    recordPair, len(key) (uvarint), key, len(value) (uvarint), value,
+//            checksum of the record so far (uint32)
+//   trailer: recordEnd, count of pairs (uvarint), checksum of the entire
+//            backup so far (uint32)
+//
+// All fixed-size integers are big-endian, and all checksums are CRC-32C.
+const (
+	backupVersion uint16 = 1
+
+	recordEnd  byte = 0
+	recordPair byte = 1
+
+	// maxBackupLength limits the length of keys and values read from a
+	// backup so that a corrupt length cannot cause a huge allocation.
+	maxBackupLength = 1 << 30
+
+	// Restore commits a transaction after writing either restoreBatchCount
+	// pairs or restoreBatchBytes bytes, whichever comes first.
+	restoreBatchCount = 1000
+	restoreBatchBytes = 1 << 20
+)
+
+var (
+	backupMagic = []byte("note-maps/kv")
+	crcTable    = crc32.MakeTable(crc32.Castagnoli)
+)
+
+// ErrCorruptBackup is returned by Restore when its input is truncated or does
+// not match its checksums.
+var ErrCorruptBackup = errors.New("kv: corrupt backup")
+
+// Backup writes every key-value pair in db to w in a portable binary format
+// that can be read by Restore.
+//
+// Keys that are a single byte long are reserved for bookkeeping by DB
+// implementations, such as the state of the Allocer that kv/badger keeps at
+// key {1}, and are not backed up.
+//
+// All pairs are read through a single read-only transaction, so the backup
+// is as consistent as that transaction.
+func Backup(db DB, w io.Writer) error {
+	txn := db.NewTxn(false)
+	defer txn.Discard()
+	iter := txn.PrefixIterator(nil)
+	defer iter.Discard()
+
+	bw := bufio.NewWriter(w)
+	all := crc32.New(crcTable)
+	out := io.MultiWriter(bw, all)
+	var header [2]byte
+	binary.BigEndian.PutUint16(header[:], backupVersion)
+	if _, err := out.Write(append(append([]byte{}, backupMagic...), header[:]...)); err != nil {
+		return err
+	}
+
+	var (
+		record bytes.Buffer
+		n      [binary.MaxVarintLen64]byte
+		count  uint64
+	)
+	for iter.Seek(nil); iter.Valid(); iter.Next() {
+		key := iter.Key()
+		if reservedKey(key) {
+			continue
+		}
+		record.Reset()
+		record.WriteByte(recordPair)
+		record.Write(n[:binary.PutUvarint(n[:], uint64(len(key)))])
+		record.Write(key)
+		err := iter.Value(func(value []byte) error {
+			record.Write(n[:binary.PutUvarint(n[:], uint64(len(value)))])
+			record.Write(value)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		record.Write(checksum(crc32.Checksum(record.Bytes(), crcTable)))
+		if _, err = out.Write(record.Bytes()); err != nil {
+			return err
+		}
+		count++
+	}
+
+	record.Reset()
+	record.WriteByte(recordEnd)
+	record.Write(n[:binary.PutUvarint(n[:], count)])
+	if _, err := out.Write(record.Bytes()); err != nil {
+		return err
+	}
+	if _, err := bw.Write(checksum(all.Sum32())); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// Restore reads a backup written by Backup from r and writes every key-value
+// pair it contains to db.
+//
+// Existing pairs in db are overwritten only where the backup contains the
+// same keys, so db should usually be empty. Reserved keys, which older
+// backups may contain, are skipped.
+//
+// Since the entities in the backup are already in use, Restore passes the
+// greatest of them to db.Reserve if db implements Reserver. Entities are
+// found where Partitioned puts them: in the first eight bytes of each key,
+// and in the eight bytes that follow a component.
+//
+// Pairs are written through a series of transactions in order to limit the
+// size of each one. If Restore returns an error, some pairs may already have
+// been committed to db.
+func Restore(db DB, r io.Reader) error {
+	br := &backupReader{r: bufio.NewReader(r), all: crc32.New(crcTable)}
+	header := make([]byte, len(backupMagic)+2)
+	if err := br.readFull(header); err != nil {
+		return err
+	}
+	if !bytes.Equal(header[:len(backupMagic)], backupMagic) {
+		return ErrCorruptBackup
+	}
+	if v := binary.BigEndian.Uint16(header[len(backupMagic):]); v != backupVersion {
+		return fmt.Errorf("kv: unsupported backup version %v", v)
+	}
+
+	txn := db.NewTxn(true)
+	defer func() { txn.Discard() }()
+	var (
+		count      uint64
+		batchCount int
+		batchBytes int
+		last       Entity
+	)
+	for {
+		br.record = crc32.New(crcTable)
+		kind, err := br.readByte()
+		if err != nil {
+			return err
+		}
+		switch kind {
+		case recordPair:
+		case recordEnd:
+			n, err := br.readUvarint()
+			if err != nil {
+				return err
+			}
+			want := br.all.Sum32()
+			if err = br.readChecksum(want); err != nil {
+				return err
+			}
+			if n != count {
+				return ErrCorruptBackup
+			}
+			if err = txn.Commit(); err != nil {
+				return err
+			}
+			if r, ok := db.(Reserver); ok {
+				return r.Reserve(last)
+			}
+			return nil
+		default:
+			return ErrCorruptBackup
+		}
+		key, err := br.readBytes()
+		if err != nil {
+			return err
+		}
+		value, err := br.readBytes()
+		if err != nil {
+			return err
+		}
+		if err = br.readChecksum(br.record.Sum32()); err != nil {
+			return err
+		}
+		count++
+		if reservedKey(key) {
+			continue
+		}
+		if err = txn.Set(key, value); err != nil {
+			return err
+		}
+		if e := keyEntity(key); e > last {
+			last = e
+		}
+		batchCount++
+		batchBytes += len(key) + len(value)
+		if batchCount >= restoreBatchCount || batchBytes >= restoreBatchBytes {
+			if err = txn.Commit(); err != nil {
+				return err
+			}
+			txn.Discard()
+			txn = db.NewTxn(true)
+			batchCount, batchBytes = 0, 0
+		}
+	}
+}
+
+// reservedKey returns true if key is reserved for bookkeeping by DB
+// implementations.
+func reservedKey(key []byte) bool { return len(key) == 1 }
+
+// keyEntity returns the greatest entity in key, assuming key was made by
+// Partitioned.
+func keyEntity(key []byte) Entity {
+	var e, c Entity
+	if len(key) >= 8 {
+		e.Decode(key)
+	}
+	if len(key) >= 8+2+8 {
+		c.Decode(key[10:])
+	}
+	if c > e {
+		return c
+	}
+	return e
+}
+
+func checksum(sum uint32) []byte {
+	var bs [4]byte
+	binary.BigEndian.PutUint32(bs[:], sum)
+	return bs[:]
+}
+
+// backupReader reads the parts of a backup while computing checksums for the
+// current record and for the backup as a whole.
+type backupReader struct {
+	r           *bufio.Reader
+	all, record hash.Hash32
+	err         error
+}
+
+func (br *backupReader) write(bs []byte) {
+	br.all.Write(bs)
+	if br.record != nil {
+		br.record.Write(bs)
+	}
+}
+
+func (br *backupReader) readFull(bs []byte) error {
+	if _, err := io.ReadFull(br.r, bs); err == io.EOF || err == io.ErrUnexpectedEOF {
+		return ErrCorruptBackup
+	} else if err != nil {
+		return err
+	}
+	br.write(bs)
+	return nil
+}
+
+func (br *backupReader) readByte() (byte, error) {
+	var b [1]byte
+	err := br.readFull(b[:])
+	return b[0], err
+}
+
+// ReadByte implements io.ByteReader so that backupReader can be used with
+// binary.ReadUvarint.
+func (br *backupReader) ReadByte() (byte, error) {
+	b, err := br.readByte()
+	br.err = err
+	return b, err
+}
+
+func (br *backupReader) readUvarint() (uint64, error) {
+	br.err = nil
+	n, err := binary.ReadUvarint(br)
+	if err != nil && br.err == nil {
+		// ReadUvarint reports an overflow without any error from ReadByte.
+		err = ErrCorruptBackup
+	}
+	return n, err
+}
+
+func (br *backupReader) readBytes() ([]byte, error) {
+	n, err := br.readUvarint()
+	if err != nil {
+		return nil, err
+	}
+	if n > maxBackupLength {
+		return nil, ErrCorruptBackup
+	}
+	bs := make([]byte, n)
+	return bs, br.readFull(bs)
+}
+
+// readChecksum reads a checksum and compares it to want.
+//
+// The checksum is included in the checksum of the backup as a whole, but not
+// in that of the current record.
+func (br *backupReader) readChecksum(want uint32) error {
+	var bs [4]byte
+	if _, err := io.ReadFull(br.r, bs[:]); err == io.EOF || err == io.ErrUnexpectedEOF {
+		return ErrCorruptBackup
+	} else if err != nil {
+		return err
+	}
+	br.all.Write(bs[:])
+	if binary.BigEndian.Uint32(bs[:]) != want {
+		return ErrCorruptBackup
+	}
+	return nil
+}
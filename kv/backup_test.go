@@ -0,0 +1,154 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv_test
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/google/note-maps/kv"
+	"github.com/google/note-maps/kv/kvtest"
+	"github.com/google/note-maps/kv/memory"
+)
+
+// pairs returns every key-value pair in db.
+func pairs(t *testing.T, db kv.DB) map[string]string {
+	txn := db.NewTxn(false)
+	defer txn.Discard()
+	iter := txn.PrefixIterator(nil)
+	defer iter.Discard()
+	m := make(map[string]string)
+	for iter.Seek(nil); iter.Valid(); iter.Next() {
+		key := string(iter.Key())
+		if err := iter.Value(func(v []byte) error {
+			m[key] = string(v)
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return m
+}
+
+func backup(t *testing.T, db kv.DB) []byte {
+	var buf bytes.Buffer
+	if err := kv.Backup(db, &buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestBackupRestore(t *testing.T) {
+	src := memory.NewDB()
+	defer src.Close()
+	txn := src.NewTxn(true)
+	for i := 0; i < 2500; i++ {
+		key := []byte(fmt.Sprintf("key %v", i))
+		if err := txn.Set(key, bytes.Repeat([]byte{byte(i)}, i%700)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := txn.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	want := pairs(t, src)
+
+	// Round trip through badger and back to memory.
+	mid := kvtest.NewDB(t)
+	defer mid.Close()
+	if err := kv.Restore(mid, bytes.NewReader(backup(t, src))); err != nil {
+		t.Fatal(err)
+	}
+	dst := memory.NewDB()
+	defer dst.Close()
+	if err := kv.Restore(dst, bytes.NewReader(backup(t, mid))); err != nil {
+		t.Fatal(err)
+	}
+	// Badger's own keys, such as those of its Allocer, are not backed up.
+	if got := pairs(t, dst); !reflect.DeepEqual(want, got) {
+		t.Errorf("want %v pairs, got %v pairs", len(want), len(got))
+	}
+}
+
+func TestRestoreReserve(t *testing.T) {
+	src := memory.NewDB()
+	defer src.Close()
+	txn := src.NewTxn(true)
+	var last kv.Entity
+	for i := 0; i < 3; i++ {
+		e, err := txn.Alloc()
+		if err != nil {
+			t.Fatal(err)
+		}
+		last = e
+		if err = txn.Set(kv.Prefix(kv.Entity(0).Encode()).ConcatEntityComponent(e, 1), []byte("value")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := txn.Set([]byte{1}, []byte("reserved")); err != nil {
+		t.Fatal(err)
+	}
+	if err := txn.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	dst := memory.NewDB()
+	defer dst.Close()
+	if err := kv.Restore(dst, bytes.NewReader(backup(t, src))); err != nil {
+		t.Fatal(err)
+	}
+	if got := pairs(t, dst); len(got) != 3 {
+		t.Errorf("want 3 pairs without the reserved key, got %q", got)
+	}
+	txn = dst.NewTxn(true)
+	defer txn.Discard()
+	if e, err := txn.Alloc(); err != nil {
+		t.Fatal(err)
+	} else if e <= last {
+		t.Errorf("want an entity greater than restored entity %v, got %v", last, e)
+	}
+}
+
+func TestRestoreCorrupt(t *testing.T) {
+	src := memory.NewDB()
+	defer src.Close()
+	txn := src.NewTxn(true)
+	for _, key := range []string{"key a", "key b", "key c"} {
+		if err := txn.Set([]byte(key), []byte("value of "+key)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := txn.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	bs := backup(t, src)
+	if err := kv.Restore(memory.NewDB(), bytes.NewReader(bs)); err != nil {
+		t.Fatal(err)
+	}
+	for i := range bs {
+		flipped := append([]byte{}, bs...)
+		flipped[i] ^= 0x10
+		if err := kv.Restore(memory.NewDB(), bytes.NewReader(flipped)); err == nil {
+			t.Errorf("flipped byte %v: want error, got nil", i)
+		}
+	}
+	for n := 0; n < len(bs); n++ {
+		err := kv.Restore(memory.NewDB(), bytes.NewReader(bs[:n]))
+		if err != kv.ErrCorruptBackup {
+			t.Errorf("truncated to %v bytes: want %v, got %v", n, kv.ErrCorruptBackup, err)
+		}
+	}
+}
@@ -16,11 +16,14 @@
 package badger
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"log"
 
 	"github.com/dgraph-io/badger"
+	bpb "github.com/dgraph-io/badger/pb"
 	"github.com/google/note-maps/kv"
 )
 
@@ -69,6 +72,10 @@ func (db *DB) Dump(w io.Writer) {
 	fmt.Fprintf(w, "%v keys\n", count)
 }
 
+// Reserve ensures that transactions will never allocate e or any smaller
+// Entity.
+func (db *DB) Reserve(e kv.Entity) error { return db.a.Reserve(e) }
+
 // Close releases unallocated Entity values and closes the database.
 func (db *DB) Close() error {
 	if db == nil {
@@ -86,6 +93,18 @@ func (db *DB) NewTxn(update bool) kv.TxnCommitDiscarder {
 	return txn{db: db, tx: btxn}
 }
 
+// Watch passes committed changes to keys matching prefix to f until ctx is
+// done or f returns an error.
+func (db *DB) Watch(ctx context.Context, prefix []byte, f func([]kv.Change) error) error {
+	return db.DB.Subscribe(ctx, func(kvs *bpb.KVList) error {
+		cs := make([]kv.Change, len(kvs.Kv))
+		for i, item := range kvs.Kv {
+			cs[i] = kv.Change{Key: item.Key, Value: item.Value}
+		}
+		return f(cs)
+	}, prefix)
+}
+
 type txn struct {
 	db *DB
 	tx *badger.Txn
@@ -95,7 +114,28 @@ func (s txn) Alloc() (kv.Entity, error) {
 	return s.db.a.Alloc()
 }
 
-func (s txn) Set(key, value []byte) error { return s.tx.Set(key, value) }
+// Set copies key and value, since badger keeps the slices it is given until the
+// transaction is committed.
+func (s txn) Set(key, value []byte) error {
+	return txnErr(s.tx.Set(append([]byte(nil), key...), append([]byte{}, value...)))
+}
+
+// Delete copies key, since badger keeps the slices it is given until the
+// transaction is committed.
+func (s txn) Delete(key []byte) error {
+	return txnErr(s.tx.Delete(append([]byte(nil), key...)))
+}
+
+// txnErr replaces badger errors that have equivalents in package kv.
+func txnErr(err error) error {
+	switch err {
+	case badger.ErrConflict:
+		return kv.ErrConflict
+	case badger.ErrTxnTooBig:
+		return kv.ErrTxnTooBig
+	}
+	return err
+}
 
 func (s txn) Get(key []byte, f func([]byte) error) error {
 	item, err := s.tx.Get(key)
@@ -117,9 +157,19 @@ func (s txn) PrefixIterator(prefix []byte) kv.Iterator {
 	}
 }
 
+func (s txn) ReversePrefixIterator(prefix []byte) kv.Iterator {
+	opts := badger.DefaultIteratorOptions
+	opts.Reverse = true
+	return reverseIterator{
+		s.tx.NewIterator(opts),
+		prefix,
+		successor(prefix),
+	}
+}
+
 func (s txn) Commit() error {
 	if err := s.tx.Commit(); err != nil {
-		return err
+		return txnErr(err)
 	}
 	if err := s.db.Sync(); err != nil {
 		// An error from Sync is important, but it does not indicate that the
@@ -147,3 +197,53 @@ func (i iterator) Key() []byte { return i.Item().Key()[len(i.prefix):] }
 func (i iterator) Value(f func([]byte) error) error { return i.Item().Value(f) }
 
 func (i iterator) Discard() { i.Close() }
+
+// reverseIterator adapts a reversed badger.Iterator to the kv.Iterator
+// contract.
+//
+// Badger interprets a reverse Seek to an empty key as a seek to the prefix
+// itself, which sorts before every other key with that prefix. Since
+// kv.Iterator requires Seek(nil) to move to the last key, reverseIterator
+// seeks to a key just past the end of the prefix instead, and so does its own
+// prefix checking.
+type reverseIterator struct {
+	*badger.Iterator
+	prefix []byte
+	end    []byte
+}
+
+func (i reverseIterator) Seek(key []byte) {
+	if len(key) > 0 {
+		i.Iterator.Seek(append(append([]byte{}, i.prefix...), key...))
+		return
+	}
+	i.Iterator.Seek(i.end)
+	if i.Iterator.Valid() && !bytes.HasPrefix(i.Item().Key(), i.prefix) {
+		i.Iterator.Next()
+	}
+}
+
+func (i reverseIterator) Valid() bool {
+	return i.Iterator.Valid() && bytes.HasPrefix(i.Item().Key(), i.prefix)
+}
+
+func (i reverseIterator) Key() []byte { return i.Item().Key()[len(i.prefix):] }
+
+func (i reverseIterator) Value(f func([]byte) error) error { return i.Item().Value(f) }
+
+func (i reverseIterator) Discard() { i.Close() }
+
+// successor returns a key that sorts after every key that has the given
+// prefix.
+func successor(prefix []byte) []byte {
+	end := append([]byte{}, prefix...)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	// Every byte is 0xff, so there is no short successor: settle for a key
+	// that sorts after any reasonably sized key with this prefix.
+	return append(end, bytes.Repeat([]byte{0xff}, 256)...)
+}
@@ -1,9 +1,14 @@
 package badger
 
 import (
+	"context"
 	"io/ioutil"
 	"os"
+	"reflect"
 	"testing"
+	"time"
+
+	"github.com/google/note-maps/kv"
 )
 
 func TestNew(t *testing.T) {
@@ -34,3 +39,146 @@ func TestNew(t *testing.T) {
 		t.Errorf("want %#v, got %#v", want, got)
 	}
 }
+
+func TestDelete(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestDelete-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := Open(DefaultOptions(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	txn := db.NewTxn(true)
+	defer txn.Discard()
+	if err = txn.Set([]byte("key"), []byte("value")); err != nil {
+		t.Fatal(err)
+	}
+	if err = txn.Delete([]byte("key")); err != nil {
+		t.Fatal(err)
+	}
+	var got []byte
+	err = txn.Get([]byte("key"), func(bs []byte) error {
+		got = bs
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(got) != 0 {
+		t.Errorf("want empty value, got %#v", string(got))
+	}
+}
+
+func TestReversePrefixIterator(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestReversePrefixIterator-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := Open(DefaultOptions(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	txn := db.NewTxn(true)
+	defer txn.Discard()
+	for _, k := range []string{"a", "b", "b1", "b2", "b3", "c", "\xff"} {
+		if err = txn.Set([]byte(k), []byte(k)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, test := range []struct {
+		Prefix string
+		Seek   string
+		Want   []string
+	}{
+		{"b", "", []string{"3", "2", "1", ""}},
+		{"b", "2", []string{"2", "1", ""}},
+		{"b", "25", []string{"2", "1", ""}},
+		{"", "b1", []string{"b1", "b", "a"}},
+		{"", "", []string{"\xff", "c", "b3", "b2", "b1", "b", "a"}},
+		{"\xff", "", []string{""}},
+		{"d", "", nil},
+	} {
+		var got []string
+		iter := txn.ReversePrefixIterator([]byte(test.Prefix))
+		for iter.Seek([]byte(test.Seek)); iter.Valid(); iter.Next() {
+			got = append(got, string(iter.Key()))
+		}
+		iter.Discard()
+		if !reflect.DeepEqual(test.Want, got) {
+			t.Errorf("%#v: Seek(%#v): want %#v, got %#v",
+				test.Prefix, test.Seek, test.Want, got)
+		}
+	}
+}
+
+func TestWatch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestWatch-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := Open(DefaultOptions(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := make(chan kv.Change, 100)
+	done := make(chan error)
+	go func() {
+		done <- db.Watch(ctx, []byte("a/"), func(cs []kv.Change) error {
+			for _, c := range cs {
+				ch <- c
+			}
+			return nil
+		})
+	}()
+	update := func(f func(kv.Txn) error) {
+		txn := db.NewTxn(true)
+		defer txn.Discard()
+		if err := f(txn); err != nil {
+			t.Fatal(err)
+		}
+		if err := txn.Commit(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Watch may not have begun watching yet, so keep committing changes until
+	// one of them is delivered.
+	tick := time.NewTicker(10 * time.Millisecond)
+	defer tick.Stop()
+	for waiting := true; waiting; {
+		update(func(txn kv.Txn) error { return txn.Set([]byte("a/1"), []byte("x")) })
+		select {
+		case <-ch:
+			waiting = false
+		case <-tick.C:
+		}
+	}
+	update(func(txn kv.Txn) error {
+		if err := txn.Set([]byte("b/1"), []byte("y")); err != nil {
+			return err
+		}
+		return txn.Delete([]byte("a/1"))
+	})
+	for deleted := false; !deleted; {
+		select {
+		case c := <-ch:
+			if string(c.Key) != "a/1" {
+				t.Fatalf("unexpected change to key %q", c.Key)
+			}
+			deleted = len(c.Value) == 0
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for deletion")
+		}
+	}
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("want %v, got %v", context.Canceled, err)
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import "errors"
+
+// ErrTxnTooBig is returned by Set or Delete when a transaction cannot hold any
+// more writes.
+//
+// Like ErrConflict, DB implementations return ErrTxnTooBig itself rather than
+// an error of their own.
+var ErrTxnTooBig = errors.New("kv: transaction too big")
+
+// DefaultBatchWrites is the default value of Batch.MaxWrites.
+const DefaultBatchWrites = 10000
+
+// Checkpointer is implemented by Txn types, such as *Batch, that may commit
+// their writes in several transactions.
+//
+// Checkpoint marks the end of a complete update, such as setting a component
+// value and its index rows, so that the implementation may commit the writes
+// made so far.
+type Checkpointer interface {
+	Checkpoint() error
+}
+
+// Checkpoint calls t.Checkpoint if t implements Checkpointer.
+//
+// Code generated by kvschema calls Checkpoint at the end of each complete
+// update.
+func Checkpoint(t Txn) error {
+	if c, ok := t.(Checkpointer); ok {
+		return c.Checkpoint()
+	}
+	return nil
+}
+
+// Batch is a Txn for bulk updates, such as imports, that may be too large for
+// a single transaction.
+//
+// Batch commits its writes in as many transactions as necessary, and only
+// ever commits the writes made before a checkpoint. It commits at the first
+// checkpoint after MaxWrites writes, and when the current transaction returns
+// ErrTxnTooBig it commits the writes made before the last checkpoint and
+// continues in a new transaction. Since code generated by kvschema calls
+// Checkpoint after each Set or Delete of a component value, each commit
+// includes whole component values along with their index rows.
+//
+// Reads through a Batch see all of its earlier writes. However, a Batch is not
+// isolated from other transactions, and any parts it has committed remain
+// committed if it is discarded. A Batch is not safe for concurrent use.
+//
+// Batch works through ordinary transactions, replaying the writes since the
+// last checkpoint when it has to split one, rather than through write-only
+// bulk loaders such as badger.WriteBatch. Generated Set methods read the old
+// value of a component to find the index rows to replace, so a Batch must be
+// able to read its own writes, and a loader that commits in the background
+// could commit a component value without its index rows.
+type Batch struct {
+	// MaxWrites is the number of writes after which the next checkpoint
+	// commits them. If MaxWrites is zero or less, Batch only commits when
+	// the current transaction is too big or when Flush is called.
+	MaxWrites int
+
+	db  DB
+	txn TxnCommitDiscarder
+
+	// log holds the writes made in txn, and its first checkpoint entries
+	// are those made before the last checkpoint.
+	log        []Change
+	checkpoint int
+}
+
+// NewBatch returns a new Batch that writes to db.
+//
+// Call Flush to commit the final writes, and Discard when the Batch is no
+// longer in use.
+func NewBatch(db DB) *Batch {
+	return &Batch{MaxWrites: DefaultBatchWrites, db: db, txn: db.NewTxn(true)}
+}
+
+// Alloc implements Txn.Alloc.
+func (b *Batch) Alloc() (Entity, error) { return b.txn.Alloc() }
+
+// Get implements Txn.Get.
+func (b *Batch) Get(key []byte, f func([]byte) error) error { return b.txn.Get(key, f) }
+
+// PrefixIterator implements Txn.PrefixIterator.
+//
+// Iterators must be discarded before the next write, since any write may
+// begin a new transaction.
+func (b *Batch) PrefixIterator(prefix []byte) Iterator { return b.txn.PrefixIterator(prefix) }
+
+// ReversePrefixIterator implements Txn.ReversePrefixIterator.
+//
+// Iterators must be discarded before the next write, since any write may
+// begin a new transaction.
+func (b *Batch) ReversePrefixIterator(prefix []byte) Iterator {
+	return b.txn.ReversePrefixIterator(prefix)
+}
+
+// Set implements Txn.Set.
+func (b *Batch) Set(key, value []byte) error {
+	return b.write(Change{
+		Key:   append([]byte(nil), key...),
+		Value: append([]byte{}, value...),
+	})
+}
+
+// Delete implements Txn.Delete.
+func (b *Batch) Delete(key []byte) error {
+	return b.write(Change{Key: append([]byte(nil), key...)})
+}
+
+// write applies c, where a nil c.Value represents a deletion.
+func (b *Batch) write(c Change) error {
+	err := apply(b.txn, c)
+	if err == ErrTxnTooBig && b.checkpoint > 0 {
+		if err = b.split(); err == nil {
+			err = apply(b.txn, c)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	b.log = append(b.log, c)
+	return nil
+}
+
+func apply(txn Txn, c Change) error {
+	if c.Value == nil {
+		return txn.Delete(c.Key)
+	}
+	return txn.Set(c.Key, c.Value)
+}
+
+// split commits the writes made before the last checkpoint in a transaction
+// of their own, and then begins a new transaction with the writes made since.
+func (b *Batch) split() error {
+	b.txn.Discard()
+	b.txn = b.db.NewTxn(true)
+	for _, c := range b.log[:b.checkpoint] {
+		if err := apply(b.txn, c); err != nil {
+			return err
+		}
+	}
+	if err := b.txn.Commit(); err != nil {
+		return err
+	}
+	b.log = append(b.log[:0], b.log[b.checkpoint:]...)
+	b.checkpoint = 0
+	b.txn.Discard()
+	b.txn = b.db.NewTxn(true)
+	for _, c := range b.log {
+		if err := apply(b.txn, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Checkpoint implements Checkpointer, and commits the writes made so far if
+// there are at least MaxWrites of them.
+func (b *Batch) Checkpoint() error {
+	b.checkpoint = len(b.log)
+	if b.MaxWrites > 0 && len(b.log) >= b.MaxWrites {
+		return b.Flush()
+	}
+	return nil
+}
+
+// Flush commits all the writes made so far, whether or not they precede a
+// checkpoint, and begins a new transaction.
+func (b *Batch) Flush() error {
+	if err := b.txn.Commit(); err != nil {
+		return err
+	}
+	b.txn.Discard()
+	b.txn = b.db.NewTxn(true)
+	b.log, b.checkpoint = b.log[:0], 0
+	return nil
+}
+
+// Discard discards any writes that have not yet been committed.
+func (b *Batch) Discard() {
+	b.txn.Discard()
+	b.log, b.checkpoint = b.log[:0], 0
+}
@@ -0,0 +1,142 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/note-maps/kv"
+	"github.com/google/note-maps/kv/memory"
+)
+
+// limitedDB records the number of writes in each commit, and fails writes
+// beyond the limit of each transaction with kv.ErrTxnTooBig.
+type limitedDB struct {
+	kv.DB
+	limit   int
+	commits []int
+}
+
+func (db *limitedDB) NewTxn(update bool) kv.TxnCommitDiscarder {
+	return &limitedTxn{TxnCommitDiscarder: db.DB.NewTxn(update), db: db}
+}
+
+type limitedTxn struct {
+	kv.TxnCommitDiscarder
+	db     *limitedDB
+	writes int
+}
+
+func (t *limitedTxn) Set(key, value []byte) error {
+	if t.writes == t.db.limit {
+		return kv.ErrTxnTooBig
+	}
+	t.writes++
+	return t.TxnCommitDiscarder.Set(key, value)
+}
+
+func (t *limitedTxn) Commit() error {
+	t.db.commits = append(t.db.commits, t.writes)
+	return t.TxnCommitDiscarder.Commit()
+}
+
+// updatePairs makes n updates through b that each read the previous update
+// and then write two keys.
+func updatePairs(t *testing.T, b *kv.Batch, n int) {
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			var got string
+			if err := b.Get([]byte(fmt.Sprintf("a%03d", i-1)), func(bs []byte) error {
+				got = string(bs)
+				return nil
+			}); err != nil {
+				t.Fatal(err)
+			} else if got != fmt.Sprint(i-1) {
+				t.Fatalf("update %v: want to read %q, got %q", i, fmt.Sprint(i-1), got)
+			}
+		}
+		for _, k := range []string{"a", "b"} {
+			if err := b.Set([]byte(fmt.Sprintf("%v%03d", k, i)), []byte(fmt.Sprint(i))); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := b.Checkpoint(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := b.Flush(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func countKeys(t *testing.T, db kv.DB) int {
+	txn := db.NewTxn(false)
+	defer txn.Discard()
+	iter := txn.PrefixIterator(nil)
+	defer iter.Discard()
+	n := 0
+	for iter.Seek(nil); iter.Valid(); iter.Next() {
+		n++
+	}
+	return n
+}
+
+func TestBatchTooBig(t *testing.T) {
+	db := &limitedDB{DB: memory.NewDB(), limit: 5}
+	defer db.Close()
+	b := kv.NewBatch(db)
+	b.MaxWrites = 0
+	defer b.Discard()
+	updatePairs(t, b, 10)
+	if got := countKeys(t, db); got != 20 {
+		t.Errorf("want 20 keys, got %v", got)
+	}
+	for _, n := range db.commits {
+		if n%2 != 0 || n > db.limit {
+			t.Errorf("want whole updates of at most %v writes in each commit, got %v", db.limit, db.commits)
+			break
+		}
+	}
+}
+
+func TestBatchMaxWrites(t *testing.T) {
+	db := &limitedDB{DB: memory.NewDB(), limit: -1}
+	defer db.Close()
+	b := kv.NewBatch(db)
+	b.MaxWrites = 5
+	defer b.Discard()
+	updatePairs(t, b, 10)
+	if got := countKeys(t, db); got != 20 {
+		t.Errorf("want 20 keys, got %v", got)
+	}
+	want := []int{6, 6, 6, 2}
+	if fmt.Sprint(want) != fmt.Sprint(db.commits) {
+		t.Errorf("want commits of %v writes, got %v", want, db.commits)
+	}
+}
+
+func TestBatchUpdateTooBig(t *testing.T) {
+	db := &limitedDB{DB: memory.NewDB(), limit: 1}
+	defer db.Close()
+	b := kv.NewBatch(db)
+	defer b.Discard()
+	if err := b.Set([]byte("a"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Set([]byte("b"), nil); err != kv.ErrTxnTooBig {
+		t.Errorf("want %v for an update too big for any transaction, got %v", kv.ErrTxnTooBig, err)
+	}
+}
@@ -0,0 +1,260 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bolt provides a bbolt-backed implementation of kv.DB.
+//
+// A bbolt database is a single file, which makes it a good fit for devices
+// with limited resources. Only one update transaction can be open at a time,
+// so NewTxn(true) blocks until any other update transaction is committed or
+// discarded.
+package bolt
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"sync"
+
+	"github.com/google/note-maps/kv"
+	"github.com/google/note-maps/kv/internal/watch"
+	bolt "go.etcd.io/bbolt"
+)
+
+// bucket holds all key-value pairs.
+var bucket = []byte("kv")
+
+// DB holds some kv-specific state in addition to mixing in a bolt.DB.
+type DB struct {
+	*bolt.DB
+	a   *kv.Allocer
+	hub *watch.Hub
+
+	// commitMutex ensures that changes are published in the order in which
+	// they are committed.
+	commitMutex sync.Mutex
+}
+
+// Open creates or opens the database in the file at path.
+//
+// The mode and options are passed to bolt.Open, and options may be nil.
+func Open(path string, mode os.FileMode, options *bolt.Options) (*DB, error) {
+	bdb, err := bolt.Open(path, mode, options)
+	if err != nil {
+		return nil, err
+	}
+	if !bdb.IsReadOnly() {
+		err = bdb.Update(func(tx *bolt.Tx) error {
+			_, err := tx.CreateBucketIfNotExists(bucket)
+			return err
+		})
+		if err != nil {
+			bdb.Close()
+			return nil, err
+		}
+	}
+	db := &DB{DB: bdb, hub: watch.NewHub()}
+	db.a = kv.NewAllocer(db, []byte{1})
+	return db, nil
+}
+
+// Reserve ensures that transactions will never allocate e or any smaller
+// Entity.
+func (db *DB) Reserve(e kv.Entity) error { return db.a.Reserve(e) }
+
+// Close releases unallocated Entity values and closes the database.
+func (db *DB) Close() error {
+	if db == nil {
+		return nil
+	}
+	db.hub.Close()
+	if db.a != nil && !db.DB.IsReadOnly() {
+		db.a.Save()
+	}
+	return db.DB.Close()
+}
+
+// NewTxn creates a new kv.Txn.
+//
+// If the underlying bolt transaction cannot be started, every method of the
+// resulting kv.Txn will return the same error.
+func (db *DB) NewTxn(update bool) kv.TxnCommitDiscarder {
+	tx, err := db.DB.Begin(update)
+	if err != nil {
+		return &txn{db: db, err: err}
+	}
+	t := &txn{db: db, tx: tx, b: tx.Bucket(bucket)}
+	if t.b == nil {
+		t.err = bolt.ErrBucketNotFound
+	}
+	return t
+}
+
+// Watch passes committed changes to keys matching prefix to f until ctx is
+// done or f returns an error.
+func (db *DB) Watch(ctx context.Context, prefix []byte, f func([]kv.Change) error) error {
+	return db.hub.Watch(ctx, prefix, f)
+}
+
+type txn struct {
+	db      *DB
+	tx      *bolt.Tx
+	b       *bolt.Bucket
+	err     error
+	changes []kv.Change
+}
+
+func (s *txn) Alloc() (kv.Entity, error) {
+	return s.db.a.Alloc()
+}
+
+func (s *txn) Set(key, value []byte) error {
+	if s.err != nil {
+		return s.err
+	}
+	// bbolt keeps value until the transaction is committed, so it is given
+	// the same copy that is kept for watchers.
+	c := kv.Change{
+		Key:   append([]byte(nil), key...),
+		Value: append([]byte{}, value...),
+	}
+	if err := s.b.Put(c.Key, c.Value); err != nil {
+		return err
+	}
+	s.changes = append(s.changes, c)
+	return nil
+}
+
+func (s *txn) Delete(key []byte) error {
+	if s.err != nil {
+		return s.err
+	}
+	if err := s.b.Delete(key); err != nil {
+		return err
+	}
+	s.changes = append(s.changes, kv.Change{Key: append([]byte(nil), key...)})
+	return nil
+}
+
+func (s *txn) Get(key []byte, f func([]byte) error) error {
+	if s.err != nil {
+		return s.err
+	}
+	return f(s.b.Get(key))
+}
+
+func (s *txn) PrefixIterator(prefix []byte) kv.Iterator {
+	return s.iterator(prefix, false)
+}
+
+func (s *txn) ReversePrefixIterator(prefix []byte) kv.Iterator {
+	return s.iterator(prefix, true)
+}
+
+func (s *txn) iterator(prefix []byte, reverse bool) kv.Iterator {
+	iter := &iterator{prefix: append([]byte(nil), prefix...), reverse: reverse}
+	if s.err == nil {
+		iter.c = s.b.Cursor()
+	}
+	return iter
+}
+
+func (s *txn) Discard() {
+	if s.tx != nil {
+		s.tx.Rollback()
+	}
+}
+
+func (s *txn) Commit() error {
+	if s.err != nil {
+		return s.err
+	}
+	if !s.tx.Writable() {
+		return s.tx.Rollback()
+	}
+	s.db.commitMutex.Lock()
+	defer s.db.commitMutex.Unlock()
+	if err := s.tx.Commit(); err != nil {
+		return err
+	}
+	if len(s.changes) > 0 {
+		s.db.hub.Publish(s.changes)
+	}
+	return nil
+}
+
+// iterator visits the keys of a bolt.Cursor that match a prefix.
+type iterator struct {
+	c          *bolt.Cursor
+	prefix     []byte
+	reverse    bool
+	key, value []byte
+}
+
+func (i *iterator) Seek(key []byte) {
+	if i.c == nil {
+		return
+	}
+	target := append(append([]byte(nil), i.prefix...), key...)
+	if !i.reverse {
+		i.key, i.value = i.c.Seek(target)
+		return
+	}
+	// Find the last key less than or equal to target, or the last key with
+	// the prefix if key is empty.
+	if len(key) == 0 {
+		target = successor(i.prefix)
+	} else {
+		target = append(target, 0)
+	}
+	if target == nil {
+		i.key, i.value = i.c.Last()
+		return
+	}
+	if k, _ := i.c.Seek(target); k == nil {
+		i.key, i.value = i.c.Last()
+	} else {
+		i.key, i.value = i.c.Prev()
+	}
+}
+
+func (i *iterator) Next() {
+	if i.reverse {
+		i.key, i.value = i.c.Prev()
+	} else {
+		i.key, i.value = i.c.Next()
+	}
+}
+
+func (i *iterator) Valid() bool {
+	return i.key != nil && bytes.HasPrefix(i.key, i.prefix)
+}
+
+func (i *iterator) Key() []byte { return i.key[len(i.prefix):] }
+
+func (i *iterator) Value(f func([]byte) error) error { return f(i.value) }
+
+func (i *iterator) Discard() {}
+
+// successor returns the least key greater than all keys with the given
+// prefix, or nil if there is no such key.
+func successor(prefix []byte) []byte {
+	for n := len(prefix) - 1; n >= 0; n-- {
+		if prefix[n] != 0xff {
+			s := append([]byte(nil), prefix[:n+1]...)
+			s[n]++
+			return s
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bolt
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/note-maps/kv"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Generic behaviour is covered by TestConformance; these tests cover what is
+// particular to a database in a single locked file.
+
+func open(t *testing.T) (*DB, string) {
+	dir, err := ioutil.TempDir("", "kv-bolt-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "test.db")
+	db, err := Open(path, 0600, nil)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return db, path
+}
+
+func TestReopen(t *testing.T) {
+	db, path := open(t)
+	defer os.RemoveAll(filepath.Dir(path))
+	txn := db.NewTxn(true)
+	if err := txn.Set([]byte("key"), []byte("value")); err != nil {
+		t.Fatal(err)
+	}
+	allocated := make(map[kv.Entity]bool)
+	for i := 0; i < 10; i++ {
+		e, err := txn.Alloc()
+		if err != nil {
+			t.Fatal(err)
+		}
+		allocated[e] = true
+	}
+	if err := txn.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := Open(path, 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	txn = db.NewTxn(false)
+	defer txn.Discard()
+	var got string
+	if err = txn.Get([]byte("key"), func(bs []byte) error {
+		got = string(bs)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	} else if got != "value" {
+		t.Errorf("want %#v, got %#v", "value", got)
+	}
+	if e, err := txn.Alloc(); err != nil {
+		t.Fatal(err)
+	} else if allocated[e] {
+		t.Errorf("allocated %v again after reopening", e)
+	}
+}
+
+func TestLock(t *testing.T) {
+	db, path := open(t)
+	defer os.RemoveAll(filepath.Dir(path))
+	// bbolt locks the file, so a second DB cannot open it until the first
+	// is closed.
+	if other, err := Open(path, 0600, &bolt.Options{Timeout: 50 * time.Millisecond}); err != bolt.ErrTimeout {
+		other.Close()
+		t.Fatalf("want %v opening a file that is already open, got %v", bolt.ErrTimeout, err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+	other, err := Open(path, 0600, &bolt.Options{Timeout: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	other.Close()
+}
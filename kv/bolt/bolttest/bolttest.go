@@ -0,0 +1,58 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bolttest provides utilities to help test packages with databases
+// backed by kv/bolt.
+//
+// It is separate from package kvtest so that only tests that use kv/bolt link
+// it.
+package bolttest
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/note-maps/kv"
+	"github.com/google/note-maps/kv/bolt"
+)
+
+// NewDB returns a new kv.DB backed by kv/bolt suitable for use in a unit test.
+//
+// It's still important to call Close() in order to delete any temporary files
+// created by the kv.DB.
+func NewDB(t testing.TB) kv.DB {
+	dir, err := ioutil.TempDir("", "kvtest-bolt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := bolt.Open(filepath.Join(dir, "kvtest.db"), 0600, nil)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return &tmpDB{db, dir}
+}
+
+type tmpDB struct {
+	kv.DB
+	dir string
+}
+
+func (db *tmpDB) Close() error {
+	db.DB.Close()
+	os.RemoveAll(db.dir)
+	return nil
+}
@@ -0,0 +1,27 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bolt_test
+
+import (
+	"testing"
+
+	"github.com/google/note-maps/kv"
+	"github.com/google/note-maps/kv/bolt/bolttest"
+	"github.com/google/note-maps/kv/kvtest"
+)
+
+func TestConformance(t *testing.T) {
+	kvtest.RunConformance(t, func() kv.DB { return bolttest.NewDB(t) })
+}
@@ -77,7 +77,7 @@ func (fi bindataFileInfo) Sys() interface{} {
 	return nil
 }
 
-var _templatesKvschemaGotmpl = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xcc\x58\x5d\x6f\xe3\xb8\x15\x7d\x36\x7f\xc5\xad\x1f\x0a\x69\x46\x23\x67\xf2\x34\xcd\x20\x05\xbc\x49\x3a\x35\x3a\x9b\x2c\x62\x4f\x07\x8b\x20\x28\x68\xe9\x5a\x22\x2c\x93\x1a\x92\x92\xe3\x0a\xfa\xef\xc5\xa5\xbe\x6c\xc7\x49\x30\x05\x16\xd8\xa7\xc4\x22\xef\xe7\x39\x3c\xbc\x52\x55\x4d\xde\x01\xbb\x52\xf9\x4e\x8b\x24\xb5\x70\x7e\xf6\xf1\x6f\xf0\x45\xa9\x24\x43\xf8\xfa\xf5\x8a\xb1\xaf\x22\x42\x69\x30\x86\x42\xc6\xa8\xc1\xa6\x08\xd3\x9c\x47\x29\x42\xbb\x12\xc0\xbf\x51\x1b\xa1\x24\x9c\x87\x67\xe0\xd1\x86\x71\xbb\x34\xf6\x3f\xb3\x9d\x2a\x60\xc3\x77\x20\x95\x85\xc2\x20\xd8\x54\x18\x58\x89\x0c\x01\x9f\x22\xcc\x2d\x08\x09\x91\xda\xe4\x99\xe0\x32\x42\xd8\x0a\x9b\xba\x20\xad\x8b\x90\xfd\xde\x3a\x50\x4b\xcb\x85\x04\x0e\x91\xca\x77\xa0\x56\xfb\xbb\x80\x5b\xc6\x00\x00\x52\x6b\x73\x73\x31\x99\x6c\xb7\xdb\x90\xbb\x34\x43\xa5\x93\x49\xd6\x6c\x33\x93\xaf\xb3\xab\x9b\xdb\xf9\xcd\x87\xf3\xf0\x8c\xb1\x6f\x32\x43\x63\x40\xe3\x8f\x42\x68\x8c\x61\xb9\x03\x9e\xe7\x99\x88\xf8\x32\x43\xc8\xf8\x16\x94\x06\x9e\x68\xc4\x18\xac\xa2\x44\xb7\x5a\x58\x21\x93\x00\x8c\x5a\xd9\x2d\xd7\xc8\x62\x61\xac\x16\xcb\xc2\x1e\x74\xa8\x4b\x4b\x18\xd8\xdf\xa0\x24\x70\x09\xe3\xe9\x1c\x66\xf3\x31\xfc\x32\x9d\xcf\xe6\x01\xfb\x3e\x5b\xfc\xf3\xee\xdb\x02\xbe\x4f\xef\xef\xa7\xb7\x8b\xd9\xcd\x1c\xee\xee\xe1\xea\xee\xf6\x7a\xb6\x98\xdd\xdd\xce\xe1\xee\x1f\x30\xbd\xfd\x1d\xfe\x35\xbb\xbd\x0e\x00\x85\x4d\x51\x03\x3e\xe5\x9a\x72\x57\x1a\x04\xf5\x0e\xe3\x90\xcd\x11\x0f\x82\xaf\x54\x93\x8c\xc9\x31\x12\x2b\x11\x41\xc6\x65\x52\xf0\x04\x21\x51\x25\x6a\x29\x64\x02\x39\xea\x8d\x30\x84\x9e\x01\x2e\x63\x96\x89\x8d\xb0\xdc\xba\xdf\xcf\xca\x09\xd9\xbb\x49\x5d\x33\x56\x55\x31\xae\x84\x44\x18\xaf\x4b\x13\xa5\xb8\xe1\x61\xa2\xc6\x75\x3d\x99\xc0\x95\x8a\x11\x12\x94\xa8\xb9\x6d\x3a\xda\xef\x19\x7f\x86\xeb\x3b\xb8\xbd\x5b\xc0\xcd\xf5\x6c\x11\x32\x96\xf3\x68\x4d\xd9\x54\x55\xf8\x5b\xf3\x6f\x78\xcb\x37\x48\x11\xc4\x26\x57\xda\x82\xc7\x46\xe3\x44\xd8\xb4\x58\x86\x91\xda\x4c\x12\x47\xcb\x89\x54\x16\x3f\x6c\x78\x6e\x26\xeb\x72\xcc\x7c\xc6\x26\x13\x58\x3c\x49\xc8\xb5\x2a\x45\x8c\x06\x50\x5a\x61\x05\x9a\xc0\x11\x4b\x49\x94\xd6\x04\x54\x1e\x08\x19\xe3\x13\x1a\x58\xf2\x68\xdd\x02\x0e\x6b\xdc\x7d\x28\x79\x56\x20\x18\xab\x34\x86\xcc\xee\x72\x74\x0e\x8d\xd5\x45\x64\x2b\x58\x97\xe1\x6f\x5c\x93\x4f\x25\x31\x86\x9a\xb1\x55\x21\x23\xb8\xc5\xad\x67\x69\x71\xf1\x24\x7d\x67\x50\x81\x46\x5b\x68\x49\x3f\xaa\x43\xab\xca\x06\x70\x56\xd7\x50\xb3\xaa\xd2\x5c\x26\x08\xe1\x55\x97\xdc\x62\x97\xa3\xa9\xeb\xaa\xb2\xb8\xc9\x33\x6e\x11\xc6\x7d\xe2\x63\x08\x69\x05\x65\xdc\xff\xd9\x07\x60\xd8\x57\xd7\xd4\x87\x39\xda\xaa\x6a\xdb\x08\x06\xad\x71\xf8\x0d\x8f\xb8\x31\x2a\x12\x0e\x1b\x77\xd2\x90\x88\x5d\x86\x6c\x32\x61\x0e\x3d\xad\xd1\xe4\x4a\xc6\xc4\x8d\xae\x59\x5c\x23\x14\x79\x4c\x46\x61\x53\xb9\x67\xc0\xd5\xbc\x1f\xcd\x43\x6a\xc5\x0d\xb5\x7e\x17\x40\x09\x55\x25\x56\x10\x5e\x0b\x8d\x91\xbd\x91\x91\x8a\x51\xbb\x0a\x32\x83\x75\xfd\xae\xaf\xa8\xb5\xf6\x01\xb5\x56\x1a\x2a\x36\x5a\xe3\x0e\x2e\x2e\x61\xc3\xd7\xe8\x51\x0f\x35\xae\xc4\x53\x00\x9f\xde\x9f\xbf\xff\xe4\xb3\x91\x19\xba\x1a\x36\x7e\xa7\xd6\x5b\xe3\xce\x67\x23\x22\x92\xdb\xdd\xf8\x3c\x58\x7e\xf8\x74\xf1\xe8\xb3\x11\x1e\x3e\xfc\x78\xe6\x9e\x56\x15\x50\xb2\xb3\xb6\xe0\xba\x2e\xb9\x06\x95\xc5\x43\xe3\xd8\x48\xac\x28\x45\xca\xcc\x84\x5f\xd0\x99\x07\xb4\x27\xbc\x46\x72\xe8\x7f\x76\xcb\x7f\xb9\x04\x29\x32\x2a\x63\xd4\x52\x01\xb5\x66\xa3\x23\xfb\x79\x67\x5f\xb6\xe9\x78\xfe\x9b\xf6\x19\xae\xc9\x38\x43\xd9\x56\xdb\x77\xdb\x3b\xf3\x4f\x56\x45\x8d\xbc\x24\x45\x43\x19\x37\xe1\xd6\xe5\x40\xba\xc1\xca\xf3\xc3\x30\xf4\xd9\x88\x8a\xf6\xd8\x68\x94\x89\x35\xec\x07\x1a\xa1\x81\x01\xdb\x39\x69\x29\x1b\xf9\x55\x05\x2d\x8f\x87\xb6\x31\x36\x9a\x4c\xe0\x9b\xe3\xca\x1e\xe9\x1c\x91\xba\x7c\x28\xc1\x8b\x0c\xd7\x8f\xe1\xd4\x65\x36\x24\x74\x04\x9f\xcf\x46\xa4\x60\xff\x09\x40\x94\x54\x79\x13\x8d\x3a\x5e\x55\xe1\xaf\x68\x53\x15\xb7\xcc\xf3\x5d\xbf\x8e\xcb\x7d\xb8\xc8\xc4\xfa\x91\xac\x8f\xea\x3c\x0d\x25\x9a\x17\x91\x3c\x80\x82\xb0\x70\x1e\x4c\x78\x8f\x1b\x55\xa2\x87\x4d\xfc\xd3\x08\xa3\x79\x05\xe2\x23\xcf\xce\x75\xed\xd0\x3e\x51\x79\xf9\xa7\xa9\x7b\x26\x0d\x6a\xfb\x87\xd4\xdd\x3e\x97\x22\xab\x2a\x40\x19\x03\x69\x04\x90\x68\x40\x5d\xb7\x8b\xa7\xcf\x4f\xbf\x9f\xd5\xee\x46\xf8\xb2\xaf\x84\x8d\xe5\x9b\x62\xd8\xe9\xe0\x6c\x05\x52\xed\x6d\x4c\xb9\x81\x25\xa2\xa4\x6b\x37\x13\x91\xb0\xd9\x8e\xc4\xd5\xdd\xb0\xd8\xdc\x2c\x07\xe1\xb6\x22\xcb\xda\x98\xe4\x8e\xa2\x6a\x34\x45\x66\x69\x6c\x89\xa9\xdb\xa4\xaf\x7c\x2f\xc2\x4a\xab\x0d\xcd\x06\xb8\xc9\xed\x0e\x0c\x9d\x31\xda\xbb\xdc\x59\x34\x47\xa2\xfb\xe5\x05\xd1\xf5\xc1\xeb\x9f\x07\x8d\x9c\x3a\x80\xe8\x58\x97\xfb\x4a\x56\x9a\xe0\x80\x07\xfd\x92\x3b\xda\xde\xc3\x63\xef\xb2\x42\x3a\x85\x62\xe5\xc4\xa0\x34\x3e\xfc\xfd\x12\x3e\x3a\x04\x4b\xb8\x84\xd2\x3c\x9c\x3d\xee\xa3\x56\x3a\xbf\x27\xfa\xef\x1c\xf7\x20\x1c\xd4\x4d\x1d\xe4\x51\xda\xdc\xd9\x3b\x9a\xb1\xa8\xe0\x9f\x87\x81\x7a\xd7\xde\x3d\x04\xc7\x5e\xcb\x09\x0c\xf2\xb6\xc4\x83\xc8\x36\xe5\x76\xf0\xe8\x40\xc1\xf8\xff\xc5\xa1\xe9\x1c\x1a\xd8\x6b\x9e\x0f\xde\xc3\xe3\x49\x44\xda\xc4\xba\x4b\xee\x60\x17\x75\x1a\x8d\xef\xff\xc1\xf7\x20\xb5\x4c\x04\x80\x83\xbc\xa0\x71\xc0\x9e\xbe\x20\x47\xcf\x75\xc3\xfb\x6b\x53\xc6\x83\x78\xf4\x3b\x05\x19\x34\xe6\x84\x8a\x48\x91\x05\x83\x94\x0c\xac\x69\xdc\x04\xb4\xde\x52\x67\x9a\x65\x7d\x47\x6e\xda\x59\xee\xe0\x08\xaf\x84\x36\x16\x64\x3f\xe8\x75\x60\x96\x07\x10\x07\xb0\xc4\x44\x48\x9a\x73\xc9\x6b\xff\x66\xd1\x58\xb7\x84\x4b\x34\x72\xeb\xc6\x5c\x2e\x69\x9a\xc6\x1f\x05\xcf\x68\x28\x7a\x67\x2c\xd7\xb6\xa3\xe2\xd4\x95\xe3\x1e\x41\x33\x2c\xba\x33\xbe\x44\x10\xd2\xa2\xce\x35\x92\x8a\x70\x22\x77\xae\xdc\x23\xf2\xf1\x5f\xd4\x6a\xf0\xd0\xd8\xa9\x15\x48\x70\xef\x1d\xcf\x42\xd2\xf6\xe7\x7e\x5b\xc7\x94\x79\xc6\x75\x82\xc6\x92\xbb\x5c\x19\x23\xe8\x35\xc5\x79\x3d\xa2\xe6\xa9\x06\x7a\x4d\xf2\xef\xf6\x86\x34\x49\x41\x7c\x38\xe2\x6d\x23\x0e\xfb\x6c\x6d\x55\x77\x9a\x65\xfd\x65\xdd\x7b\x3d\xe2\x5a\xd0\xf4\x28\x00\xe9\xb3\xba\x9f\x74\xdb\x09\xa1\x6e\xe0\xed\x6c\x7f\xe5\x36\x4a\x85\x4c\xaa\x6a\x98\x4a\x1a\x2f\xcf\x85\xbb\x47\xda\xa1\xf8\xdc\xa2\x69\x43\x4b\x84\x36\x63\x0e\x9b\x36\x02\x19\xd0\x90\x7d\xf3\x94\xeb\x4e\x6c\x6d\x8a\x42\xc3\xd1\x95\x0a\x1b\xf7\xa3\xc3\x6c\x91\x76\xaa\x85\x31\xec\x8d\x3f\xf4\x5e\xc7\x33\x8d\x3c\xde\x81\x51\xfa\xf9\x60\xfc\x13\x25\x7a\xe5\x61\x76\x3e\x78\x87\xb3\xd6\xbe\x72\xbc\xa6\x09\xef\xcf\xdf\x54\x85\x3e\x87\xb7\xe4\xe1\xcd\xd9\xf2\x55\x89\xf9\xf8\xe9\x85\xf9\xf3\x78\x22\xa1\xab\x09\xcd\xb3\xd9\xb2\x1b\x0e\x4c\x70\x7a\x50\x61\x87\x3c\xfa\x65\xf7\xd3\x0c\x22\xf3\x97\x49\xa4\x74\x8c\xed\xf7\x80\x76\x60\xd8\x23\x4f\xbb\x67\xe0\x50\xeb\xeb\x15\x1a\xdd\x23\x77\x17\xbe\x93\x23\x03\xdc\x42\x54\x68\xa3\x74\x73\x55\xa1\x8c\x0d\x6c\x53\x94\xcd\x11\x47\x99\xd8\xb4\xfb\xbc\x71\x44\x3e\x72\x66\x3a\x02\x0e\x1a\x22\x43\xf8\x4e\xf6\xba\x8d\x23\x8c\xfb\xda\xe2\xbe\xaa\xa0\xc5\xa0\x0d\x47\xcf\xdb\xf7\x38\x30\x45\x94\x36\xa3\x09\xb7\x50\x18\x67\xe5\x3e\xc5\x70\x30\xc5\x12\x7f\x14\x28\x2d\x44\x3c\x73\xba\xe4\x1a\xdc\x8d\x36\xaa\xc8\xe2\xee\x84\x49\x7c\xb2\xe0\x46\x9c\xae\xbb\x2f\x9c\x83\x57\x21\xf2\xda\xf4\x48\x9a\x9c\x52\x5c\xb5\xdd\xf9\x49\x7d\x1a\x82\xf5\x91\x9c\x3b\xef\x25\xe6\x07\xf0\x4c\xbd\x3a\x60\x1a\xf9\x6a\x5e\xb7\xbb\xbf\xff\x0b\x00\x00\xff\xff\x10\x3d\x9c\xf7\x2b\x13\x00\x00")
+var _templatesKvschemaGotmpl = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xdc\x5b\x5f\x73\xe3\x36\x92\x7f\x16\x3f\x45\x9f\x6b\x2b\x45\x4d\xb8\x94\x93\xa7\xdc\x64\x7d\x55\x8e\xc7\x49\x5c\x99\x9d\xc9\x8d\x9d\xa4\xb6\x5c\xae\x2b\x88\x6c\x89\x38\x53\x00\x07\x80\x68\x2b\x2c\x7e\xf7\xab\x06\xc0\xbf\xa2\x2d\x39\xb3\x93\xdb\xda\x07\x97\x25\x12\xe8\x6e\x74\xff\xfa\x0f\x1a\x50\x55\x2d\x5e\x41\x70\x21\x8b\x9d\xe2\xeb\xcc\xc0\xd7\xa7\x5f\xfd\x27\xfc\x20\xe5\x3a\x47\x78\xfb\xf6\x22\x08\xde\xf2\x04\x85\xc6\x14\xb6\x22\x45\x05\x26\x43\x38\x2f\x58\x92\x21\xf8\x37\x11\xfc\x8a\x4a\x73\x29\xe0\xeb\xf8\x14\x42\x1a\x70\xe2\x5f\x9d\xcc\xbf\x0d\x76\x72\x0b\x1b\xb6\x03\x21\x0d\x6c\x35\x82\xc9\xb8\x86\x15\xcf\x11\xf0\x31\xc1\xc2\x00\x17\x90\xc8\x4d\x91\x73\x26\x12\x84\x07\x6e\x32\x30\x1d\xf5\x38\xf8\x87\x27\x20\x97\x86\x71\x01\x0c\x12\x59\xec\x40\xae\xfa\xa3\x80\x99\x20\x00\x00\xc8\x8c\x29\xf4\xeb\xc5\xe2\xe1\xe1\x21\x66\x56\xcc\x58\xaa\xf5\x22\x77\xc3\xf4\xe2\xed\xd5\xc5\xe5\xbb\xeb\xcb\xbf\x7e\x1d\x9f\x06\xc1\x2f\x22\x47\xad\x41\xe1\xc7\x2d\x57\x98\xc2\x72\x07\xac\x28\x72\x9e\xb0\x65\x8e\x90\xb3\x07\x90\x0a\xd8\x5a\x21\xa6\x60\x24\x09\xfa\xa0\xb8\xe1\x62\x1d\x81\x96\x2b\xf3\xc0\x14\x06\x29\xd7\x46\xf1\xe5\xd6\x0c\x34\xd4\x88\xc5\x35\xf4\x07\x48\x01\x4c\xc0\xc9\xf9\x35\x5c\x5d\x9f\xc0\x77\xe7\xd7\x57\xd7\x51\xf0\xdb\xd5\xcd\x8f\xef\x7f\xb9\x81\xdf\xce\x3f\x7c\x38\x7f\x77\x73\x75\x79\x0d\xef\x3f\xc0\xc5\xfb\x77\x6f\xae\x6e\xae\xde\xbf\xbb\x86\xf7\xdf\xc3\xf9\xbb\x7f\xc0\x4f\x57\xef\xde\x44\x80\xdc\x64\xa8\x00\x1f\x0b\x45\xb2\x4b\x05\x9c\x74\x87\x69\x1c\x5c\x23\x0e\x98\xaf\xa4\x33\x97\x2e\x30\xe1\x2b\x9e\x40\xce\xc4\x7a\xcb\xd6\x08\x6b\x59\xa2\x12\x5c\xac\xa1\x40\xb5\xe1\x9a\xac\xa7\x81\x89\x34\xc8\xf9\x86\x1b\x66\xec\xf7\xbd\xe5\xc4\xc1\xab\x45\x5d\x07\x41\x55\xa5\xb8\xe2\x02\xe1\xe4\xbe\xd4\x49\x86\x1b\x16\xaf\xe5\x49\x5d\x2f\x16\x70\x21\x53\x84\x35\x0a\x54\x8c\x16\xbc\xdc\x75\x63\x4e\xbe\x85\x37\xef\xe1\xdd\xfb\x1b\xb8\x7c\x73\x75\x13\x07\x41\xc1\x92\x7b\x92\xa6\xaa\xe2\x9f\xdd\xc7\xf8\x1d\xdb\x20\x71\xe0\x9b\x42\x2a\x03\x61\x30\x3b\x49\xa4\x30\xf8\x68\x4e\x82\xaa\x02\xc5\xc4\x1a\x21\xbe\xb2\x6f\x35\xd4\x75\x30\xab\x2a\x07\x19\x3b\x15\xea\xba\xaa\xe2\xba\x86\xaa\x02\x14\x29\xd4\xf5\x89\x25\x6e\x32\xfb\xc9\x3f\x0b\xe6\x41\xb0\x58\xc0\xcd\xa3\x80\x42\xc9\x92\xa7\xa8\x01\x85\xe1\x86\xa3\x8e\x2c\x16\xa5\x40\x61\x74\x44\x1a\x01\x2e\x52\x7c\x44\x0d\x4b\x96\xdc\x7b\x8c\xc0\x3d\xee\xfe\x5a\xb2\x7c\x8b\xa0\x8d\x54\x18\x07\x66\x57\xa0\x25\xa8\x8d\xda\x26\xa6\x82\xfb\x32\xfe\x99\x29\xa2\x29\x05\xa6\x50\x07\xc1\x6a\x2b\x12\x78\x87\x0f\xa1\xa1\x97\x37\x8f\x62\x6e\x27\x54\xa0\xd0\x6c\x95\xa0\x2f\xd5\x70\x56\x65\x22\x38\xad\x6b\x9a\xbc\x58\xc0\x7f\x6f\x51\xed\xfc\x60\x6d\xed\xba\xe2\x4a\x1b\x10\xad\xec\x60\x32\x66\x40\x33\xc3\xf5\x6a\x07\x45\x04\x4b\x5c\x73\x61\xcd\xdc\x7a\x95\x9d\x43\xab\xb7\x93\x76\xb0\x56\xc8\x8c\x05\x2d\x13\x20\x15\xe0\xc7\x2d\xcb\x09\xec\xaf\xb4\x61\xca\xc4\xc1\x62\x41\xa3\xcf\x41\xf0\x1c\xec\x23\x70\x0b\x7f\xe0\x79\x0e\x4b\x04\x2e\x0c\xaa\x42\x21\x59\x9b\x69\x60\x50\x48\xfb\x88\x68\xfc\x8e\x4a\x76\x14\xdc\x3c\xb9\x02\x01\xd6\xed\xf6\x58\xd2\xf0\x7d\xba\x9e\x30\x2d\x38\x67\x6a\x8d\xda\x10\xb9\x42\x6a\xcd\xc9\x4b\x2d\xd5\xd8\x69\x37\xd4\xa4\xc5\xb9\x53\x55\x58\xc0\x47\xfa\x1f\xff\xac\x30\xe5\x09\x33\x18\xf9\x05\xbc\xba\x2f\xe3\x4b\xbb\xfc\x08\x04\x31\x9a\x43\x78\x7b\xd7\x7b\x88\x4a\x49\x35\x87\x2a\x98\x79\xdb\x38\x42\x97\x5e\xcf\xa1\xee\x5b\x29\x22\x4d\x5b\xc2\x11\x88\x79\xe0\x8c\xf5\x2b\x2a\xbe\xda\x5d\x79\xe8\x24\x2c\xcf\x9d\xc9\xdc\x73\xd8\xa0\xc9\x64\xda\x3a\x68\x03\x31\xb9\x02\x64\x49\xd6\x41\x90\x48\x11\xb4\x1c\x12\x1b\xdb\xb3\x3c\xf7\xe1\x8f\x2b\x48\xf9\x6a\x85\x0a\x45\x82\x7a\xa4\x85\x81\x0c\x61\xb3\x46\xfb\xfd\x67\x25\x97\x39\x6e\xfa\x2b\x2d\x99\x22\x6f\xa0\xc7\x1a\xf6\x46\x06\x33\x92\xf5\x7f\x22\x28\x2d\x51\x78\x7d\xe6\x7d\xf1\xf6\x8e\x78\x3e\x4b\xbd\xaa\x4e\xaa\x93\xba\xee\xdc\xf7\xa2\x59\xde\xcd\xae\x40\xf2\xe2\xaa\x02\xbe\x82\xb8\x51\x17\xb9\xf5\x4c\xc7\x4e\xfe\xaa\xf2\x41\xc1\xbf\x8d\x5a\x37\xee\xfc\x79\x56\xd3\x0a\x66\x85\xb6\x0b\x22\xe1\x9c\x98\xe1\x3c\x98\xcd\xf8\xca\x3e\xfc\x8f\x33\x0b\x61\x1a\xd7\x58\x55\xf0\xdc\x4e\x08\x66\x33\xe2\xd8\xae\xfe\x8c\xb2\x00\x8a\x34\x6c\x9e\x44\x50\xe8\x38\x8e\xe7\xc1\xac\x6e\x21\xd1\xbd\x13\x3c\xf7\x56\xff\x80\xcb\x2d\xcf\xd3\x7d\xb3\xfb\x17\x2f\xb6\xfb\xc8\xa2\x43\xfa\xe1\x9c\x84\x97\x0a\xaa\xd6\x3a\xca\xf3\xd9\x37\x8f\x1d\xf9\x47\x2d\xe1\xf9\xbe\xc0\x14\x5e\xe9\x24\x87\x9b\x1b\xce\xbf\x7d\xca\x0c\xad\x05\x3a\xe5\x3a\x95\x56\xd5\xa4\x98\xc4\xce\xe0\xa6\xc8\x99\x41\x38\x69\x75\x76\x02\x31\xbd\x41\x91\xb6\xff\xfa\x99\xaa\x1b\x57\xd7\xe4\x56\xd7\x68\xda\xf5\x80\x46\xe3\x0c\xd5\x3d\x62\x5a\xcb\x84\xdb\x24\x66\x83\x27\x52\x40\x2b\x9b\x68\x76\x21\x95\x42\x5d\x48\x91\x52\x74\x6d\xec\xc8\x14\xc2\xb6\x48\x69\x52\xec\x35\xf9\x23\xd3\xbf\x08\xfe\x71\x8b\x50\xd7\x70\xb5\x02\xe6\xc3\x20\x19\x8c\xc1\xd6\xbd\xb2\xf3\x89\x2c\xcb\x15\xb2\x74\x07\x4b\xcc\xa5\x58\x6b\x62\xc9\x84\x74\x09\xdf\xc7\xa6\x81\xdc\x6d\x44\xa0\x94\xe2\xf8\xfc\xca\x65\x6e\xf3\xb7\xa5\x27\x52\x48\x32\x52\xa2\xa6\xfa\x2b\xe3\x62\x1d\x77\xb6\x1a\x60\xab\x4f\x37\x44\xe8\x45\xc3\x12\xaa\x8a\xdc\xf3\x0d\x57\x98\x98\x4b\x91\xc8\x14\x95\xd5\x71\xae\xb1\xae\x5f\xb5\x3a\xf7\xb3\x7b\xb0\xbc\xc7\x1d\x79\xe3\x86\xdd\x63\x48\xb9\x4d\xe1\x8a\x3f\x46\xf0\xcd\x97\x5f\x7f\xf9\xcd\x3c\x98\xf5\xe2\x68\xec\xe8\x9e\x9b\xf0\x1e\x77\x73\x4a\xeb\x7e\xb4\xa3\x39\x78\x7d\xfb\xcd\xeb\xbb\x79\x30\xc3\xe1\xc3\xaf\x4e\xed\xd3\x3d\x04\x53\x60\x93\x79\xda\x99\x36\x68\x42\xc2\xeb\x33\xd0\xf1\x0f\x68\xa7\x47\x20\xf3\x34\x7e\x83\x24\xc4\x3e\x54\xfb\x48\x75\x35\x87\x47\x66\xc7\xc6\xf3\x6d\x8d\xed\x3d\x92\x97\x5d\xac\x2c\xe3\xaa\x8a\xff\x6e\x43\x80\xd7\xf3\x7c\xe4\x2c\x3a\xbe\xc8\x30\xb9\x77\x44\x48\x63\x9e\xea\x4f\xb8\xa3\x22\xa2\xb6\xff\xc9\x80\xb9\x26\x40\x5d\x53\x59\xdb\xda\xf3\x2d\xdb\xc9\xad\x89\x68\xa1\xad\xc3\xf4\x75\x18\xc1\x48\xa9\xf6\x81\xd3\xe1\xe5\x63\xa1\xe0\x84\x97\x27\x34\x6c\x42\x01\x53\xbe\xda\x32\x6e\x3f\xf4\x17\x72\xdd\xe8\xb5\xf4\x66\x0a\xe7\x07\xf5\xda\x9f\xef\xfc\xa8\x35\x9a\x57\x74\x88\x11\x7c\x21\xf3\x34\x9a\xc6\xe4\x17\x1e\x8b\xe5\x41\x56\xfe\xeb\x7d\xe9\x34\x6e\x8b\x96\x50\xc7\xe4\x0a\x9d\x7e\x3f\x75\x3d\x07\x98\x58\x9d\xf9\xd4\xf1\x06\x73\xec\x2d\x17\x14\x6e\x64\x89\x07\x83\xd2\xf1\xf1\x68\x98\x49\x46\xec\xfa\x0e\xff\xef\xef\xbe\xfd\xf9\x4e\x11\x24\xc1\x3f\x15\x9d\x82\xe7\x07\xe9\x1d\x40\xc7\x3e\x04\x5f\x20\xeb\x91\xc8\xdb\xd3\x36\x61\x69\x7a\x6d\xfe\xb1\xee\x0a\x17\x50\xf2\xc1\xd5\x2e\x11\x3c\x64\x3c\xc9\x68\x7b\x6f\x37\xca\x19\x2b\xed\x4e\x94\xa8\xb5\x74\xc8\x42\xb6\x8c\x15\xf2\x01\x32\xa6\xa1\xa4\x69\xa8\xb0\xd9\xd2\x52\x93\x60\x89\xd6\x68\xb4\x6a\xc8\x58\x4a\xdb\x03\x1a\x2a\xa4\xc0\x11\x82\x9f\xb2\x40\x3f\x73\x59\x53\x94\xf0\x6a\x22\x35\xf9\x95\xff\xc8\xf4\x07\x5a\x45\x5d\x7f\x5e\xb0\xe7\x78\x4f\xc4\x73\x14\x7e\x0e\x71\x6b\xeb\x4c\x0b\xe0\xfb\xb2\x0b\xdb\xe1\xe9\xdc\x53\x09\xe7\xae\xf0\x5c\x2c\xe0\x92\x4a\x44\x25\x1f\x80\x6b\xdb\x87\x30\x28\x5c\x14\xb0\xfb\x51\x32\x04\x37\x1a\xe4\x83\x88\x40\x73\x6a\xa5\x30\xf2\x75\xdb\x3a\xb9\x47\x2c\xac\x41\x66\x8b\x05\x0d\xd6\x50\x30\xed\x8d\xc5\x4d\x1c\xcc\x46\x79\x26\xe7\x03\x71\x3b\x38\x52\x32\x0d\x83\xd9\x8c\x06\xf4\x97\x33\x43\x0d\x9d\xe6\x6d\x5e\x0a\x66\x3d\xa8\xb5\x9f\xa6\x52\xa7\x5d\xdd\x2f\xd6\x9e\x3d\xb8\x58\x8c\x39\xb9\xa4\x82\xf8\x06\x1f\x0d\xc4\xdf\x6f\x7f\xff\x7d\x47\x38\x9d\xf9\xb8\xd0\x8e\x8f\x40\xe0\x43\x37\xfb\xf6\xae\xaa\xe2\x9b\x5d\x61\x33\x9b\x0f\x1a\x04\xc0\x9e\xcb\xf4\x67\xc3\x19\xc1\x73\x3f\x3f\x37\x8e\x5f\xf6\x27\x0e\x18\x9d\x41\xf9\xf4\x34\xef\xba\x5e\xbb\x76\x09\x75\xbd\xda\xe6\x39\x75\x34\x3a\xad\x1a\xc5\xd7\x8a\x6d\x5a\x25\xc5\x4e\x19\xe3\x7d\xe6\x0b\x12\x3b\x46\xcf\x28\xe7\x40\xf4\xe8\xe4\x6a\x60\x4a\x38\x7e\x9d\xe3\x3d\xfd\xdd\xc5\xe7\x76\x73\xd4\x61\x75\xc4\x7c\x3e\xa5\xeb\x89\x6a\x68\x52\xdf\xd6\x30\x63\x34\x0e\x7d\xa5\xf4\x02\xd8\xb7\x16\x44\xbf\x52\x45\x4d\x40\xbd\x7d\x9d\xf3\x7b\xfa\xbb\x9b\x2e\x6d\xe6\x11\xe0\xc8\xaf\x66\xb3\x63\x23\xec\x50\x4b\xb3\xbe\x9e\xc6\xce\x7c\x48\x8e\x86\x35\x6a\x38\x03\xd4\xb7\xaf\x4f\xef\xc6\x92\xb4\x79\x0d\xf5\x93\x69\x6d\x4f\xa2\x86\x08\xed\xd8\xa8\x7c\x08\x71\xee\x07\xf6\x49\x5f\xa3\xe9\xb9\x69\xc3\x65\x8a\xfc\x88\xfe\xac\x6e\xd7\xed\x37\x7b\xb3\x7a\xd2\x41\x5e\x50\xfb\xfe\xff\xd9\xba\x2d\xe8\x6e\xef\x96\x3b\x83\x55\x7d\x84\x82\xff\x95\x4d\x7e\x25\x34\x2a\xf3\xa4\xc9\x5b\xd2\x8d\x4e\xa6\x88\x1f\x67\xf0\xee\x7b\xfb\xa9\x99\xd8\xf5\x42\x7c\xf8\xf6\x21\xc7\x9a\xcd\xa5\xad\xe9\x12\x82\x72\x5b\x3b\x9a\x3a\xed\x5a\x25\x60\x24\x51\xa2\x3e\xac\xa6\x00\x6a\x7b\x63\x0f\x54\x31\x68\xb4\xc7\x06\x04\xfe\x51\x59\x30\xc5\x36\x24\x5a\xf6\x6d\x5b\xd9\x62\xd7\x2a\xd2\x2a\x89\xcf\xf3\xbc\x9d\xd3\x76\xf9\x6c\x7b\xe8\x74\xde\x06\xf2\x9e\xa6\xfa\x6a\xaa\xdb\xee\x0b\x76\x70\x47\x6d\xc7\x95\x03\x2e\x3f\x0c\xb6\xd8\xcf\xf7\xa6\xda\x8d\xd6\x91\x65\xa7\x15\xf6\x8b\x89\x7d\xcf\x73\x04\xa7\xca\xc3\xc3\x04\xea\x60\xdf\xda\xd3\xbd\x3a\xdb\xc8\x64\x6a\xd2\xe2\xed\x58\xd7\x10\xd1\xed\xc1\x0f\x91\xb3\x95\x25\xb5\xcf\xd0\x35\xcb\x96\x3b\x4b\xc1\x8f\x34\x19\x6e\x34\xe6\x25\xea\x61\x5f\x94\x86\x1c\x6a\x87\x8e\x45\x3c\xd8\x17\xf5\x4b\x6d\xda\x91\x6d\xda\x6b\xe6\xef\xa5\x5e\x82\xa3\xeb\xf1\xf7\x92\x77\x0f\x7a\x8d\xf2\xa8\xdf\x6f\xea\x78\x1a\xb0\xd4\x6a\x6c\x7a\xc9\x4f\x74\xdf\x40\x61\x91\xb3\xe4\xf3\x29\x77\xa4\xbf\x27\xc4\xe8\x77\x21\x5b\x5d\xf9\xb1\x7f\xb2\xb2\xba\xb8\xb4\x58\x40\xdf\xd9\x06\x08\x39\x62\x37\x7d\xb5\x02\x21\x7b\x4a\xa4\x2d\xc8\x12\x51\xd0\xa9\x5b\xce\x13\x6e\xf2\x1d\xb5\x0c\x2d\x34\x7d\x6f\x7e\xc0\xce\x9e\x61\x38\x9e\xa4\x70\xc2\xa5\x42\xbd\xcd\x0d\x15\xe9\x29\x85\x76\xda\xa5\xb3\x1e\x87\x95\x92\x1b\x3a\x1a\xc4\x4d\x61\x76\xa0\xa9\x7e\xa6\xb1\x94\x98\xc6\x76\xe8\x73\x1a\xee\xdb\xc3\xf6\x79\x1f\xc0\x54\x28\x97\x1d\xab\x60\x56\xf6\x62\x5f\xdc\xa7\xe6\xea\x81\xde\x49\x48\x85\xb5\x0b\x7f\x54\xe8\x97\x7a\x0e\xff\x75\x06\x5f\x11\xcd\x59\x09\x67\x50\xea\xdb\xd3\xbb\x7e\x38\x70\xd1\xce\xa3\x76\x8f\x70\x6b\x84\xc1\xba\x49\x83\x14\xd6\xfd\x71\x14\x17\x94\xca\xfe\x80\x19\x98\x3f\x06\xdb\x39\x73\xf4\x54\x4e\xc6\x20\x2b\x2c\x71\xa0\x71\x7b\x56\xd6\x52\xb4\x46\xc1\xf4\x8f\xda\xc1\x2e\x30\x44\x7f\x70\xd2\xda\xe3\xf6\x6e\xd2\x22\x5e\xb0\x66\xb3\x39\x18\x45\x9a\x46\x3d\x9f\x07\x9f\x77\x3f\x4a\xc8\xe5\x53\x19\x0b\x87\xa3\x7d\x57\x66\xd6\xc7\x0b\xbd\x88\x20\xfc\xc2\x2d\xe3\x96\xdf\xcd\x9b\x72\xa5\x4b\x2e\x07\x4f\x5b\x3a\xd4\x38\x32\xfd\x63\x94\xa9\x6c\x7c\xc4\xc1\xa7\xed\x3a\xf4\x4c\xdc\x3b\xff\x24\xaa\x4d\x08\xf4\xb3\xff\x9d\xcf\x3f\x27\xcb\x99\xa7\xcf\x3e\x87\xb8\xb5\x46\x9a\x4a\x80\xe7\x79\xde\x06\xf4\x96\xea\x5e\x44\x1f\x9f\x84\xfe\xc6\x4c\x92\xb5\xd2\xb8\xbe\x83\x6e\x8f\xbb\x36\xd4\xc6\x68\xce\x28\x48\x65\x7d\x17\xe5\x02\x8a\x06\xe3\xf4\x6e\x15\x11\x41\x46\x47\x23\x9d\x35\x9b\xc0\x61\xa8\x2d\x32\x8a\xe8\x11\x6c\x85\xe1\x39\x24\xe6\x91\xde\xa6\x52\x20\x29\x7c\xd5\x80\xc9\x92\xb3\xa5\x91\x54\x8d\x9d\xde\xf2\x7b\x1c\xf8\x76\x34\x5e\x82\x42\x77\xfb\x80\x41\x6a\xb7\x8b\xbd\xde\x64\x63\x28\x87\x69\x22\xf7\x09\x01\x7f\xc8\x36\xa4\x45\xf8\xbb\x10\xf1\x85\xfb\x1f\x41\xba\xa4\x04\xf0\xe6\xbb\xa8\xa7\xa9\x9e\x25\x57\x40\x94\xc2\xde\x93\x96\x9e\x4f\xb2\xbd\x5c\x5b\x58\x3b\x3e\x11\x73\xe6\xc1\xac\xe5\xd0\x85\x08\x37\xe5\xb9\x90\xe3\x46\xf8\xa8\xe3\xa1\x94\x2e\x63\xbb\x36\x5a\x52\x04\x85\x67\x62\x25\x4d\x3c\x14\x2f\xec\xa1\x55\x4f\xb8\xa6\xc6\x4e\xba\x88\x95\xb8\x88\x65\x3b\x41\xbd\x3c\x38\xdc\x56\xa1\x8f\x4d\x61\x42\xcd\xad\x5b\x0a\xaf\x5e\xe8\xd7\x77\x13\xb5\xee\x68\x03\xd4\x6e\xaf\xe0\xec\x0c\x4e\xfd\x10\x6a\xc5\x59\x4e\xce\x61\x33\x99\xa7\xba\x5f\x7f\x29\x66\x0f\xec\xac\x7b\x5b\xef\xd4\xb1\x9d\x47\xc6\xe3\x62\x8b\x2d\xe1\xbd\xcc\xdc\x17\xbc\xec\x04\xb7\x45\xe1\x0b\x84\x75\x04\x56\x74\x56\x52\x1e\x37\xad\xee\x2a\x2d\x0a\xc3\x5d\xed\xf9\x23\xd3\xad\x7c\x6d\x08\x66\xe3\x8b\x15\xfe\xce\x09\x77\xd7\x63\xa6\xa2\xf2\xa0\x13\xec\xf1\xdd\xa7\x1d\xce\xf7\x68\xb6\x17\x63\xdc\x8b\x1f\xd9\x5e\xb0\x99\x43\xdd\x1e\x11\x7f\xc0\x95\xa6\x8b\x43\x8e\x4f\x1b\xa7\x1c\xf5\x96\xcd\xfb\xd6\xf5\x0f\x2d\xc2\xd7\xca\xc3\xb5\xf8\xf2\x71\x44\xbd\xa9\x9c\xe5\xaa\x1b\xee\x2f\xe1\x90\xd7\x17\xa0\x70\x85\x8a\x34\x60\x24\x98\x4c\xc9\xed\x3a\xf3\x97\x37\x46\x3d\x11\x7f\x3f\xc0\xeb\xe7\xd9\x75\xec\xdf\x6e\x99\xd0\x60\xeb\x71\xcd\x1b\xf9\xbf\x98\x98\xb0\x78\xa2\xee\x8e\xfa\x6e\xd4\xec\x8b\x9a\xc0\xd1\x25\x84\xde\xae\xd6\x97\xe5\x2e\x5c\x8e\x84\x0d\x5f\x54\x0f\x34\x4f\x27\xfa\x44\xae\x34\xb0\x98\x6c\x8f\x9a\x87\x3d\xe4\xba\x1e\x76\x58\x2d\x0c\xae\x91\xa9\x24\xdb\x97\xab\x55\x62\x0b\x85\x6d\x41\xd9\xa5\x57\x4e\x50\x72\x99\x46\x52\x63\xeb\x87\x4c\xd2\x55\x46\x7c\x34\xda\xd5\x8c\xcf\xd9\x93\x48\x6d\x28\xe0\xc1\xc7\xa8\x4b\x5c\x1b\xa9\x0d\x28\xcc\xb1\x64\xc2\x97\x23\x84\x1c\x5b\x9d\x34\xc9\x88\x6e\xf4\x35\xed\x62\xea\x05\x6b\xb4\x57\x9b\xda\x0b\x24\x7a\x27\x0c\x7b\xa4\x0a\xf5\x63\x7c\xa8\xc2\xf0\x00\x3c\xb6\xc8\x78\xbe\xc2\x38\xa8\xdc\xf0\x23\xd0\xb5\x47\xb1\xee\xca\x8c\xdb\xbb\x76\x29\x1f\x7c\xcd\xd7\x81\xca\x9b\xbf\x1d\xe1\x18\x7c\x42\x07\x7c\x42\x6d\xe1\xc7\xb9\xbb\xa2\xd5\xb4\xf1\xf8\xaa\x77\xa2\x40\xfa\xb9\xe6\x1b\x9e\x33\xf5\x99\x40\x63\xab\x54\xbf\x50\x06\x83\xf3\x09\xd0\x8e\x33\xd1\x2c\x2d\xa2\xfc\xc6\xf1\x49\x50\x8d\x91\xd4\x10\x98\x06\xd2\x9f\x59\x7d\x1e\x56\x62\x58\x4e\x80\xc3\x1f\x82\x3c\x8d\x8d\x66\xc0\x27\x43\xa3\x1c\xa2\xc0\xdb\xfe\xef\xe4\xa1\x5c\xac\x8f\x31\xfe\xf3\xc9\x63\x90\x38\xac\x95\x8e\xc5\xc4\xc6\xcb\x30\x04\x07\xd1\x3b\x18\x64\xbc\x11\x8e\x58\x45\x58\x0e\xc9\x1f\x4e\x1c\x96\xe8\x53\x57\x51\x6c\xc7\x25\x7c\x81\xfa\x47\xed\x71\xea\x8e\x37\xe5\x46\xb3\xad\x78\x89\x2d\xfe\x99\x9a\x3e\x46\xcd\xce\xa1\x6e\x6c\x83\x81\x24\xc0\xd4\x97\x83\xb6\x09\x40\xfb\x8c\xe6\x16\x97\x96\x6a\xff\xe6\xc5\x0b\x96\xb8\x6f\xa8\xae\x92\xb7\xcc\xfa\x4e\x32\x32\x8f\x5f\xa8\x8e\xc7\xfc\xba\xa3\x94\x4f\xb5\x59\xe7\x3f\xcf\xb5\x2a\xbe\xfc\xfa\x60\xb3\x62\x52\x88\xa9\xae\x45\xbb\xfa\xee\x7c\x7c\xd8\xa7\x18\xc9\x3d\x1a\xe3\x89\x8c\x4e\x6e\x26\x17\xe7\x8e\x6b\xa8\x40\x47\xbd\x77\xae\xed\x75\x4b\xed\xe7\xc9\x53\x9b\xae\x0f\xe9\xeb\x12\xfa\xe9\x43\xfc\x3d\x47\xda\x28\xf8\x68\xd3\x98\xe5\x37\x6e\x32\xa7\xea\x67\x80\xf0\xd9\x92\x0e\xed\xd5\xdb\xeb\xe1\x6e\x5f\x74\x54\xb0\x89\x40\xaa\x14\xfd\x4f\x26\x8c\xad\x85\x86\x94\xfd\xbe\xc7\x83\xf2\x42\xe6\x74\x59\xd3\xb7\x66\xfb\xc1\xd6\xba\x8b\x2b\xac\x0b\xc5\x37\x4c\xed\x20\xc7\x12\xf3\x08\xf8\x5a\x48\xaa\x1f\x20\x61\x9a\x7a\xac\x49\x42\x57\xf2\x6d\x73\xef\x81\xa7\x26\xb3\x7d\x3e\x22\xe5\xb7\xad\x3a\x93\xdb\x3c\xa5\xdc\xb5\x61\x29\xd2\x3e\xc4\x71\x95\xca\x43\xe2\x35\x30\xbb\xbd\x6e\x26\x48\xba\x8c\x99\xd8\x31\xb4\xa7\xfd\x09\x77\x44\x8d\xd3\x65\x13\x7b\xb8\xe4\x7e\xc4\x90\x5b\xd9\x7e\x72\xf7\x2b\x58\x6f\x2e\x3d\x96\x8a\xaf\xb9\x60\xb9\xcf\x66\xbd\xab\x95\x7f\x7e\xd6\x7d\x11\xa0\xfc\x1e\x78\x08\x87\x7e\x32\x6e\x21\x3f\x91\x87\xbb\x98\x42\xbc\x5a\x16\xcf\xe6\x07\x7f\xba\x42\x62\x7d\x42\xd8\x71\x52\xdb\x3b\x8a\x4d\x02\x17\xe9\xd8\xa3\xbe\xdb\x1d\xe3\x49\x2f\xf0\xa0\x01\xd4\xa7\x81\xde\xf3\x18\x4f\xeb\x99\xd4\xf1\x01\x99\xbd\x35\x6c\xbb\x93\x16\xfe\xc9\x56\x69\xa9\x2c\xa2\x69\x45\xb4\xbd\x40\x61\x99\xe5\x28\xd6\x26\x6b\xf0\x36\x4a\x38\xc4\x4a\x37\x49\xa7\x83\x97\x88\xe1\xb7\x0c\xa9\xb1\xea\xf8\x78\x48\xd3\x59\x1c\xb5\xab\x22\xcf\x8e\x5c\xcf\x5f\x0e\x04\xbd\x4d\xac\x12\x6c\xb0\xd8\x6a\x3b\xcb\x3a\x01\x03\xbd\x5d\xe2\xc7\x2d\x0a\x63\x8f\x8c\xa8\x3e\xfd\xae\x3b\x44\x83\x07\xeb\x74\x1e\x18\x82\x76\x5f\xa2\x5f\xfd\x3c\x81\xd1\x67\x4d\x14\x7a\xf1\x5e\x35\x87\x72\x17\x5e\x3b\x2f\x6c\x57\x76\xcc\x5e\x00\xd1\x97\x80\xb3\xb1\x5a\xdb\xea\x3c\x6a\x79\x1f\xec\xce\x95\x0c\xc6\xef\xf1\xe8\x59\x51\xf3\x63\x39\x6b\x21\x6e\x40\x8a\x7c\xb7\x07\xe6\xb6\xcd\x3d\x0e\xf4\x16\xa5\x50\x5a\x3b\x13\x48\x0c\xe4\x12\xfe\x76\x06\x25\xfc\x0d\x32\xde\x1c\x99\x10\x65\x6a\x90\x94\xa8\x34\x61\x8a\xc8\x19\xb5\x45\x6a\xae\xee\xf9\x0d\x17\x90\xa2\x4e\xd0\xdd\x38\xb5\x3e\xd2\x00\xdc\x35\xcb\x73\x49\x81\x2e\xe3\x90\x23\x6b\x6e\xb2\xba\x6d\xfb\x56\x2c\x25\xfd\xb6\x2c\x25\x84\x59\xb6\xa9\xbd\x5a\x4e\x61\xf8\x0f\x00\xc6\x6a\x34\xcc\x65\x04\x19\x87\x57\x83\x85\x47\xed\x6a\x96\x52\xe6\x11\x7c\x1a\xb4\xa8\x1c\x58\x12\x9f\x65\xc6\xfd\xad\x11\x77\x3a\x26\xfb\xfd\x8d\x65\x2e\xbb\xfa\xa2\xb9\x5b\xb2\x17\xca\x72\xd9\x56\x19\xee\x02\x4d\xc6\x07\x44\x32\x7e\x04\x91\x8c\xf7\x89\x7c\x1a\xf2\x9d\x16\xfb\x6a\x3e\x00\xff\x46\x13\xad\x8e\x87\x1e\x51\x55\xf0\x17\xfe\x48\x4d\xa2\x78\x70\xed\xef\xad\x8b\x7e\xd3\xd5\x50\x53\x16\x54\xd5\x5f\xf8\xe3\xbe\xc1\xe9\xe1\xc8\xf8\x4f\xfb\xc0\x24\x8d\xa7\xaa\x22\x1a\xda\xf3\x97\x66\x6b\x4d\xb2\xad\x79\x89\xd4\x4f\xb1\x62\xc3\xca\xc9\x3d\xa8\x90\x68\xf2\x9f\xb3\x55\x38\x56\x2b\x14\xc6\x7e\x66\x8a\x6d\xf4\x81\x5d\x43\xff\x1e\xcc\x73\x69\x9d\x18\x1f\x93\xd9\x07\x02\x8e\x01\x44\x2f\xc7\xcf\x7a\x78\xa6\x07\xa7\xfd\x2a\xbf\x39\x55\x9d\xc7\xd7\x52\x99\xb0\x3b\x40\xf0\x52\x4f\x84\xdd\x63\x15\xf4\x54\x00\x3e\x30\xff\xd9\x30\xbc\xbf\xed\x9f\x40\x96\xc5\x5f\xd3\x19\x27\x1c\xe2\x24\xc0\x9e\x89\x83\x7f\x04\x03\x9f\x1a\xf9\x72\x49\x8e\x3c\xb2\xd6\x31\x11\xe7\x00\x6e\x9a\xa0\x33\x58\xd3\x61\xd8\x50\xe4\xa1\xdf\xd0\x6e\x8b\x1c\x2f\x45\x1a\xe6\x72\x1e\xc1\x8a\xe5\x13\x31\x68\xf4\x0b\x95\xf6\x43\x50\x55\x28\xd2\xba\x0e\xfe\x6f\x00\x65\x35\x9f\x01\x00\x3f\x00\x00")
 
 func templatesKvschemaGotmplBytes() ([]byte, error) {
 	return bindataRead(
@@ -92,7 +92,7 @@ func templatesKvschemaGotmpl() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "templates/kvschema.gotmpl", size: 4907, mode: os.FileMode(420), modTime: time.Unix(1564964873, 0)}
+	info := bindataFileInfo{name: "templates/kvschema.gotmpl", size: 16128, mode: os.FileMode(420), modTime: time.Unix(1792331211, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
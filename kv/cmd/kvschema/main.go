@@ -31,16 +31,21 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"sort"
 	"strings"
 	"text/template"
+	"unicode"
 
 	"github.com/google/note-maps/kv"
 	"github.com/google/note-maps/kv/cmd/kvschema/bindata"
+	"github.com/google/note-maps/kv/collation"
 )
 
 var (
 	verbose = flag.Bool("v", false, "enable verbose logging")
 	output  = flag.String("output", "kvschema.go", "output file name")
+	layout  = flag.String("index_layout", "slice",
+		`index row layout: "slice" for one row per index value, or "keyed" for one row per index value and entity`)
 )
 
 func verboseLogf(format string, v ...interface{}) {
@@ -113,6 +118,7 @@ func main() {
 
 func gen(pkg *types.Package, w io.Writer) error {
 	kvpath := reflect.TypeOf(kv.Entity(0)).PkgPath()
+	collationPath := reflect.TypeOf(collation.Key(nil)).PkgPath()
 	var kvpkg *types.Package
 	for _, ipkg := range pkg.Imports() {
 		if ipkg.Path() == kvpath {
@@ -122,6 +128,14 @@ func gen(pkg *types.Package, w io.Writer) error {
 	if kvpkg == nil {
 		return fmt.Errorf("%s does not import %s", pkg.Path(), kvpath)
 	}
+	var keyed bool
+	switch *layout {
+	case kv.SliceLayout.String():
+	case kv.KeyedLayout.String():
+		keyed = true
+	default:
+		return fmt.Errorf("unknown index layout %q", *layout)
+	}
 	kvInterface := func(name string) *types.Interface {
 		if obj := kvpkg.Scope().Lookup(name); obj == nil {
 			return nil
@@ -137,6 +151,12 @@ func gen(pkg *types.Package, w io.Writer) error {
 		encoderType    = kvInterface("Encoder")
 		decoderType    = kvInterface("Decoder")
 		componentTypes []*componentType
+		imports        = make(map[string]*types.Package)
+		imported       = func(p *types.Package) {
+			if p != pkg && p.Path() != kvpath {
+				imports[p.Path()] = p
+			}
+		}
 	)
 	for _, name := range pkg.Scope().Names() {
 		if obj, ok := pkg.Scope().Lookup(name).(*types.TypeName); ok {
@@ -180,12 +200,27 @@ func gen(pkg *types.Package, w io.Writer) error {
 				PrefixName:    prefixName,
 				DirectEncoder: encoderImpl == directImplementation,
 				DirectDecoder: decoderImpl == directImplementation,
+				Keyed:         keyed,
 			}
 			methods := types.NewMethodSet(types.NewPointer(named))
 			for i := 0; i < methods.Len(); i++ {
 				selection := methods.At(i)
 				direct := !selection.Indirect()
 				name := selection.Obj().(*types.Func).Name()
+				if ref := strings.TrimPrefix(name, "Ref"); ref != name &&
+					ref != "" && unicode.IsUpper(rune(ref[0])) {
+					if !returnsEntities(selection.Type().(*types.Signature), kvpath) {
+						verboseLogf("%s does not return only a []kv.Entity", name)
+						continue
+					}
+					c.Refs = append(c.Refs, &refInfo{
+						ComponentName: c.Name,
+						Name:          ref,
+						MethodName:    name,
+					})
+					verboseLogf("reference found: %v", c.Refs[len(c.Refs)-1])
+					continue
+				}
 				if !strings.HasPrefix(name, "Index") {
 					continue
 				}
@@ -205,12 +240,21 @@ func gen(pkg *types.Package, w io.Writer) error {
 					verboseLogf("%s is not a named type", elem)
 					continue
 				}
+				fromKV := elem.Obj().Pkg().Path() == kvpath
+				text := fromKV && elem.Obj().Name() == "Text"
+				fuzzy := fromKV && elem.Obj().Name() == "Fuzzy"
 				encoderImpl = implements(elem, encoderType)
 				decoderImpl = implements(elem, decoderType)
-				if encoderImpl == noImplementation || decoderImpl == noImplementation {
-					verboseLogf(
-						"%v does not implement encoder/decoder interfaces", elem)
-					continue
+				var fields []*tupleField
+				if text || fuzzy {
+					// Full-text and trigram indexes need neither encoders
+					// nor fields.
+				} else if encoderImpl == noImplementation || decoderImpl == noImplementation {
+					if fields = tupleFields(elem, pkg, encoderType, imported); fields == nil {
+						verboseLogf(
+							"%v does not implement encoder/decoder interfaces and is not a tuple of encoders", elem)
+						continue
+					}
 				}
 				expr := elem.Obj().Name()
 				elemPkg := elem.Obj().Pkg()
@@ -218,6 +262,16 @@ func gen(pkg *types.Package, w io.Writer) error {
 					expr = elemPkg.Name() + "." + expr
 				}
 				indexName := strings.TrimPrefix(name, "Index")
+				unique := false
+				if rest := strings.TrimPrefix(indexName, "Unique"); rest != indexName &&
+					rest != "" && unicode.IsUpper(rune(rest[0])) {
+					indexName, unique = rest, true
+				}
+				if (text || fuzzy) && unique {
+					verboseLogf("%s cannot be unique and full-text or trigram", name)
+					continue
+				}
+				imported(elemPkg)
 				c.Indexes = append(c.Indexes, &indexInfo{
 					ComponentName:       c.Name,
 					ComponentPrefixName: c.PrefixName,
@@ -228,6 +282,12 @@ func gen(pkg *types.Package, w io.Writer) error {
 					TypeExpr:            expr,
 					DirectEncoder:       encoderImpl == directImplementation,
 					DirectDecoder:       decoderImpl == directImplementation,
+					Keyed:               keyed,
+					Fields:              fields,
+					Unique:              unique,
+					Text:                text,
+					Fuzzy:               fuzzy,
+					Collated:            elemPkg.Path() == collationPath && elem.Obj().Name() == "Key",
 				})
 				verboseLogf("index found: %v", c.Indexes[len(c.Indexes)-1])
 			}
@@ -246,15 +306,51 @@ func gen(pkg *types.Package, w io.Writer) error {
 	if err != nil {
 		return err
 	}
+	paths := []string{kvpath, kvpath + "/query"}
+	for _, c := range componentTypes {
+		if c.HasText() {
+			paths = append(paths, kvpath+"/fulltext")
+			break
+		}
+	}
+	for _, c := range componentTypes {
+		if c.HasFuzzy() {
+			paths = append(paths, kvpath+"/trigram")
+			break
+		}
+	}
+	for path := range imports {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	var specs []*importSpec
+	for _, path := range paths {
+		spec := &importSpec{Path: path}
+		if p, ok := imports[path]; ok && p.Name() != filepath.Base(path) {
+			spec.Name = p.Name()
+		}
+		specs = append(specs, spec)
+	}
 	return t.ExecuteTemplate(w, "kvschema.go", &struct {
 		Package        *types.Package
+		Imports        []*importSpec
 		ComponentTypes []*componentType
 	}{
 		pkg,
+		specs,
 		componentTypes,
 	})
 }
 
+// importSpec describes a package imported by generated code, other than
+// "context".
+type importSpec struct {
+	// Name is empty unless the name of the package differs from the last
+	// element of its path.
+	Name string
+	Path string
+}
+
 type componentType struct {
 	PrefixName    string
 	Name          string
@@ -262,6 +358,72 @@ type componentType struct {
 	DirectEncoder bool
 	DirectDecoder bool
 	Indexes       []*indexInfo
+	Refs          []*refInfo
+	Keyed         bool
+}
+
+// refInfo describes a method named with the "Ref" prefix, which returns the
+// entities that a component value refers to, such as the topic of a name.
+type refInfo struct {
+	ComponentName string
+	Name          string
+	MethodName    string
+}
+
+// returnsEntities returns true if sig receives nothing and returns only a
+// []kv.Entity.
+func returnsEntities(sig *types.Signature, kvpath string) bool {
+	if sig.Params().Len() != 0 || sig.Results().Len() != 1 {
+		return false
+	}
+	rtype, ok := sig.Results().At(0).Type().(*types.Slice)
+	if !ok {
+		return false
+	}
+	elem, ok := rtype.Elem().(*types.Named)
+	return ok && elem.Obj().Pkg() != nil && elem.Obj().Pkg().Path() == kvpath &&
+		elem.Obj().Name() == "Entity"
+}
+
+// HasUnique returns true if any of the indexes of c is unique.
+func (c *componentType) HasUnique() bool {
+	for _, ix := range c.Indexes {
+		if ix.Unique {
+			return true
+		}
+	}
+	return false
+}
+
+// HasText returns true if any of the indexes of c is a full-text index.
+func (c *componentType) HasText() bool {
+	for _, ix := range c.Indexes {
+		if ix.Text {
+			return true
+		}
+	}
+	return false
+}
+
+// HasFuzzy returns true if any of the indexes of c is a trigram index.
+func (c *componentType) HasFuzzy() bool {
+	for _, ix := range c.Indexes {
+		if ix.Fuzzy {
+			return true
+		}
+	}
+	return false
+}
+
+// HasRows returns true if any of the indexes of c stores index rows directly,
+// rather than through package fulltext or package trigram.
+func (c *componentType) HasRows() bool {
+	for _, ix := range c.Indexes {
+		if !ix.Text && !ix.Fuzzy {
+			return true
+		}
+	}
+	return false
 }
 
 type indexInfo struct {
@@ -274,6 +436,140 @@ type indexInfo struct {
 	TypeExpr            string
 	DirectEncoder       bool
 	DirectDecoder       bool
+	Keyed               bool
+
+	// Fields holds the fields of TypeExpr if it is a tuple type for a
+	// composite index, or nil otherwise.
+	Fields []*tupleField
+
+	// Unique is true if the index method is named with the "IndexUnique"
+	// prefix, so that each index value may belong to only one entity.
+	Unique bool
+
+	// Text is true if the index method returns a slice of kv.Text, so that
+	// the index is a full-text index maintained through package fulltext.
+	Text bool
+
+	// Fuzzy is true if the index method returns a slice of kv.Fuzzy, so that
+	// the index is a trigram index maintained through package trigram.
+	Fuzzy bool
+
+	// Collated is true if TypeExpr is collation.Key, whose prefixes must be
+	// made by Collator.Prefix.
+	Collated bool
+}
+
+// EncodeExpr returns an expression that encodes the index value v.
+func (ix *indexInfo) EncodeExpr(v string) string {
+	if ix.Fields == nil {
+		return v + ".Encode()"
+	}
+	var args []string
+	for _, f := range ix.Fields {
+		args = append(args, f.addr(v+"."+f.Name))
+	}
+	return "kv.EncodeTuple(" + strings.Join(args, ", ") + ")"
+}
+
+// LeadingFields returns each proper prefix of the fields of a tuple type,
+// shortest first.
+func (ix *indexInfo) LeadingFields() []*tuplePrefix {
+	var prefixes []*tuplePrefix
+	for n := 1; n < len(ix.Fields); n++ {
+		var (
+			name   = "With"
+			params []string
+			args   []string
+		)
+		for _, f := range ix.Fields[:n] {
+			name += f.Name
+			params = append(params, f.Param()+" "+f.TypeExpr)
+			args = append(args, f.addr(f.Param()))
+		}
+		prefixes = append(prefixes, &tuplePrefix{
+			Name:       name,
+			Params:     strings.Join(params, ", "),
+			EncodeExpr: "kv.EncodeTuple(" + strings.Join(args, ", ") + ")",
+		})
+	}
+	return prefixes
+}
+
+// tupleField describes a field of a tuple type used as a composite index
+// value.
+type tupleField struct {
+	Name          string
+	TypeExpr      string
+	DirectEncoder bool
+}
+
+// Param returns the name of a parameter that holds a value for f.
+func (f *tupleField) Param() string {
+	name := strings.ToLower(f.Name[:1]) + f.Name[1:]
+	switch {
+	case token.Lookup(name).IsKeyword():
+	case name == "s", name == "cursor", name == "n", name == "es", name == "err",
+		name == "lo", name == "kv", name == "query":
+	default:
+		return name
+	}
+	return "v" + f.Name
+}
+
+// addr returns expr, or its address if only the pointer type implements
+// kv.Encoder.
+func (f *tupleField) addr(expr string) string {
+	if f.DirectEncoder {
+		return expr
+	}
+	return "&" + expr
+}
+
+// tuplePrefix describes the leading fields of a tuple type.
+type tuplePrefix struct {
+	Name       string
+	Params     string
+	EncodeExpr string
+}
+
+// tupleFields returns the fields of t if t is a struct type with only
+// exported fields that implement encoder, or nil otherwise.
+//
+// Each package other than pkg named by the type of a field is passed to
+// imported.
+func tupleFields(t *types.Named, pkg *types.Package, encoder *types.Interface, imported func(*types.Package)) []*tupleField {
+	st, ok := t.Underlying().(*types.Struct)
+	if !ok || st.NumFields() == 0 {
+		return nil
+	}
+	var (
+		fields []*tupleField
+		pkgs   []*types.Package
+	)
+	qualifier := func(p *types.Package) string {
+		if p == pkg {
+			return ""
+		}
+		pkgs = append(pkgs, p)
+		return p.Name()
+	}
+	for i := 0; i < st.NumFields(); i++ {
+		f := st.Field(i)
+		impl := implements(f.Type(), encoder)
+		if !f.Exported() || f.Anonymous() || impl == noImplementation {
+			verboseLogf("%v field %v is not an exported kv.Encoder", t, f.Name())
+			return nil
+		}
+		fields = append(fields, &tupleField{
+			Name:          f.Name(),
+			TypeExpr:      types.TypeString(f.Type(), qualifier),
+			DirectEncoder: impl == directImplementation,
+		})
+	}
+	for _, p := range pkgs {
+		imported(p)
+	}
+	return fields
 }
 
 type implementation int
@@ -27,11 +27,13 @@ import (
 
 var tests = []struct {
 	Name       string
+	Layout     string
 	Source     string
 	Substrings []string
 }{
 	{
-		Name: "docs",
+		Name:   "docs",
+		Layout: "slice",
 		Source: `
 package input
 
@@ -51,6 +53,211 @@ func (d *Document) IndexTitle() []kv.String { return nil }
 		Substrings: []string{
 			`type Txn struct.?{`,
 			`func \(.* Txn\) EntitiesMatchingDocumentTitle\(v kv\.String\)`,
+			`func \(.* Txn\) DeleteDocument\(e kv\.Entity\) error`,
+			`func \(.* Txn\) EntitiesWithPrefixDocumentTitle\(prefix kv\.String, n int\)`,
+			`func WatchDocument\(ctx context\.Context, db kv\.DB, partition kv\.Entity, f func\(kv\.Entity, Document\) error\) error`,
+			`func MatchingDocumentTitle\(v kv\.String\) query\.Predicate`,
+			`func HasDocument\(\) query\.Predicate`,
+			`func \(.* Txn\) EntitiesByDocumentTitleRange\(lo, hi \*kv\.String, reverse bool,`,
+			`s\.SetEntitySlice\(key, es\)`,
+			`func \(.* Txn\) VerifyDocumentIndexes\(\) \(\[\]kv\.IndexProblem, error\)`,
+			`func \(.* Txn\) RebuildDocumentIndexes\(\) error`,
+			`s\.VerifyComponentIndexes\(DocumentPrefix,`,
+			`s\.RebuildComponentIndexes\(DocumentPrefix,`,
+			`func \(.* Txn\) VerifyIndexes\(\) \(\[\]kv\.IndexProblem, error\)`,
+		},
+	},
+	{
+		Name:   "keyed docs",
+		Layout: "keyed",
+		Source: `
+package input
+
+import "github.com/google/note-maps/kv"
+
+const (
+	DocumentPrefix kv.Component = 3
+	TitlePrefix    kv.Component = 4
+)
+
+type Document struct{ Title string }
+
+func (d *Document) Encode() []byte          { return nil }
+func (d *Document) Decode(src []byte) error { return nil }
+func (d *Document) IndexTitle() []kv.String { return nil }
+`,
+		Substrings: []string{
+			`func \(.* Txn\) EntitiesMatchingDocumentTitle\(v kv\.String\)`,
+			`kv\.AppendKeyedIndexValue\(key\[:lik:lik\], iv\.Encode\(\)\)`,
+			`s\.EntitiesMatchingKeyedIndex\(DocumentPrefix, TitlePrefix, v\.Encode\(\)\)`,
+			`query\.MatchKeyed\(DocumentPrefix, TitlePrefix, v\.Encode\(\)\)`,
+			`s\.EntitiesByComponentKeyedIndexRange\(DocumentPrefix, TitlePrefix,`,
+		},
+	},
+	{
+		Name:   "composite",
+		Layout: "keyed",
+		Source: `
+package input
+
+import "github.com/google/note-maps/kv"
+
+const (
+	OccurrencePrefix kv.Component = 3
+	TypeValuePrefix  kv.Component = 4
+)
+
+type Occurrence struct{ Type, Value string }
+
+type TypeValue struct {
+	Type  kv.Entity
+	Value kv.String
+}
+
+func (o *Occurrence) Encode() []byte              { return nil }
+func (o *Occurrence) Decode(src []byte) error     { return nil }
+func (o *Occurrence) IndexTypeValue() []TypeValue { return nil }
+`,
+		Substrings: []string{
+			`func \(.* Txn\) EntitiesMatchingOccurrenceTypeValue\(v TypeValue\)`,
+			`kv\.EncodeTuple\(iv\.Type, iv\.Value\)`,
+			`func \(.* Txn\) EntitiesMatchingOccurrenceTypeValueWithType\(vType kv\.Entity\) \(kv\.EntitySlice, error\)`,
+			`func \(.* Txn\) EntitiesByOccurrenceTypeValueWithType\(vType kv\.Entity, cursor \*kv\.IndexCursor, n int\)`,
+			`kv\.TupleEnd\(lo\)`,
+		},
+	},
+	{
+		Name:   "unique",
+		Layout: "slice",
+		Source: `
+package input
+
+import "github.com/google/note-maps/kv"
+
+const (
+	SIsPrefix     kv.Component = 3
+	LiteralPrefix kv.Component = 4
+)
+
+type SIs []string
+
+func (s *SIs) Encode() []byte                  { return nil }
+func (s *SIs) Decode(src []byte) error         { return nil }
+func (s *SIs) IndexUniqueLiteral() []kv.String { return nil }
+`,
+		Substrings: []string{
+			`func \(.* Txn\) EntitiesMatchingSIsLiteral\(v kv\.String\)`,
+			`for _, iv := range v\.IndexUniqueLiteral\(\) {\s+if err := s\.CheckUnique\(kv\.SliceLayout, SIsPrefix, LiteralPrefix, iv\.Encode\(\), e\); err != nil {`,
+			`returns a kv\.UniqueViolation`,
+		},
+	},
+	{
+		Name:   "full-text",
+		Layout: "keyed",
+		Source: `
+package input
+
+import "github.com/google/note-maps/kv"
+
+const (
+	NotePrefix kv.Component = 3
+	TextPrefix kv.Component = 4
+)
+
+type Note struct{ Text string }
+
+func (n *Note) Encode() []byte          { return nil }
+func (n *Note) Decode(src []byte) error { return nil }
+func (n *Note) IndexText() []kv.Text    { return nil }
+`,
+		Substrings: []string{
+			`"github\.com/google/note-maps/kv/fulltext"`,
+			`fulltext\.Update\(s\.Partitioned, NotePrefix, TextPrefix, e, oldText, newText\)`,
+			`s\.updateNoteIndexes\(e, &old, nil\)`,
+			`func \(.* Txn\) SearchNoteText\(q string, n int\) \(\[\]fulltext\.Result, error\)`,
+		},
+	},
+	{
+		Name:   "trigram",
+		Layout: "slice",
+		Source: `
+package input
+
+import "github.com/google/note-maps/kv"
+
+const (
+	PersonPrefix   kv.Component = 3
+	SpellingPrefix kv.Component = 4
+)
+
+type Person struct{ Name string }
+
+func (p *Person) Encode() []byte            { return nil }
+func (p *Person) Decode(src []byte) error   { return nil }
+func (p *Person) IndexSpelling() []kv.Fuzzy { return nil }
+`,
+		Substrings: []string{
+			`"github\.com/google/note-maps/kv/trigram"`,
+			`trigram\.Update\(s\.Partitioned, PersonPrefix, SpellingPrefix, e, oldSpelling, newSpelling\)`,
+			`s\.updatePersonIndexes\(e, &old, nil\)`,
+			`func \(.* Txn\) SimilarPersonSpelling\(v string, n int\) \(\[\]trigram\.Result, error\)`,
+		},
+	},
+	{
+		Name:   "collation",
+		Layout: "keyed",
+		Source: `
+package input
+
+import (
+	"github.com/google/note-maps/kv"
+	"github.com/google/note-maps/kv/collation"
+)
+
+const (
+	PersonPrefix  kv.Component = 3
+	SortKeyPrefix kv.Component = 4
+)
+
+type Person struct{ Name string }
+
+func (p *Person) Encode() []byte                { return nil }
+func (p *Person) Decode(src []byte) error       { return nil }
+func (p *Person) IndexSortKey() []collation.Key { return nil }
+`,
+		Substrings: []string{
+			`"github\.com/google/note-maps/kv/collation"`,
+			`func \(.* Txn\) EntitiesByPersonSortKeyRange\(lo, hi \*collation\.Key, reverse bool,`,
+			`func \(.* Txn\) EntitiesWithPrefixPersonSortKey\(prefix collation\.Key, n int\)`,
+			`prefix should be made by Collator\.Prefix`,
+		},
+	},
+	{
+		Name:   "ref",
+		Layout: "keyed",
+		Source: `
+package input
+
+import "github.com/google/note-maps/kv"
+
+const (
+	PetPrefix   kv.Component = 5
+	OwnerPrefix kv.Component = 6
+)
+
+type Pet struct{ Owner kv.Entity }
+
+func (p *Pet) Encode() []byte            { return nil }
+func (p *Pet) Decode(src []byte) error   { return nil }
+func (p *Pet) RefOwner() []kv.Entity     { return []kv.Entity{p.Owner} }
+func (p *Pet) Refurbish() []kv.Entity    { return nil }
+func (p *Pet) RefName() string           { return "" }
+`,
+		Substrings: []string{
+			`func PetOwnerOf\(p query\.Predicate\) query\.Predicate`,
+			`return query\.Project\(p, func\(t kv\.Partitioned, e kv\.Entity\) \(\[\]kv\.Entity, error\)`,
+			`v, err := Txn\{t\}\.GetPet\(e\)`,
+			`return v\.RefOwner\(\), nil`,
 		},
 	},
 }
@@ -68,6 +275,7 @@ type ImplementerReport struct {
 
 func TestStuff(t *testing.T) {
 	for _, test := range tests {
+		*layout = test.Layout
 		fset := token.NewFileSet()
 		f, err := parser.ParseFile(fset, "input.go", test.Source, 0)
 		if err != nil {
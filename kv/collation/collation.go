@@ -0,0 +1,122 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package collation encodes strings as index values that sort the way a
+// dictionary does.
+//
+// The bytes of a kv.String sort by code point, so that "Zebra" sorts before
+// "apple" and "Émile" sorts after "Zoe". An index method that returns Keys
+// made by a Collator instead sorts entities by the Unicode Collation
+// Algorithm, tailored to a locale, at primary strength: case, accents and
+// compatibility variants such as full-width letters are ignored, so "apple",
+// "Apple" and "ÀPPLE" are all equal and all sort before "Zebra".
+package collation
+
+import (
+	"bytes"
+	"sync"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Key is an encoded collation key that implements the kv.Encoder and
+// kv.Decoder interfaces.
+//
+// Keys made by the same Collator sort in the same order as the strings they
+// were made from. A Key cannot be decoded back into its string.
+type Key []byte
+
+// Encode returns k.
+func (k Key) Encode() []byte { return k }
+
+// Decode decodes src into k.
+func (k *Key) Decode(src []byte) error {
+	*k = append((*k)[:0], src...)
+	return nil
+}
+
+// Default is a Collator for the root locale, which sorts most languages
+// acceptably.
+var Default = New(language.Und)
+
+// Collator makes Keys that sort strings in the order of a locale.
+//
+// A Collator is safe for concurrent use.
+type Collator struct {
+	tag language.Tag
+
+	mu  sync.Mutex
+	c   *collate.Collator
+	buf collate.Buffer
+}
+
+// New returns a Collator for the locale identified by tag.
+//
+// Secondary and tertiary differences are ignored even if tag asks for them,
+// for example with "-u-ks-level3", so that Keys hold primary weights only.
+func New(tag language.Tag) *Collator {
+	return &Collator{
+		tag: tag,
+		c:   collate.New(tag, collate.Loose),
+	}
+}
+
+// Tag returns the language tag of the locale of c.
+func (c *Collator) Tag() language.Tag { return c.tag }
+
+// Key returns the collation key of s.
+//
+// Before making its key, s is normalized to NFKC and its case is folded, so
+// that strings differing only in those respects have equal keys.
+//
+// A Key holds only primary weights, whatever the strength requested by the
+// language tag of c, so it is the concatenation of the weights of the letters
+// of s with no secondary or tertiary level after them.
+func (c *Collator) Key(s string) Key {
+	folded, _, err := transform.String(transform.Chain(norm.NFKC, cases.Fold()), s)
+	if err != nil {
+		folded = s
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := Key(append([]byte(nil), c.c.KeyFromString(&c.buf, folded)...))
+	c.buf.Reset()
+	return key
+}
+
+// Prefix returns the primary weights of s, which are a prefix of the Key of
+// every string that begins with s when case, accents and width are ignored.
+// For example, Prefix("emi") is a prefix of Key("Émile").
+//
+// The result is meant for the generated EntitiesWithPrefix methods of
+// collation indexes. A byte prefix of a Key is not in general the Key of a
+// prefix, so matching by prefix is only meaningful at the primary level.
+// Exceptions remain where s ends inside a sequence of characters sorted as
+// one letter in the locale of c, such as "c" in the Slovak "ch", or ends with
+// a digit when the locale sorts numbers by value.
+func (c *Collator) Prefix(s string) Key {
+	// Keys hold primary weights only, because c is Loose; see New.
+	return c.Key(s)
+}
+
+// Compare returns an integer comparing a and b in the same order as their
+// Keys, which is zero if their Keys are equal, negative if a sorts before b,
+// and positive otherwise.
+func (c *Collator) Compare(a, b string) int {
+	return bytes.Compare(c.Key(a), c.Key(b))
+}
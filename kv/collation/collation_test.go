@@ -0,0 +1,113 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collation
+
+import (
+	"bytes"
+	"sort"
+	"testing"
+
+	"golang.org/x/text/language"
+)
+
+func sortByKey(c *Collator, ss []string) []string {
+	sorted := append([]string(nil), ss...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return bytes.Compare(c.Key(sorted[i]), c.Key(sorted[j])) < 0
+	})
+	return sorted
+}
+
+func TestKeyOrder(t *testing.T) {
+	for _, test := range []struct {
+		Tag  language.Tag
+		Want []string
+	}{
+		{language.Und, []string{"Äpfel", "apple", "Émile", "Zebra", "Zoe"}},
+		// In Swedish, "ä" is a letter that sorts after "z".
+		{language.Swedish, []string{"apple", "Émile", "Zebra", "Zoe", "Äpfel"}},
+	} {
+		c := New(test.Tag)
+		if c.Tag() != test.Tag {
+			t.Errorf("want tag %v, got %v", test.Tag, c.Tag())
+		}
+		in := []string{"Zoe", "Émile", "apple", "Zebra", "Äpfel"}
+		got := sortByKey(c, in)
+		for i := range test.Want {
+			if got[i] != test.Want[i] {
+				t.Errorf("%v: want %q, got %q", test.Tag, test.Want, got)
+				break
+			}
+		}
+	}
+}
+
+func TestKeyEquivalence(t *testing.T) {
+	for _, ss := range [][]string{
+		{"apple", "Apple", "APPLE", "àpple"},
+		{"full", "ｆｕｌｌ", "FULL"},
+		{"strasse", "Straße"},
+	} {
+		want := Default.Key(ss[0])
+		for _, s := range ss[1:] {
+			if got := Default.Key(s); !bytes.Equal(want, got) {
+				t.Errorf("want equal keys for %q and %q, got %x and %x", ss[0], s, want, got)
+			}
+			if Default.Compare(ss[0], s) != 0 {
+				t.Errorf("want %q and %q to compare equal", ss[0], s)
+			}
+		}
+	}
+}
+
+func TestKeyPrefix(t *testing.T) {
+	prefix, key := Default.Key("Lenn"), Default.Key("Lennon")
+	if !bytes.HasPrefix(key, prefix) {
+		t.Errorf("want %x to begin with %x", key, prefix)
+	}
+	var decoded Key
+	if err := decoded.Decode(key.Encode()); err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(key, decoded) {
+		t.Errorf("want %x, got %x", key, decoded)
+	}
+}
+
+func TestPrefix(t *testing.T) {
+	for _, tag := range []language.Tag{
+		language.Und,
+		language.French,
+		language.MustParse("und-u-ks-level3"),
+		language.MustParse("und-u-kc-true"),
+	} {
+		c := New(tag)
+		for _, test := range []struct{ Prefix, S string }{
+			{"emi", "Émile"},
+			{"ÉMI", "emily"},
+			{"É", "eve"},
+			{"strasse", "Straßenbahn"},
+			{"ｆｕ", "Full"},
+		} {
+			if prefix, key := c.Prefix(test.Prefix), c.Key(test.S); !bytes.HasPrefix(key, prefix) {
+				t.Errorf("%v: want Key(%q) %x to begin with Prefix(%q) %x",
+					tag, test.S, key, test.Prefix, prefix)
+			}
+		}
+		if prefix, key := c.Prefix("emi"), c.Key("Emma"); bytes.HasPrefix(key, prefix) {
+			t.Errorf("%v: want Key(%q) %x not to begin with Prefix(%q) %x",
+				tag, "Emma", key, "emi", prefix)
+		}
+	}
+}
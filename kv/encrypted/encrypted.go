@@ -0,0 +1,637 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package encrypted provides an implementation of kv.DB that encrypts the
+// values stored in any other kv.DB.
+//
+// Values are encrypted with AES-GCM using a randomly generated data key, and
+// each value is bound to its key so that values cannot be swapped between
+// keys without detection. Keys are not encrypted, since their order must be
+// preserved.
+//
+// The data key is itself encrypted with a key derived from a passphrase
+// through scrypt, and stored in the wrapped kv.DB along with the parameters
+// used for derivation. Opening the database with the wrong passphrase fails
+// with ErrPassphrase.
+//
+// Keys that are a single byte long are reserved for bookkeeping by backends
+// such as kv/badger and by this package, and their values are neither
+// encrypted nor decrypted.
+package encrypted
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/google/note-maps/kv"
+	"golang.org/x/crypto/scrypt"
+)
+
+var (
+	// ErrPassphrase is returned when a passphrase does not match the one
+	// used to encrypt a database.
+	ErrPassphrase = errors.New("encrypted: wrong passphrase")
+
+	// ErrReservedKey is returned when attempting to modify the key used by
+	// this package to store its metadata.
+	ErrReservedKey = errors.New("encrypted: reserved key")
+
+	// ErrCorrupt is returned when an encrypted value cannot be decrypted.
+	ErrCorrupt = errors.New("encrypted: value cannot be decrypted")
+)
+
+// metaKey is where metadata about the encryption of a database is stored.
+//
+// Key {1} is used by kv.Allocer in kv/badger.
+var metaKey = []byte{2}
+
+const (
+	metaVersion = 1
+
+	flagPerPartition = 1 << 0
+
+	keySize   = 32
+	saltSize  = 16
+	nonceSize = 12
+
+	// Encrypted values begin with the identifier of the data key used to
+	// encrypt them, followed by a nonce.
+	valueHeaderSize = 4 + nonceSize
+
+	// Rotate re-encrypts values in transactions of up to rotateBatchCount
+	// pairs.
+	rotateBatchCount = 1000
+)
+
+// Options control how a new encrypted database is created.
+//
+// Options are recorded in the database when it is created and ignored when
+// it is opened again later.
+type Options struct {
+	// PerPartition causes values to be encrypted with a distinct key for
+	// each partition, derived from the data key and the first eight bytes of
+	// each key.
+	PerPartition bool
+
+	// ScryptLogN is the base-2 logarithm of the scrypt CPU/memory cost
+	// parameter used to derive a key from the passphrase. If zero, a
+	// reasonable default is used.
+	ScryptLogN uint8
+}
+
+// DefaultScryptLogN is the value used when Options.ScryptLogN is zero.
+const DefaultScryptLogN = 15
+
+// DB wraps a kv.DB to encrypt all values written through it.
+type DB struct {
+	db kv.DB
+
+	mutex        sync.RWMutex
+	meta         meta
+	aeads        map[uint32]cipher.AEAD
+	perPartition bool
+}
+
+// Open returns a DB that encrypts the values stored in db using a key derived
+// from passphrase.
+//
+// If db does not yet hold any encryption metadata, a new data key is
+// generated according to opts, which may be nil. Any values already present
+// in db are assumed to be unencrypted reserved values.
+func Open(db kv.DB, passphrase []byte, opts *Options) (*DB, error) {
+	var m meta
+	txn := db.NewTxn(false)
+	err := txn.Get(metaKey, m.Decode)
+	txn.Discard()
+	if err != nil {
+		return nil, err
+	}
+	if m.version == 0 {
+		if opts == nil {
+			opts = &Options{}
+		}
+		m, err = newMeta(passphrase, opts.PerPartition, opts.ScryptLogN, 1)
+		if err != nil {
+			return nil, err
+		}
+		txn := db.NewTxn(true)
+		defer txn.Discard()
+		if err = txn.Set(metaKey, m.Encode()); err != nil {
+			return nil, err
+		}
+		if err = txn.Commit(); err != nil {
+			return nil, err
+		}
+	}
+	e := &DB{db: db}
+	if err = e.load(m, passphrase); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// load makes the data keys in m available to e.
+func (e *DB) load(m meta, passphrase []byte) error {
+	kek, err := m.kek(passphrase)
+	if err != nil {
+		return err
+	}
+	aeads := make(map[uint32]cipher.AEAD)
+	for _, k := range m.keys {
+		dk, err := kek.Open(nil, k.wrapped[:nonceSize], k.wrapped[nonceSize:], metaKey)
+		if err != nil {
+			return ErrPassphrase
+		}
+		if aeads[k.id], err = newAEAD(dk); err != nil {
+			return err
+		}
+	}
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.meta = m
+	e.aeads = aeads
+	e.perPartition = m.flags&flagPerPartition != 0
+	return nil
+}
+
+// NewTxn returns a transaction that encrypts values as they are written and
+// decrypts them as they are read.
+func (e *DB) NewTxn(update bool) kv.TxnCommitDiscarder {
+	return txn{e.db.NewTxn(update), e}
+}
+
+// Close closes the wrapped kv.DB.
+func (e *DB) Close() error { return e.db.Close() }
+
+// Reserve calls the Reserve method of the wrapped kv.DB, if it has one.
+func (e *DB) Reserve(entity kv.Entity) error {
+	if r, ok := e.db.(kv.Reserver); ok {
+		return r.Reserve(entity)
+	}
+	return nil
+}
+
+// Watch passes decrypted changes to keys matching prefix to f.
+func (e *DB) Watch(ctx context.Context, prefix []byte, f func([]kv.Change) error) error {
+	return e.db.Watch(ctx, prefix, func(cs []kv.Change) error {
+		decrypted := make([]kv.Change, 0, len(cs))
+		for _, c := range cs {
+			if bytes.Equal(c.Key, metaKey) {
+				continue
+			}
+			v, err := e.open(c.Key, c.Value)
+			if err != nil {
+				return err
+			}
+			decrypted = append(decrypted, kv.Change{Key: c.Key, Value: v})
+		}
+		if len(decrypted) == 0 {
+			return nil
+		}
+		return f(decrypted)
+	})
+}
+
+// Rotate replaces the data key with a newly generated one, re-encrypts every
+// value with it, and protects it with a key derived from a new passphrase.
+//
+// Values are re-encrypted through a series of transactions. Until Rotate
+// completes, previous data keys remain available so that the database
+// can still be opened with the new passphrase if Rotate is interrupted.
+// The old passphrase stops working as soon as Rotate begins.
+func (e *DB) Rotate(passphrase []byte) error {
+	e.mutex.RLock()
+	old := e.meta
+	e.mutex.RUnlock()
+
+	// Record the new key along with the old ones, all protected by the new
+	// passphrase.
+	m, err := newMeta(passphrase, old.flags&flagPerPartition != 0, old.logN, old.keys[0].id+1)
+	if err != nil {
+		return err
+	}
+	kek, err := m.kek(passphrase)
+	if err != nil {
+		return err
+	}
+	for _, k := range old.keys {
+		e.mutex.RLock()
+		dk, err := e.dataKey(k.id)
+		e.mutex.RUnlock()
+		if err != nil {
+			return err
+		}
+		wrapped, err := wrap(kek, dk)
+		if err != nil {
+			return err
+		}
+		m.keys = append(m.keys, wrappedKey{k.id, wrapped})
+	}
+	if err = e.setMeta(m, passphrase); err != nil {
+		return err
+	}
+
+	// Re-encrypt all values that were encrypted with an older key.
+	newID := m.keys[0].id
+	var start []byte
+	for {
+		n, next, err := e.reencrypt(start, newID)
+		if err != nil {
+			return err
+		}
+		if n < rotateBatchCount {
+			break
+		}
+		start = next
+	}
+
+	// Forget the old keys.
+	m.keys = m.keys[:1]
+	return e.setMeta(m, passphrase)
+}
+
+// reencrypt re-encrypts up to rotateBatchCount values with keys greater than
+// or equal to start, returning the number of keys visited and the key that
+// follows the last one.
+func (e *DB) reencrypt(start []byte, id uint32) (int, []byte, error) {
+	t := e.db.NewTxn(true)
+	defer t.Discard()
+	type pair struct{ key, value []byte }
+	var (
+		pairs []pair
+		n     int
+		next  []byte
+	)
+	iter := t.PrefixIterator(nil)
+	for iter.Seek(start); iter.Valid() && n < rotateBatchCount; iter.Next() {
+		n++
+		key := append([]byte(nil), iter.Key()...)
+		next = append(key[:len(key):len(key)], 0)
+		if reserved(key) {
+			continue
+		}
+		err := iter.Value(func(v []byte) error {
+			if len(v) == 0 {
+				// Empty values, such as index rows, are stored as they are.
+				return nil
+			}
+			if len(v) >= 4 && binary.BigEndian.Uint32(v) == id {
+				return nil
+			}
+			plain, err := e.open(key, v)
+			if err != nil {
+				return err
+			}
+			pairs = append(pairs, pair{key, plain})
+			return nil
+		})
+		if err != nil {
+			iter.Discard()
+			return 0, nil, err
+		}
+	}
+	iter.Discard()
+	for _, p := range pairs {
+		v, err := e.seal(p.key, p.value)
+		if err != nil {
+			return 0, nil, err
+		}
+		if err = t.Set(p.key, v); err != nil {
+			return 0, nil, err
+		}
+	}
+	return n, next, t.Commit()
+}
+
+// setMeta stores m and makes its keys available to e.
+func (e *DB) setMeta(m meta, passphrase []byte) error {
+	t := e.db.NewTxn(true)
+	defer t.Discard()
+	if err := t.Set(metaKey, m.Encode()); err != nil {
+		return err
+	}
+	if err := t.Commit(); err != nil {
+		return err
+	}
+	return e.load(m, passphrase)
+}
+
+// dataKey returns the plaintext of the data key identified by id.
+//
+// The caller must hold e.mutex.
+func (e *DB) dataKey(id uint32) ([]byte, error) {
+	a, ok := e.aeads[id]
+	if !ok {
+		return nil, ErrCorrupt
+	}
+	return a.(*dataAEAD).key, nil
+}
+
+// aead returns the AEAD for values stored under key with the data key
+// identified by id.
+func (e *DB) aead(key []byte, id uint32) (cipher.AEAD, error) {
+	e.mutex.RLock()
+	a, ok := e.aeads[id]
+	perPartition := e.perPartition
+	e.mutex.RUnlock()
+	if !ok {
+		return nil, ErrCorrupt
+	}
+	if !perPartition || len(key) < 8 {
+		return a, nil
+	}
+	mac := hmac.New(sha256.New, a.(*dataAEAD).key)
+	mac.Write([]byte("partition"))
+	mac.Write(key[:8])
+	return newAEAD(mac.Sum(nil))
+}
+
+// seal encrypts value for storage under key with the current data key.
+func (e *DB) seal(key, value []byte) ([]byte, error) {
+	e.mutex.RLock()
+	id := e.meta.keys[0].id
+	e.mutex.RUnlock()
+	a, err := e.aead(key, id)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, valueHeaderSize, valueHeaderSize+len(value)+a.Overhead())
+	binary.BigEndian.PutUint32(out, id)
+	if _, err := io.ReadFull(rand.Reader, out[4:valueHeaderSize]); err != nil {
+		return nil, err
+	}
+	return a.Seal(out, out[4:valueHeaderSize], value, key), nil
+}
+
+// open decrypts a value stored under key.
+//
+// Empty values are not encrypted, since Txn.Get does not distinguish them
+// from missing values, and neither are values of reserved keys.
+func (e *DB) open(key, value []byte) ([]byte, error) {
+	if len(value) == 0 || reserved(key) {
+		return value, nil
+	}
+	if len(value) < valueHeaderSize {
+		return nil, ErrCorrupt
+	}
+	a, err := e.aead(key, binary.BigEndian.Uint32(value))
+	if err != nil {
+		return nil, err
+	}
+	plain, err := a.Open(nil, value[4:valueHeaderSize], value[valueHeaderSize:], key)
+	if err != nil {
+		return nil, ErrCorrupt
+	}
+	return plain, nil
+}
+
+func reserved(key []byte) bool { return len(key) == 1 }
+
+// dataAEAD remembers the key used to create an AEAD so that keys for
+// partitions can be derived from it.
+type dataAEAD struct {
+	cipher.AEAD
+	key []byte
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &dataAEAD{gcm, key}, nil
+}
+
+// wrap encrypts a data key with a key derived from a passphrase.
+func wrap(kek cipher.AEAD, dk []byte) ([]byte, error) {
+	nonce := make([]byte, nonceSize, nonceSize+len(dk)+kek.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return kek.Seal(nonce, nonce, dk, metaKey), nil
+}
+
+// meta describes how the values in a database are encrypted.
+//
+// The first of its keys is the current data key. Any others are only present
+// while Rotate is in progress.
+type meta struct {
+	version uint8
+	flags   uint8
+	logN    uint8
+	r, p    uint8
+	salt    []byte
+	keys    []wrappedKey
+}
+
+type wrappedKey struct {
+	id      uint32
+	wrapped []byte
+}
+
+// newMeta generates a salt and a data key identified by id, protected by
+// passphrase.
+func newMeta(passphrase []byte, perPartition bool, logN uint8, id uint32) (meta, error) {
+	if logN == 0 {
+		logN = DefaultScryptLogN
+	}
+	m := meta{
+		version: metaVersion,
+		logN:    logN,
+		r:       8,
+		p:       1,
+		salt:    make([]byte, saltSize),
+	}
+	if perPartition {
+		m.flags |= flagPerPartition
+	}
+	dk := make([]byte, keySize)
+	for _, bs := range [][]byte{m.salt, dk} {
+		if _, err := io.ReadFull(rand.Reader, bs); err != nil {
+			return meta{}, err
+		}
+	}
+	kek, err := m.kek(passphrase)
+	if err != nil {
+		return meta{}, err
+	}
+	wrapped, err := wrap(kek, dk)
+	if err != nil {
+		return meta{}, err
+	}
+	m.keys = []wrappedKey{{id, wrapped}}
+	return m, nil
+}
+
+// kek derives the key-encryption key from passphrase.
+func (m meta) kek(passphrase []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key(passphrase, m.salt, 1<<m.logN, int(m.r), int(m.p), keySize)
+	if err != nil {
+		return nil, err
+	}
+	return newAEAD(key)
+}
+
+// Encode implements kv.Encoder.
+func (m meta) Encode() []byte {
+	var buf bytes.Buffer
+	buf.Write([]byte{m.version, m.flags, m.logN, m.r, m.p, byte(len(m.keys))})
+	buf.Write(m.salt)
+	for _, k := range m.keys {
+		var id [4]byte
+		binary.BigEndian.PutUint32(id[:], k.id)
+		buf.Write(id[:])
+		buf.WriteByte(byte(len(k.wrapped)))
+		buf.Write(k.wrapped)
+	}
+	return buf.Bytes()
+}
+
+// Decode implements kv.Decoder.
+//
+// An empty src decodes as a meta with version zero.
+func (m *meta) Decode(src []byte) error {
+	*m = meta{}
+	if len(src) == 0 {
+		return nil
+	}
+	if len(src) < 6+saltSize {
+		return fmt.Errorf("encrypted: invalid metadata")
+	}
+	if src[0] != metaVersion {
+		return fmt.Errorf("encrypted: unsupported metadata version %v", src[0])
+	}
+	m.version, m.flags, m.logN, m.r, m.p = src[0], src[1], src[2], src[3], src[4]
+	n := int(src[5])
+	m.salt = append([]byte(nil), src[6:6+saltSize]...)
+	src = src[6+saltSize:]
+	for i := 0; i < n; i++ {
+		if len(src) < 5 || len(src) < 5+int(src[4]) {
+			return fmt.Errorf("encrypted: invalid metadata")
+		}
+		m.keys = append(m.keys, wrappedKey{
+			id:      binary.BigEndian.Uint32(src),
+			wrapped: append([]byte(nil), src[5:5+int(src[4])]...),
+		})
+		src = src[5+int(src[4]):]
+	}
+	if len(m.keys) == 0 {
+		return fmt.Errorf("encrypted: invalid metadata")
+	}
+	return nil
+}
+
+type txn struct {
+	kv.TxnCommitDiscarder
+	db *DB
+}
+
+func (t txn) Set(key, value []byte) error {
+	if bytes.Equal(key, metaKey) {
+		return ErrReservedKey
+	}
+	if len(value) > 0 && !reserved(key) {
+		var err error
+		if value, err = t.db.seal(key, value); err != nil {
+			return err
+		}
+	}
+	return t.TxnCommitDiscarder.Set(key, value)
+}
+
+func (t txn) Delete(key []byte) error {
+	if bytes.Equal(key, metaKey) {
+		return ErrReservedKey
+	}
+	return t.TxnCommitDiscarder.Delete(key)
+}
+
+func (t txn) Get(key []byte, f func([]byte) error) error {
+	if bytes.Equal(key, metaKey) {
+		return f(nil)
+	}
+	return t.TxnCommitDiscarder.Get(key, func(v []byte) error {
+		plain, err := t.db.open(key, v)
+		if err != nil {
+			return err
+		}
+		return f(plain)
+	})
+}
+
+func (t txn) PrefixIterator(prefix []byte) kv.Iterator {
+	return t.iterator(prefix, t.TxnCommitDiscarder.PrefixIterator(prefix))
+}
+
+func (t txn) ReversePrefixIterator(prefix []byte) kv.Iterator {
+	return t.iterator(prefix, t.TxnCommitDiscarder.ReversePrefixIterator(prefix))
+}
+
+func (t txn) iterator(prefix []byte, iter kv.Iterator) kv.Iterator {
+	i := &iterator{Iterator: iter, db: t.db, prefix: prefix}
+	// Only an iterator that could visit metaKey needs to skip it.
+	if len(prefix) == 0 || bytes.Equal(prefix, metaKey) {
+		i.hidden = metaKey[len(prefix):]
+	}
+	return i
+}
+
+// iterator decrypts values and hides the metadata stored by this package.
+type iterator struct {
+	kv.Iterator
+	db     *DB
+	prefix []byte
+	hidden []byte
+}
+
+func (i *iterator) skip() {
+	if i.hidden != nil && i.Iterator.Valid() && bytes.Equal(i.Iterator.Key(), i.hidden) {
+		i.Iterator.Next()
+	}
+}
+
+func (i *iterator) Seek(key []byte) {
+	i.Iterator.Seek(key)
+	i.skip()
+}
+
+func (i *iterator) Next() {
+	i.Iterator.Next()
+	i.skip()
+}
+
+func (i *iterator) Value(f func([]byte) error) error {
+	key := append(append([]byte(nil), i.prefix...), i.Iterator.Key()...)
+	return i.Iterator.Value(func(v []byte) error {
+		plain, err := i.db.open(key, v)
+		if err != nil {
+			return err
+		}
+		return f(plain)
+	})
+}
@@ -0,0 +1,183 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encrypted
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"testing"
+
+	"github.com/google/note-maps/kv"
+	"github.com/google/note-maps/kv/kvtest"
+	"github.com/google/note-maps/kv/memory"
+)
+
+// testOptions keeps key derivation fast in tests.
+var testOptions = Options{ScryptLogN: 4}
+
+func backends(t *testing.T) map[string]func() kv.DB {
+	return map[string]func() kv.DB{
+		"memory": memory.NewDB,
+		"badger": func() kv.DB { return kvtest.NewDB(t) },
+	}
+}
+
+func set(t *testing.T, db kv.DB, pairs map[string]string) {
+	txn := db.NewTxn(true)
+	defer txn.Discard()
+	for k, v := range pairs {
+		if err := txn.Set([]byte(k), []byte(v)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := txn.Commit(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func get(t *testing.T, db kv.DB, key string) (got string) {
+	txn := db.NewTxn(false)
+	defer txn.Discard()
+	if err := txn.Get([]byte(key), func(v []byte) error {
+		got = string(v)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return got
+}
+
+func TestEncrypt(t *testing.T) {
+	for name, newDB := range backends(t) {
+		for _, perPartition := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%v per partition %v", name, perPartition), func(t *testing.T) {
+				raw := newDB()
+				defer raw.Close()
+				opts := testOptions
+				opts.PerPartition = perPartition
+				db, err := Open(raw, []byte("secret"), &opts)
+				if err != nil {
+					t.Fatal(err)
+				}
+				want := map[string]string{
+					"partition one":   "private notes",
+					"partition two":   "private notes",
+					"partition three": "",
+				}
+				set(t, db, want)
+				for k, v := range want {
+					if got := get(t, db, k); got != v {
+						t.Errorf("%q: want %q, got %q", k, v, got)
+					}
+					if stored := get(t, raw, k); v != "" && bytes.Contains([]byte(stored), []byte(v)) {
+						t.Errorf("%q: stored value %q contains plaintext", k, stored)
+					}
+				}
+
+				// Values cannot be moved between keys.
+				set(t, raw, map[string]string{"partition two": get(t, raw, "partition one")})
+				txn := db.NewTxn(false)
+				defer txn.Discard()
+				if err := txn.Get([]byte("partition two"), func([]byte) error { return nil }); err != ErrCorrupt {
+					t.Errorf("want %v, got %v", ErrCorrupt, err)
+				}
+
+				// Iteration decrypts values and hides metadata.
+				iter := txn.PrefixIterator(nil)
+				defer iter.Discard()
+				var keys []string
+				for iter.Seek(nil); iter.Valid(); iter.Next() {
+					keys = append(keys, string(iter.Key()))
+					if string(iter.Key()) == "partition one" {
+						iter.Value(func(v []byte) error {
+							if string(v) != want["partition one"] {
+								t.Errorf("want %q, got %q", want["partition one"], v)
+							}
+							return nil
+						})
+					}
+				}
+				if len(keys) != len(want) {
+					t.Errorf("want %v keys, got %q", len(want), keys)
+				}
+			})
+		}
+	}
+}
+
+func TestReopen(t *testing.T) {
+	raw := memory.NewDB()
+	defer raw.Close()
+	db, err := Open(raw, []byte("secret"), &testOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	set(t, db, map[string]string{"key": "value"})
+	if _, err = Open(raw, []byte("wrong"), nil); err != ErrPassphrase {
+		t.Errorf("want %v, got %v", ErrPassphrase, err)
+	}
+	if db, err = Open(raw, []byte("secret"), nil); err != nil {
+		t.Fatal(err)
+	} else if got := get(t, db, "key"); got != "value" {
+		t.Errorf("want %q, got %q", "value", got)
+	}
+	txn := db.NewTxn(true)
+	defer txn.Discard()
+	if err = txn.Set(metaKey, nil); err != ErrReservedKey {
+		t.Errorf("want %v, got %v", ErrReservedKey, err)
+	}
+}
+
+func TestRotate(t *testing.T) {
+	raw := memory.NewDB()
+	defer raw.Close()
+	db, err := Open(raw, []byte("old"), &Options{ScryptLogN: 4, PerPartition: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := make(map[string]string)
+	for i := 0; i < rotateBatchCount*2+1; i++ {
+		want[fmt.Sprintf("key %08d", i)] = fmt.Sprintf("value %v", i)
+	}
+	want["empty"] = ""
+	set(t, db, want)
+	if err = db.Rotate([]byte("new")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = Open(raw, []byte("old"), nil); err != ErrPassphrase {
+		t.Errorf("old passphrase: want %v, got %v", ErrPassphrase, err)
+	}
+	if db, err = Open(raw, []byte("new"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(db.meta.keys); n != 1 {
+		t.Errorf("want one data key after rotation, got %v", n)
+	}
+	for k, v := range want {
+		if got := get(t, db, k); got != v {
+			t.Fatalf("%q: want %q, got %q", k, v, got)
+		}
+		if v == "" {
+			if stored := get(t, raw, k); stored != "" {
+				t.Errorf("%q: want empty value to stay empty, got %q", k, stored)
+			}
+			continue
+		}
+		if id := binary.BigEndian.Uint32([]byte(get(t, raw, k))); id != 2 {
+			t.Fatalf("%q: want key id 2, got %v", k, id)
+		}
+	}
+}
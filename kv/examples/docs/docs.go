@@ -27,6 +27,7 @@ import (
 	"io"
 	"log"
 	"strings"
+	"time"
 
 	"github.com/google/note-maps/kv"
 )
@@ -37,12 +38,27 @@ const (
 
 	// TitlePrefix is a unique identifier for the Title component in this schema.
 	TitlePrefix kv.Component = 4
+
+	// AuthorTitlePrefix is a unique identifier for the AuthorTitle index in
+	// this schema.
+	AuthorTitlePrefix kv.Component = 5
+
+	// ModifiedPrefix is a unique identifier for the Modified index in this
+	// schema.
+	ModifiedPrefix kv.Component = 6
+
+	// PriorityModifiedPrefix is a unique identifier for the PriorityModified
+	// index in this schema.
+	PriorityModifiedPrefix kv.Component = 7
 )
 
 // Document is a component value type.
 type Document struct {
-	Title   string
-	Content string
+	Title    string
+	Content  string
+	Author   string
+	Priority int
+	Modified time.Time
 }
 
 // Encode implements kv.Encoder for storing documents in a kv.Txn.
@@ -80,3 +96,49 @@ func (d *Document) IndexTitle() []kv.String {
 		kv.String(strings.ToLower(d.Title)),
 	}
 }
+
+// AuthorTitle is a value for a composite index over authors and titles.
+//
+// Since AuthorTitle is a struct whose fields all implement kv.Encoder,
+// kvschema treats it as a tuple: documents can be found by author and title
+// together, or by author alone.
+type AuthorTitle struct {
+	Author kv.String
+	Title  kv.String
+}
+
+// IndexAuthorTitle provides values that should be mapped back to this
+// document through a composite index.
+func (d *Document) IndexAuthorTitle() []AuthorTitle {
+	return []AuthorTitle{{
+		Author: kv.String(d.Author),
+		Title:  kv.String(strings.ToLower(d.Title)),
+	}}
+}
+
+// IndexModified provides values that should be mapped back to this document
+// through an index.
+//
+// Since kv.Time encodes times in chronological order, documents can be loaded
+// in the order they were modified, or only those modified within a range of
+// times.
+func (d *Document) IndexModified() []kv.Time {
+	return []kv.Time{{Time: d.Modified}}
+}
+
+// PriorityModified is a value for a composite index over priorities and
+// modification times.
+type PriorityModified struct {
+	Priority kv.Int64
+	Modified kv.Time
+}
+
+// IndexPriorityModified provides values that should be mapped back to this
+// document through a composite index, so that documents of a given priority
+// can be loaded in the order they were modified.
+func (d *Document) IndexPriorityModified() []PriorityModified {
+	return []PriorityModified{{
+		Priority: kv.Int64(d.Priority),
+		Modified: kv.Time{Time: d.Modified},
+	}}
+}
@@ -15,12 +15,21 @@
 package docs
 
 import (
+	"context"
 	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/google/note-maps/kv"
+	"github.com/google/note-maps/kv/badger"
+	"github.com/google/note-maps/kv/bolt/bolttest"
 	"github.com/google/note-maps/kv/kvtest"
+	"github.com/google/note-maps/kv/memory"
+	"github.com/google/note-maps/kv/sqlite/sqlitetest"
 )
 
 func sampleDocuments(t string, n int) []Document {
@@ -124,6 +133,46 @@ func TestCreateUpdateRead(t *testing.T) {
 	kvtest.Deflake(t, test)
 }
 
+func TestCreateDeleteRead(t *testing.T) {
+	test := func(s_ kv.Txn) {
+		s := New(s_)
+		samples := sampleDocuments("Deleted", 5)
+		des := createDocuments(&s, samples)
+		for _, de := range des[:3] {
+			if err := s.DeleteDocument(de); err != nil {
+				panic(err)
+			}
+		}
+		verifyDocuments(&s, des[3:], samples[3:])
+		for i, de := range des[:3] {
+			if got, err := s.GetDocument(de); err != nil {
+				panic(err)
+			} else if got.Title != "" || got.Content != "" {
+				panic(fmt.Sprintf("%v: want empty document, got %#v", de, got))
+			}
+			matches, err := s.EntitiesMatchingDocumentTitle(samples[i].IndexTitle()[0])
+			if err != nil {
+				panic(err)
+			} else if len(matches) != 0 {
+				panic(fmt.Sprintf("want no documents matching %#v, got %#v",
+					samples[i].IndexTitle()[0], matches))
+			}
+		}
+		var cursor kv.IndexCursor
+		es, err := s.EntitiesByDocumentTitle(&cursor, len(des))
+		if err != nil {
+			panic(err)
+		}
+		kv.EntitySlice(es).Sort()
+		want := kv.EntitySlice(append([]kv.Entity{}, des[3:]...))
+		want.Sort()
+		if !want.Equal(es) {
+			panic(fmt.Sprintf("want %#v, got %#v", want, es))
+		}
+	}
+	kvtest.Deflake(t, test)
+}
+
 func TestIterator(t *testing.T) {
 	test := func(s_ kv.Txn) {
 		s := New(s_)
@@ -174,6 +223,61 @@ func TestIterator(t *testing.T) {
 	kvtest.Deflake(t, test)
 }
 
+func TestRange(t *testing.T) {
+	test := func(s_ kv.Txn) {
+		s := New(s_)
+		createDocuments(&s, sampleDocuments("A", 3))
+		createDocuments(&s, sampleDocuments("B", 3))
+		createDocuments(&s, sampleDocuments("B", 3))
+		createDocuments(&s, sampleDocuments("C", 3))
+		lo, hi := kv.String("b"), kv.String("c")
+		for _, reverse := range []bool{false, true} {
+			for pageSize := 1; pageSize < 8; pageSize++ {
+				var (
+					cursor kv.IndexCursor
+					got    []kv.Entity
+				)
+				for {
+					es, err := s.EntitiesByDocumentTitleRange(
+						&lo, &hi, reverse, &cursor, pageSize)
+					if err != nil {
+						panic(err)
+					}
+					got = append(got, es...)
+					if len(es) < pageSize {
+						break
+					}
+				}
+				if len(got) != 6 {
+					t.Fatalf("reverse=%v pageSize=%v: want 6 entities, got %v",
+						reverse, pageSize, len(got))
+				}
+				ds, err := s.GetDocumentSlice(got)
+				if err != nil {
+					panic(err)
+				}
+				for i := range ds {
+					if ds[i].Title[0] != 'B' {
+						t.Fatalf("want only titles starting with B, got %#v",
+							ds[i].Title)
+					}
+					if i == 0 {
+						continue
+					}
+					if reverse && ds[i-1].Title < ds[i].Title ||
+						!reverse && ds[i-1].Title > ds[i].Title ||
+						ds[i-1].Title == ds[i].Title &&
+							(got[i-1] < got[i]) == reverse {
+						t.Fatalf("reverse=%v: got %#v (%v) before %#v (%v)",
+							reverse, ds[i-1].Title, got[i-1], ds[i].Title, got[i])
+					}
+				}
+			}
+		}
+	}
+	kvtest.Deflake(t, test)
+}
+
 func TestAllDocumentEntities(t *testing.T) {
 	test := func(s_ kv.Txn) {
 		s := New(s_)
@@ -204,3 +308,463 @@ func TestAllDocumentEntities(t *testing.T) {
 	}
 	kvtest.Deflake(t, test)
 }
+
+func TestWatchDocument(t *testing.T) {
+	db := memory.NewDB()
+	defer db.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	type change struct {
+		E kv.Entity
+		D Document
+	}
+	ch := make(chan change, 100)
+	go WatchDocument(ctx, db, 7, func(e kv.Entity, d Document) error {
+		ch <- change{e, d}
+		return nil
+	})
+	update := func(f func(Txn) error) {
+		txn := db.NewTxn(true)
+		defer txn.Discard()
+		s := New(txn)
+		s.Partition = 7
+		if err := f(s); err != nil {
+			t.Fatal(err)
+		}
+		if err := txn.Commit(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := Document{Title: "Title", Content: "Content"}
+	// WatchDocument may not have begun watching yet, so keep committing
+	// changes until one of them is delivered.
+	for waiting := true; waiting; {
+		update(func(s Txn) error { return s.SetDocument(1, &want) })
+		select {
+		case got := <-ch:
+			if got.E != 1 || !reflect.DeepEqual(want, got.D) {
+				t.Fatalf("want 1 %#v, got %v %#v", want, got.E, got.D)
+			}
+			waiting = false
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	update(func(s Txn) error { return s.DeleteDocument(1) })
+	for got := range ch {
+		if got.E != 1 {
+			t.Fatalf("want changes only to entity 1, got %v", got.E)
+		}
+		if reflect.DeepEqual(Document{}, got.D) {
+			break
+		}
+	}
+}
+
+func TestBatch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestBatch-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// A small table size makes badger's transaction size limit small too.
+	db, err := badger.Open(badger.DefaultOptions(dir).WithMaxTableSize(1 << 20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	const n = 4000
+	sample := func(i int) *Document {
+		return &Document{Title: fmt.Sprintf("title %v", i%10), Content: "Lorem ipsum something"}
+	}
+
+	// Check that n documents are too many for a single transaction.
+	txn := db.NewTxn(true)
+	for i := 0; i < n; i++ {
+		if err = New(txn).SetDocument(kv.Entity(i+1), sample(i)); err != nil {
+			break
+		}
+	}
+	txn.Discard()
+	if err != kv.ErrTxnTooBig {
+		t.Fatalf("want %v, got %v", kv.ErrTxnTooBig, err)
+	}
+
+	// Import the documents, and then retitle each of them, so that the
+	// second pass replaces index rows written in earlier transactions.
+	for _, offset := range []int{0, 1} {
+		b := kv.NewBatch(db)
+		b.MaxWrites = 0
+		s := New(b)
+		for i := 0; i < n; i++ {
+			if err := s.SetDocument(kv.Entity(i+1), sample(i+offset)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := b.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		b.Discard()
+	}
+
+	txn = db.NewTxn(false)
+	defer txn.Discard()
+	s := New(txn)
+	if es, err := s.AllDocumentEntities(nil, 0); err != nil {
+		t.Fatal(err)
+	} else if len(es) != n {
+		t.Errorf("want %v documents, got %v", n, len(es))
+	}
+	for i := 0; i < 10; i++ {
+		title := kv.String(fmt.Sprintf("title %v", i))
+		if es, err := s.EntitiesMatchingDocumentTitle(title); err != nil {
+			t.Fatal(err)
+		} else if len(es) != n/10 {
+			t.Errorf("want %v documents titled %q, got %v", n/10, title, len(es))
+		}
+	}
+	if problems, err := s.VerifyIndexes(); err != nil {
+		t.Fatal(err)
+	} else if len(problems) != 0 {
+		t.Errorf("want consistent indexes, got %v problems such as %v", len(problems), problems[0])
+	}
+}
+
+func TestAuthorTitle(t *testing.T) {
+	s := New(memory.New())
+	ds := []Document{
+		{Author: "ann", Title: "C"},
+		{Author: "anna", Title: "A"},
+		{Author: "ann", Title: "a"},
+		{Author: "an", Title: "B"},
+		{Author: "ann", Title: "b"},
+		{Author: "ann\x00", Title: "B"},
+	}
+	es := createDocuments(&s, ds)
+	got, err := s.EntitiesMatchingDocumentAuthorTitleWithAuthor("ann")
+	if err != nil {
+		t.Fatal(err)
+	} else if want := (kv.EntitySlice{es[0], es[2], es[4]}); !want.Equal(got) {
+		t.Errorf("matching author: want %v, got %v", want, got)
+	}
+	got, err = s.EntitiesMatchingDocumentAuthorTitle(AuthorTitle{"ann", "a"})
+	if err != nil {
+		t.Fatal(err)
+	} else if want := (kv.EntitySlice{es[2]}); !want.Equal(got) {
+		t.Errorf("matching author and title: want %v, got %v", want, got)
+	}
+	want := []kv.Entity{es[2], es[4], es[0]}
+	for n := 1; n <= len(want)+1; n++ {
+		var (
+			cursor kv.IndexCursor
+			all    []kv.Entity
+		)
+		for {
+			page, err := s.EntitiesByDocumentAuthorTitleWithAuthor("ann", &cursor, n)
+			if err != nil {
+				t.Fatal(err)
+			}
+			all = append(all, page...)
+			if len(page) < n {
+				break
+			}
+		}
+		if !reflect.DeepEqual(want, all) {
+			t.Errorf("n=%v: want %v ordered by title, got %v", n, want, all)
+		}
+	}
+	all, err := s.EntitiesByDocumentAuthorTitle(&kv.IndexCursor{}, len(ds)+1)
+	if err != nil {
+		t.Fatal(err)
+	} else if want := []kv.Entity{es[3], es[2], es[4], es[0], es[5], es[1]}; !reflect.DeepEqual(want, all) {
+		t.Errorf("want %v ordered by author and then title, got %v", want, all)
+	}
+}
+
+func TestRetitleOnDisk(t *testing.T) {
+	db := kvtest.NewDB(t)
+	defer db.Close()
+	for _, d := range []Document{
+		{Author: "ann", Title: "Draft"},
+		{Author: "ann", Title: "Final"},
+	} {
+		if err := kv.Update(context.Background(), db, func(t kv.Txn) error {
+			return New(t).SetDocument(10, &d)
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := kv.View(context.Background(), db, func(t kv.Txn) error {
+		s := New(t)
+		for _, test := range []struct {
+			Title kv.String
+			Want  []kv.Entity
+		}{
+			{"draft", nil},
+			{"final", []kv.Entity{10}},
+		} {
+			got, err := s.EntitiesMatchingDocumentTitle(test.Title)
+			if err != nil {
+				return err
+			} else if !kv.EntitySlice(test.Want).Equal(got) {
+				return fmt.Errorf("%#v: want %v, got %v", test.Title, test.Want, got)
+			}
+		}
+		ps, err := s.VerifyIndexes()
+		if err != nil {
+			return err
+		} else if len(ps) != 0 {
+			return fmt.Errorf("want no index problems, got %v", ps)
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestModified(t *testing.T) {
+	s := New(memory.New())
+	t0 := time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC)
+	ds := []Document{
+		{Title: "a", Priority: 1, Modified: t0.Add(time.Hour)},
+		{Title: "b", Priority: -1, Modified: t0},
+		{Title: "c", Priority: 1, Modified: t0.Add(-time.Hour)},
+		{Title: "d", Priority: 0, Modified: t0.Add(time.Nanosecond)},
+		{Title: "e", Priority: 1, Modified: t0.AddDate(-30, 0, 0)},
+	}
+	es := createDocuments(&s, ds)
+	all, err := s.EntitiesByDocumentModified(&kv.IndexCursor{}, len(ds)+1)
+	if err != nil {
+		t.Fatal(err)
+	} else if want := []kv.Entity{es[4], es[2], es[1], es[3], es[0]}; !reflect.DeepEqual(want, all) {
+		t.Errorf("want %v ordered by time, got %v", want, all)
+	}
+	lo, hi := kv.Time{Time: t0}, kv.Time{Time: t0.Add(time.Hour)}
+	all, err = s.EntitiesByDocumentModifiedRange(&lo, &hi, true, &kv.IndexCursor{}, len(ds)+1)
+	if err != nil {
+		t.Fatal(err)
+	} else if want := []kv.Entity{es[3], es[1]}; !reflect.DeepEqual(want, all) {
+		t.Errorf("want %v within range in reverse, got %v", want, all)
+	}
+	all, err = s.EntitiesByDocumentPriorityModified(&kv.IndexCursor{}, len(ds)+1)
+	if err != nil {
+		t.Fatal(err)
+	} else if want := []kv.Entity{es[1], es[3], es[4], es[2], es[0]}; !reflect.DeepEqual(want, all) {
+		t.Errorf("want %v ordered by priority and then time, got %v", want, all)
+	}
+	all, err = s.EntitiesByDocumentPriorityModifiedWithPriority(1, &kv.IndexCursor{}, len(ds)+1)
+	if err != nil {
+		t.Fatal(err)
+	} else if want := []kv.Entity{es[4], es[2], es[0]}; !reflect.DeepEqual(want, all) {
+		t.Errorf("want %v with priority 1 ordered by time, got %v", want, all)
+	}
+}
+
+func TestVerifyRebuildIndexes(t *testing.T) {
+	s := New(memory.New())
+	es := createDocuments(&s, sampleDocuments("Test", 5))
+	if ps, err := s.VerifyIndexes(); err != nil {
+		t.Fatal(err)
+	} else if len(ps) != 0 {
+		t.Fatalf("want no problems with new indexes, got %v", ps)
+	}
+	titleKey := func(title string) []byte {
+		key := make(kv.Prefix, 8+2+8+2)
+		s.Partition.EncodeAt(key)
+		DocumentPrefix.EncodeAt(key[8:])
+		TitlePrefix.EncodeAt(key[18:])
+		return append(key, title...)
+	}
+	// One row is lost, one is left behind, and one has an extra entity.
+	if err := s.Delete(titleKey("test 0")); err != nil {
+		t.Fatal(err)
+	} else if err = s.Set(titleKey("ghost"), kv.EntitySlice{es[1]}.Encode()); err != nil {
+		t.Fatal(err)
+	} else if err = s.Set(titleKey("test 2"), kv.EntitySlice{es[2], 999}.Encode()); err != nil {
+		t.Fatal(err)
+	}
+	ps, err := s.VerifyDocumentIndexes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, p := range ps {
+		if p.Component != DocumentPrefix || p.Index != TitlePrefix {
+			t.Errorf("want problems with the title index, got %v", p)
+		}
+		switch {
+		case p.Missing():
+			got = append(got, "missing "+string(p.Key))
+		case p.Orphaned():
+			got = append(got, "orphaned "+string(p.Key))
+		default:
+			got = append(got, "wrong "+string(p.Key))
+		}
+	}
+	if want := []string{"orphaned ghost", "missing test 0", "wrong test 2"}; !reflect.DeepEqual(want, got) {
+		t.Errorf("want problems %q, got %q", want, got)
+	}
+	if err = s.RebuildIndexes(); err != nil {
+		t.Fatal(err)
+	}
+	if ps, err = s.VerifyIndexes(); err != nil {
+		t.Fatal(err)
+	} else if len(ps) != 0 {
+		t.Errorf("want no problems after rebuilding indexes, got %v", ps)
+	}
+	if got, err := s.EntitiesMatchingDocumentTitle("test 0"); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(kv.EntitySlice{es[0]}, got) {
+		t.Errorf("want %v after rebuilding indexes, got %v", es[:1], got)
+	}
+}
+
+func TestModel(t *testing.T) {
+	titles := []string{"", "a", "A", "ab", "b"}
+	authors := []string{"", "a", "a\x00", "ab"}
+	times := []time.Time{{}, time.Date(1969, 12, 31, 23, 59, 59, 0, time.UTC), time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC)}
+	m := kvtest.Model{
+		New: New,
+		Values: map[string]func(*rand.Rand) interface{}{
+			"Document": func(r *rand.Rand) interface{} {
+				return &Document{
+					Title:    titles[r.Intn(len(titles))],
+					Content:  fmt.Sprint(r.Intn(3)),
+					Author:   authors[r.Intn(len(authors))],
+					Priority: r.Intn(3) - 1,
+					Modified: times[r.Intn(len(times))],
+				}
+			},
+		},
+	}
+	for _, backend := range []struct {
+		Name  string
+		NewDB func(testing.TB) kv.DB
+	}{
+		{"memory", nil},
+		{"badger", kvtest.NewDB},
+		{"bolt", bolttest.NewDB},
+	} {
+		backend := backend
+		t.Run(backend.Name, func(t *testing.T) {
+			m := m
+			if backend.NewDB != nil {
+				m.NewDB = func() kv.DB { return backend.NewDB(t) }
+			}
+			kvtest.RunModel(t, m)
+		})
+	}
+}
+
+// benchmarkBackends runs f as a sub-benchmark against each kv.DB
+// implementation.
+func benchmarkBackends(b *testing.B, f func(b *testing.B, db kv.DB)) {
+	for _, backend := range []struct {
+		Name string
+		New  func(testing.TB) kv.DB
+	}{
+		{"memory", func(testing.TB) kv.DB { return memory.NewDB() }},
+		{"badger", kvtest.NewDB},
+		{"bolt", bolttest.NewDB},
+		{"sqlite", sqlitetest.NewDB},
+	} {
+		b.Run(backend.Name, func(b *testing.B) {
+			db := backend.New(b)
+			defer db.Close()
+			f(b, db)
+		})
+	}
+}
+
+// populate creates n documents in db through transactions of a modest size.
+func populate(b *testing.B, db kv.DB, ds []Document) {
+	const batch = 500
+	for i := 0; i < len(ds); i += batch {
+		txn := db.NewTxn(true)
+		s := New(txn)
+		end := i + batch
+		if end > len(ds) {
+			end = len(ds)
+		}
+		createDocuments(&s, ds[i:end])
+		if err := txn.Commit(); err != nil {
+			b.Fatal(err)
+		}
+		txn.Discard()
+	}
+}
+
+const benchmarkDocuments = 10000
+
+func BenchmarkSetDocument(b *testing.B) {
+	benchmarkBackends(b, func(b *testing.B, db kv.DB) {
+		populate(b, db, sampleDocuments("Benchmark", b.N))
+	})
+}
+
+func BenchmarkEntitiesByDocumentTitle(b *testing.B) {
+	benchmarkBackends(b, func(b *testing.B, db kv.DB) {
+		populate(b, db, sampleDocuments("Benchmark", benchmarkDocuments))
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			txn := db.NewTxn(false)
+			s := New(txn)
+			var (
+				cursor kv.IndexCursor
+				n      int
+			)
+			for {
+				es, err := s.EntitiesByDocumentTitle(&cursor, 100)
+				if err != nil {
+					b.Fatal(err)
+				}
+				n += len(es)
+				if len(es) < 100 {
+					break
+				}
+			}
+			if n != benchmarkDocuments {
+				b.Fatalf("want %v entities, got %v", benchmarkDocuments, n)
+			}
+			txn.Discard()
+		}
+	})
+}
+
+func BenchmarkEntitiesMatchingDocumentTitle(b *testing.B) {
+	benchmarkBackends(b, func(b *testing.B, db kv.DB) {
+		ds := sampleDocuments("Benchmark", benchmarkDocuments)
+		populate(b, db, ds)
+		txn := db.NewTxn(false)
+		defer txn.Discard()
+		s := New(txn)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			d := &ds[i%len(ds)]
+			es, err := s.EntitiesMatchingDocumentTitle(d.IndexTitle()[0])
+			if err != nil {
+				b.Fatal(err)
+			} else if len(es) != 1 {
+				b.Fatalf("want one match for %#v, got %v", d.Title, es)
+			}
+		}
+	})
+}
+
+func BenchmarkEntitiesWithPrefixDocumentTitle(b *testing.B) {
+	benchmarkBackends(b, func(b *testing.B, db kv.DB) {
+		populate(b, db, sampleDocuments("Benchmark", benchmarkDocuments))
+		txn := db.NewTxn(false)
+		defer txn.Discard()
+		s := New(txn)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			prefix := kv.String(fmt.Sprintf("benchmark %v", i%1000))
+			es, err := s.EntitiesWithPrefixDocumentTitle(prefix, 10)
+			if err != nil {
+				b.Fatal(err)
+			} else if len(es) == 0 {
+				b.Fatalf("want matches for %#v, got none", prefix)
+			}
+		}
+	})
+}
@@ -3,7 +3,10 @@
 package docs
 
 import (
+	"context"
+
 	"github.com/google/note-maps/kv"
+	"github.com/google/note-maps/kv/query"
 )
 
 // Txn provides entities, components, and indexes backed by a key-value store.
@@ -11,6 +14,46 @@ type Txn struct{ kv.Partitioned }
 
 func New(t kv.Txn) Txn { return Txn{kv.Partitioned{t, 0}} }
 
+// Query returns the first n entities that satisfy p, beginning with the first
+// entity greater than or equal to *start.
+//
+// A nil start value will be interpreted as a pointer to zero.
+//
+// A value of n less than or equal to zero will be interpretted as the largest
+// possible value.
+func (s Txn) Query(p query.Predicate, start *kv.Entity, n int) ([]kv.Entity, error) {
+	return query.Entities(s.Partitioned, p, start, n)
+}
+
+// VerifyIndexes calls the Verify method for the indexes of each component
+// type, and returns all of their differences.
+func (s Txn) VerifyIndexes() ([]kv.IndexProblem, error) {
+	var problems []kv.IndexProblem
+	for _, verify := range []func() ([]kv.IndexProblem, error){
+		s.VerifyDocumentIndexes,
+	} {
+		ps, err := verify()
+		if err != nil {
+			return nil, err
+		}
+		problems = append(problems, ps...)
+	}
+	return problems, nil
+}
+
+// RebuildIndexes calls the Rebuild method for the indexes of each component
+// type.
+func (s Txn) RebuildIndexes() error {
+	for _, rebuild := range []func() error{
+		s.RebuildDocumentIndexes,
+	} {
+		if err := rebuild(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // SetDocument sets the Document associated with e to v.
 //
 // Corresponding indexes are updated.
@@ -26,41 +69,212 @@ func (s Txn) SetDocument(e kv.Entity, v *Document) error {
 	if err := s.Set(key, v.Encode()); err != nil {
 		return err
 	}
+	if err := s.updateDocumentIndexes(e, &old, v); err != nil {
+		return err
+	}
+	return kv.Checkpoint(s.Txn)
+}
+
+// DeleteDocument removes the Document associated with e.
+//
+// Corresponding indexes are updated.
+func (s Txn) DeleteDocument(e kv.Entity) error {
+	key := make(kv.Prefix, 8+2+8)
+	s.Partition.EncodeAt(key)
+	DocumentPrefix.EncodeAt(key[8:])
+	e.EncodeAt(key[10:])
+	var old Document
+	if err := s.Get(key, old.Decode); err != nil {
+		return err
+	}
+	if err := s.Delete(key); err != nil {
+		return err
+	}
+	if err := s.updateDocumentIndexes(e, &old, nil); err != nil {
+		return err
+	}
+	return kv.Checkpoint(s.Txn)
+}
+
+// updateDocumentIndexes updates the index rows of e, which used to have the
+// Document old and now has v, where either may be nil if e had or has none.
+func (s Txn) updateDocumentIndexes(e kv.Entity, old, v *Document) error {
+	key := make(kv.Prefix, 8+2+8)
+	s.Partition.EncodeAt(key)
+	DocumentPrefix.EncodeAt(key[8:])
 	lek := len(key)
-	kv.Entity(0).EncodeAt(key[10:])
 	key = append(key, kv.Component(0).Encode()...)
+	// Each row is written with a key of its own, since a Txn may keep the
+	// keys passed to it.
 	var (
 		lik = len(key)
 		es  kv.EntitySlice
 	)
 
-	// Update Title index
-	key = key[:lek].AppendComponent(TitlePrefix)
-	for _, iv := range old.IndexTitle() {
-		key = append(key[:lik], iv.Encode()...)
-		if err := s.Get(key, es.Decode); err != nil {
-			return err
+	// Update AuthorTitle index
+	key = key[:lek:lek].AppendComponent(AuthorTitlePrefix)
+	if old != nil {
+		for _, iv := range old.IndexAuthorTitle() {
+			key = append(key[:lik:lik], kv.EncodeTuple(iv.Author, iv.Title)...)
+			es = es[:0]
+			if err := s.Get(key, es.Decode); err != nil {
+				return err
+			}
+			if es.Remove(e) {
+				if err := s.SetEntitySlice(key, es); err != nil {
+					return err
+				}
+			}
 		}
-		if es.Remove(e) {
-			if err := s.Set(key, es.Encode()); err != nil {
+	}
+	if v != nil {
+		for _, iv := range v.IndexAuthorTitle() {
+			key = append(key[:lik:lik], kv.EncodeTuple(iv.Author, iv.Title)...)
+			es = es[:0]
+			if err := s.Get(key, es.Decode); err != nil {
 				return err
 			}
+			if es.Insert(e) {
+				if err := s.Set(key, es.Encode()); err != nil {
+					return err
+				}
+			}
 		}
 	}
-	for _, iv := range v.IndexTitle() {
-		key = append(key[:lik], iv.Encode()...)
-		if err := s.Get(key, es.Decode); err != nil {
-			return err
+
+	// Update Modified index
+	key = key[:lek:lek].AppendComponent(ModifiedPrefix)
+	if old != nil {
+		for _, iv := range old.IndexModified() {
+			key = append(key[:lik:lik], iv.Encode()...)
+			es = es[:0]
+			if err := s.Get(key, es.Decode); err != nil {
+				return err
+			}
+			if es.Remove(e) {
+				if err := s.SetEntitySlice(key, es); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if v != nil {
+		for _, iv := range v.IndexModified() {
+			key = append(key[:lik:lik], iv.Encode()...)
+			es = es[:0]
+			if err := s.Get(key, es.Decode); err != nil {
+				return err
+			}
+			if es.Insert(e) {
+				if err := s.Set(key, es.Encode()); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	// Update PriorityModified index
+	key = key[:lek:lek].AppendComponent(PriorityModifiedPrefix)
+	if old != nil {
+		for _, iv := range old.IndexPriorityModified() {
+			key = append(key[:lik:lik], kv.EncodeTuple(iv.Priority, iv.Modified)...)
+			es = es[:0]
+			if err := s.Get(key, es.Decode); err != nil {
+				return err
+			}
+			if es.Remove(e) {
+				if err := s.SetEntitySlice(key, es); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if v != nil {
+		for _, iv := range v.IndexPriorityModified() {
+			key = append(key[:lik:lik], kv.EncodeTuple(iv.Priority, iv.Modified)...)
+			es = es[:0]
+			if err := s.Get(key, es.Decode); err != nil {
+				return err
+			}
+			if es.Insert(e) {
+				if err := s.Set(key, es.Encode()); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	// Update Title index
+	key = key[:lek:lek].AppendComponent(TitlePrefix)
+	if old != nil {
+		for _, iv := range old.IndexTitle() {
+			key = append(key[:lik:lik], iv.Encode()...)
+			es = es[:0]
+			if err := s.Get(key, es.Decode); err != nil {
+				return err
+			}
+			if es.Remove(e) {
+				if err := s.SetEntitySlice(key, es); err != nil {
+					return err
+				}
+			}
 		}
-		if es.Insert(e) {
-			if err := s.Set(key, es.Encode()); err != nil {
+	}
+	if v != nil {
+		for _, iv := range v.IndexTitle() {
+			key = append(key[:lik:lik], iv.Encode()...)
+			es = es[:0]
+			if err := s.Get(key, es.Decode); err != nil {
 				return err
 			}
+			if es.Insert(e) {
+				if err := s.Set(key, es.Encode()); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// indexDocumentValues writes the index rows of each Document in src to
+// s, as if each were set in turn.
+func (s Txn) indexDocumentValues(src Txn) error {
+	es, err := src.AllDocumentEntities(nil, 0)
+	if err != nil {
+		return err
+	}
+	for _, e := range es {
+		v, err := src.GetDocument(e)
+		if err != nil {
+			return err
+		}
+		if err := s.updateDocumentIndexes(e, nil, &v); err != nil {
+			return err
+		}
+		if err := kv.Checkpoint(s.Txn); err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
+// VerifyDocumentIndexes compares the index rows of Document values with the
+// rows called for by the values themselves, and returns the differences.
+func (s Txn) VerifyDocumentIndexes() ([]kv.IndexProblem, error) {
+	return s.VerifyComponentIndexes(DocumentPrefix, func(t kv.Partitioned) error {
+		return Txn{t}.indexDocumentValues(s)
+	})
+}
+
+// RebuildDocumentIndexes replaces the index rows of Document values with the
+// rows called for by the values themselves.
+func (s Txn) RebuildDocumentIndexes() error {
+	return s.RebuildComponentIndexes(DocumentPrefix, func(t kv.Partitioned) error {
+		return Txn{t}.indexDocumentValues(s)
+	})
+}
+
 // GetDocument returns the Document associated with e.
 //
 // If no Document has been explicitly set for e, and GetDocument will return
@@ -104,6 +318,247 @@ func (s Txn) AllDocumentEntities(start *kv.Entity, n int) (es []kv.Entity, err e
 	return s.AllComponentEntities(DocumentPrefix, start, n)
 }
 
+// WatchDocument passes each committed change to a Document in partition to f,
+// along with the entity it is associated with, until ctx is done or f returns
+// an error.
+//
+// Like GetDocument, WatchDocument reports a deleted Document as the result
+// of decoding a Document from an empty slice of bytes.
+func WatchDocument(ctx context.Context, db kv.DB, partition kv.Entity, f func(kv.Entity, Document) error) error {
+	prefix := make(kv.Prefix, 8+2)
+	partition.EncodeAt(prefix)
+	DocumentPrefix.EncodeAt(prefix[8:])
+	return db.Watch(ctx, prefix, func(cs []kv.Change) error {
+		for _, c := range cs {
+			var e kv.Entity
+			if err := e.Decode(c.Key[len(prefix):]); err != nil {
+				return err
+			}
+			if e == 0 {
+				// Entity zero holds index rows rather than values.
+				continue
+			}
+			var v Document
+			if err := v.Decode(c.Value); err != nil {
+				return err
+			}
+			if err := f(e, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// HasDocument returns a query.Predicate satisfied by entities that have a
+// Document.
+func HasDocument() query.Predicate { return query.Has(DocumentPrefix) }
+
+// MatchingDocumentAuthorTitle returns a query.Predicate satisfied by
+// entities with Document values that return a matching AuthorTitle
+// from their IndexAuthorTitle method.
+func MatchingDocumentAuthorTitle(v AuthorTitle) query.Predicate {
+	return query.Match(DocumentPrefix, AuthorTitlePrefix, kv.EncodeTuple(v.Author, v.Title))
+}
+
+// EntitiesMatchingDocumentAuthorTitle returns entities with Document values that return a matching AuthorTitle from their IndexAuthorTitle method.
+//
+// The returned EntitySlice is already sorted.
+func (s Txn) EntitiesMatchingDocumentAuthorTitle(v AuthorTitle) (kv.EntitySlice, error) {
+	key := make(kv.Prefix, 8+2+8+2)
+	s.Partition.EncodeAt(key)
+	DocumentPrefix.EncodeAt(key[8:])
+	kv.Entity(0).EncodeAt(key[10:])
+	AuthorTitlePrefix.EncodeAt(key[18:])
+	key = append(key, kv.EncodeTuple(v.Author, v.Title)...)
+	var es kv.EntitySlice
+	return es, s.Get(key, es.Decode)
+}
+
+// EntitiesByDocumentAuthorTitle returns entities with
+// Document values ordered by the AuthorTitle values from their
+// IndexAuthorTitle method.
+//
+// Reading begins at cursor, and ends when the length of the returned Entity
+// slice is less than n. When reading is not complete, cursor is updated such
+// that using it in a subequent call to ByAuthorTitle would return next n
+// entities.
+func (s Txn) EntitiesByDocumentAuthorTitle(cursor *kv.IndexCursor, n int) (es []kv.Entity, err error) {
+	return s.EntitiesByComponentIndex(DocumentPrefix, AuthorTitlePrefix, cursor, n)
+}
+
+// EntitiesByDocumentAuthorTitleRange is like
+// EntitiesByDocumentAuthorTitle, except that it only returns entities
+// with a AuthorTitle value v such that lo <= v < hi, and that if reverse is
+// true it returns entities in descending order.
+//
+// A nil lo or hi leaves the range unbounded in that direction.
+func (s Txn) EntitiesByDocumentAuthorTitleRange(lo, hi *AuthorTitle, reverse bool, cursor *kv.IndexCursor, n int) (es []kv.Entity, err error) {
+	var blo, bhi []byte
+	if lo != nil {
+		blo = append([]byte{}, kv.EncodeTuple(lo.Author, lo.Title)...)
+	}
+	if hi != nil {
+		bhi = append([]byte{}, kv.EncodeTuple(hi.Author, hi.Title)...)
+	}
+	return s.EntitiesByComponentIndexRange(DocumentPrefix, AuthorTitlePrefix, blo, bhi, reverse, cursor, n)
+}
+
+// EntitiesMatchingDocumentAuthorTitleWithAuthor returns entities
+// with Document values that return a AuthorTitle with the
+// given leading fields from their IndexAuthorTitle method.
+//
+// The returned EntitySlice is already sorted.
+func (s Txn) EntitiesMatchingDocumentAuthorTitleWithAuthor(author kv.String) (kv.EntitySlice, error) {
+	es, err := s.EntitiesWithComponentIndexPrefix(DocumentPrefix, AuthorTitlePrefix, kv.EncodeTuple(author), 0)
+	kv.EntitySlice(es).Sort()
+	return es, err
+}
+
+// EntitiesByDocumentAuthorTitleWithAuthor is like
+// EntitiesByDocumentAuthorTitle, except that it only returns
+// entities with a AuthorTitle value that has the given leading fields.
+func (s Txn) EntitiesByDocumentAuthorTitleWithAuthor(author kv.String, cursor *kv.IndexCursor, n int) (es []kv.Entity, err error) {
+	lo := kv.EncodeTuple(author)
+	return s.EntitiesByComponentIndexRange(DocumentPrefix, AuthorTitlePrefix, lo, kv.TupleEnd(lo), false, cursor, n)
+}
+
+// MatchingDocumentModified returns a query.Predicate satisfied by
+// entities with Document values that return a matching kv.Time
+// from their IndexModified method.
+func MatchingDocumentModified(v kv.Time) query.Predicate {
+	return query.Match(DocumentPrefix, ModifiedPrefix, v.Encode())
+}
+
+// EntitiesMatchingDocumentModified returns entities with Document values that return a matching kv.Time from their IndexModified method.
+//
+// The returned EntitySlice is already sorted.
+func (s Txn) EntitiesMatchingDocumentModified(v kv.Time) (kv.EntitySlice, error) {
+	key := make(kv.Prefix, 8+2+8+2)
+	s.Partition.EncodeAt(key)
+	DocumentPrefix.EncodeAt(key[8:])
+	kv.Entity(0).EncodeAt(key[10:])
+	ModifiedPrefix.EncodeAt(key[18:])
+	key = append(key, v.Encode()...)
+	var es kv.EntitySlice
+	return es, s.Get(key, es.Decode)
+}
+
+// EntitiesWithPrefixDocumentModified returns up to n entities with
+// Document values that return a kv.Time starting with prefix
+// from their IndexModified method, ordered by those kv.Time values.
+//
+// A value of n less than or equal to zero will be interpretted as the largest
+// possible value.
+func (s Txn) EntitiesWithPrefixDocumentModified(prefix kv.Time, n int) ([]kv.Entity, error) {
+	return s.EntitiesWithComponentIndexPrefix(DocumentPrefix, ModifiedPrefix, prefix.Encode(), n)
+}
+
+// EntitiesByDocumentModified returns entities with
+// Document values ordered by the kv.Time values from their
+// IndexModified method.
+//
+// Reading begins at cursor, and ends when the length of the returned Entity
+// slice is less than n. When reading is not complete, cursor is updated such
+// that using it in a subequent call to ByModified would return next n
+// entities.
+func (s Txn) EntitiesByDocumentModified(cursor *kv.IndexCursor, n int) (es []kv.Entity, err error) {
+	return s.EntitiesByComponentIndex(DocumentPrefix, ModifiedPrefix, cursor, n)
+}
+
+// EntitiesByDocumentModifiedRange is like
+// EntitiesByDocumentModified, except that it only returns entities
+// with a kv.Time value v such that lo <= v < hi, and that if reverse is
+// true it returns entities in descending order.
+//
+// A nil lo or hi leaves the range unbounded in that direction.
+func (s Txn) EntitiesByDocumentModifiedRange(lo, hi *kv.Time, reverse bool, cursor *kv.IndexCursor, n int) (es []kv.Entity, err error) {
+	var blo, bhi []byte
+	if lo != nil {
+		blo = append([]byte{}, lo.Encode()...)
+	}
+	if hi != nil {
+		bhi = append([]byte{}, hi.Encode()...)
+	}
+	return s.EntitiesByComponentIndexRange(DocumentPrefix, ModifiedPrefix, blo, bhi, reverse, cursor, n)
+}
+
+// MatchingDocumentPriorityModified returns a query.Predicate satisfied by
+// entities with Document values that return a matching PriorityModified
+// from their IndexPriorityModified method.
+func MatchingDocumentPriorityModified(v PriorityModified) query.Predicate {
+	return query.Match(DocumentPrefix, PriorityModifiedPrefix, kv.EncodeTuple(v.Priority, v.Modified))
+}
+
+// EntitiesMatchingDocumentPriorityModified returns entities with Document values that return a matching PriorityModified from their IndexPriorityModified method.
+//
+// The returned EntitySlice is already sorted.
+func (s Txn) EntitiesMatchingDocumentPriorityModified(v PriorityModified) (kv.EntitySlice, error) {
+	key := make(kv.Prefix, 8+2+8+2)
+	s.Partition.EncodeAt(key)
+	DocumentPrefix.EncodeAt(key[8:])
+	kv.Entity(0).EncodeAt(key[10:])
+	PriorityModifiedPrefix.EncodeAt(key[18:])
+	key = append(key, kv.EncodeTuple(v.Priority, v.Modified)...)
+	var es kv.EntitySlice
+	return es, s.Get(key, es.Decode)
+}
+
+// EntitiesByDocumentPriorityModified returns entities with
+// Document values ordered by the PriorityModified values from their
+// IndexPriorityModified method.
+//
+// Reading begins at cursor, and ends when the length of the returned Entity
+// slice is less than n. When reading is not complete, cursor is updated such
+// that using it in a subequent call to ByPriorityModified would return next n
+// entities.
+func (s Txn) EntitiesByDocumentPriorityModified(cursor *kv.IndexCursor, n int) (es []kv.Entity, err error) {
+	return s.EntitiesByComponentIndex(DocumentPrefix, PriorityModifiedPrefix, cursor, n)
+}
+
+// EntitiesByDocumentPriorityModifiedRange is like
+// EntitiesByDocumentPriorityModified, except that it only returns entities
+// with a PriorityModified value v such that lo <= v < hi, and that if reverse is
+// true it returns entities in descending order.
+//
+// A nil lo or hi leaves the range unbounded in that direction.
+func (s Txn) EntitiesByDocumentPriorityModifiedRange(lo, hi *PriorityModified, reverse bool, cursor *kv.IndexCursor, n int) (es []kv.Entity, err error) {
+	var blo, bhi []byte
+	if lo != nil {
+		blo = append([]byte{}, kv.EncodeTuple(lo.Priority, lo.Modified)...)
+	}
+	if hi != nil {
+		bhi = append([]byte{}, kv.EncodeTuple(hi.Priority, hi.Modified)...)
+	}
+	return s.EntitiesByComponentIndexRange(DocumentPrefix, PriorityModifiedPrefix, blo, bhi, reverse, cursor, n)
+}
+
+// EntitiesMatchingDocumentPriorityModifiedWithPriority returns entities
+// with Document values that return a PriorityModified with the
+// given leading fields from their IndexPriorityModified method.
+//
+// The returned EntitySlice is already sorted.
+func (s Txn) EntitiesMatchingDocumentPriorityModifiedWithPriority(priority kv.Int64) (kv.EntitySlice, error) {
+	es, err := s.EntitiesWithComponentIndexPrefix(DocumentPrefix, PriorityModifiedPrefix, kv.EncodeTuple(priority), 0)
+	kv.EntitySlice(es).Sort()
+	return es, err
+}
+
+// EntitiesByDocumentPriorityModifiedWithPriority is like
+// EntitiesByDocumentPriorityModified, except that it only returns
+// entities with a PriorityModified value that has the given leading fields.
+func (s Txn) EntitiesByDocumentPriorityModifiedWithPriority(priority kv.Int64, cursor *kv.IndexCursor, n int) (es []kv.Entity, err error) {
+	lo := kv.EncodeTuple(priority)
+	return s.EntitiesByComponentIndexRange(DocumentPrefix, PriorityModifiedPrefix, lo, kv.TupleEnd(lo), false, cursor, n)
+}
+
+// MatchingDocumentTitle returns a query.Predicate satisfied by
+// entities with Document values that return a matching kv.String
+// from their IndexTitle method.
+func MatchingDocumentTitle(v kv.String) query.Predicate {
+	return query.Match(DocumentPrefix, TitlePrefix, v.Encode())
+}
+
 // EntitiesMatchingDocumentTitle returns entities with Document values that return a matching kv.String from their IndexTitle method.
 //
 // The returned EntitySlice is already sorted.
@@ -118,6 +573,16 @@ func (s Txn) EntitiesMatchingDocumentTitle(v kv.String) (kv.EntitySlice, error)
 	return es, s.Get(key, es.Decode)
 }
 
+// EntitiesWithPrefixDocumentTitle returns up to n entities with
+// Document values that return a kv.String starting with prefix
+// from their IndexTitle method, ordered by those kv.String values.
+//
+// A value of n less than or equal to zero will be interpretted as the largest
+// possible value.
+func (s Txn) EntitiesWithPrefixDocumentTitle(prefix kv.String, n int) ([]kv.Entity, error) {
+	return s.EntitiesWithComponentIndexPrefix(DocumentPrefix, TitlePrefix, prefix.Encode(), n)
+}
+
 // EntitiesByDocumentTitle returns entities with
 // Document values ordered by the kv.String values from their
 // IndexTitle method.
@@ -129,3 +594,20 @@ func (s Txn) EntitiesMatchingDocumentTitle(v kv.String) (kv.EntitySlice, error)
 func (s Txn) EntitiesByDocumentTitle(cursor *kv.IndexCursor, n int) (es []kv.Entity, err error) {
 	return s.EntitiesByComponentIndex(DocumentPrefix, TitlePrefix, cursor, n)
 }
+
+// EntitiesByDocumentTitleRange is like
+// EntitiesByDocumentTitle, except that it only returns entities
+// with a kv.String value v such that lo <= v < hi, and that if reverse is
+// true it returns entities in descending order.
+//
+// A nil lo or hi leaves the range unbounded in that direction.
+func (s Txn) EntitiesByDocumentTitleRange(lo, hi *kv.String, reverse bool, cursor *kv.IndexCursor, n int) (es []kv.Entity, err error) {
+	var blo, bhi []byte
+	if lo != nil {
+		blo = append([]byte{}, lo.Encode()...)
+	}
+	if hi != nil {
+		bhi = append([]byte{}, hi.Encode()...)
+	}
+	return s.EntitiesByComponentIndexRange(DocumentPrefix, TitlePrefix, blo, bhi, reverse, cursor, n)
+}
@@ -0,0 +1,143 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fulltext maintains full-text indexes in a kv.Txn and searches them.
+//
+// A full-text index is an inverted index: for each term, it stores the
+// entities whose text includes that term along with the positions at which
+// the term appears, so that searches can match phrases as well as words and
+// rank the results.
+//
+// Code generated by kvschema uses this package for each index method that
+// returns a slice of kv.Text.
+package fulltext
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Token is a term found in text, along with its position.
+type Token struct {
+	// Term is the normalized form of a word.
+	Term string
+
+	// Position is the number of tokens that precede this one in the text.
+	Position int
+}
+
+// Normalize returns the form of s in which terms are stored and searched.
+//
+// Normalize applies compatibility decomposition, removes diacritical marks
+// and folds case, so that, for example, "Résumé", "resume" and "RESUME" all
+// have the same normalized form.
+func Normalize(s string) string {
+	t := transform.Chain(
+		norm.NFKD,
+		runes.Remove(runes.In(unicode.Mn)),
+		cases.Fold(),
+		norm.NFC)
+	normalized, _, err := transform.String(t, s)
+	if err != nil {
+		return strings.ToLower(s)
+	}
+	return normalized
+}
+
+// Tokenize splits text into normalized terms.
+//
+// Terms are made of letters, numbers and marks. Since scripts such as Chinese
+// and Japanese do not separate words with spaces, each ideograph or kana is a
+// term of its own, so that phrases match sequences of them.
+func Tokenize(text string) []Token {
+	var (
+		tokens []Token
+		term   []rune
+	)
+	flush := func() {
+		if len(term) > 0 {
+			tokens = append(tokens, Token{string(term), len(tokens)})
+			term = term[:0]
+		}
+	}
+	for _, r := range Normalize(text) {
+		switch {
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana):
+			flush()
+			term = append(term, r)
+			flush()
+		case unicode.In(r, unicode.Letter, unicode.Number, unicode.Mark):
+			term = append(term, r)
+		default:
+			flush()
+		}
+	}
+	flush()
+	return tokens
+}
+
+// Query is a parsed search query.
+//
+// Each element of a Query is a phrase of one or more terms, all of which must
+// appear consecutively in the text of a matching entity.
+type Query [][]string
+
+// ParseQuery parses a search query in which words are separated by spaces or
+// punctuation and phrases are enclosed in double quotes.
+//
+// An entity matches the query if its text includes every word and phrase.
+// A missing closing quote ends the last phrase at the end of s.
+func ParseQuery(s string) Query {
+	s = strings.NewReplacer("“", `"`, "”", `"`).Replace(s)
+	var q Query
+	for i, part := range strings.Split(s, `"`) {
+		tokens := Tokenize(part)
+		if i%2 == 1 {
+			if len(tokens) > 0 {
+				phrase := make([]string, len(tokens))
+				for j, t := range tokens {
+					phrase[j] = t.Term
+				}
+				q = append(q, phrase)
+			}
+			continue
+		}
+		for _, t := range tokens {
+			q = append(q, []string{t.Term})
+		}
+	}
+	return q
+}
+
+// terms returns the distinct terms in q, in order of their first appearance.
+func (q Query) terms() []string {
+	var (
+		terms []string
+		seen  = make(map[string]bool)
+	)
+	for _, phrase := range q {
+		for _, term := range phrase {
+			if !seen[term] {
+				seen[term] = true
+				terms = append(terms, term)
+			}
+		}
+	}
+	return terms
+}
@@ -0,0 +1,179 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fulltext
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/note-maps/kv"
+	"github.com/google/note-maps/kv/memory"
+)
+
+func TestTokenize(t *testing.T) {
+	for _, test := range []struct {
+		Text string
+		Want []string
+	}{
+		{"", nil},
+		{"Hello, World!", []string{"hello", "world"}},
+		{"Résumé RESUME", []string{"resume", "resume"}},
+		{"Straße", []string{"strasse"}},
+		{"ﬁle № 42", []string{"file", "no", "42"}},
+		{"東京タワー", []string{"東", "京", "タ", "ワ", "ー"}},
+	} {
+		var got []string
+		for i, token := range Tokenize(test.Text) {
+			if token.Position != i {
+				t.Errorf("%q: want token %v at position %v, got %v", test.Text, token.Term, i, token.Position)
+			}
+			got = append(got, token.Term)
+		}
+		if !reflect.DeepEqual(test.Want, got) {
+			t.Errorf("%q: want %q, got %q", test.Text, test.Want, got)
+		}
+	}
+}
+
+func TestParseQuery(t *testing.T) {
+	for _, test := range []struct {
+		Query string
+		Want  Query
+	}{
+		{"", nil},
+		{"big cat", Query{{"big"}, {"cat"}}},
+		{`"big cat" meow`, Query{{"big", "cat"}, {"meow"}}},
+		{"“Big Cat”", Query{{"big", "cat"}}},
+		{`meow "big cat`, Query{{"meow"}, {"big", "cat"}}},
+		{`""`, nil},
+	} {
+		if got := ParseQuery(test.Query); !reflect.DeepEqual(test.Want, got) {
+			t.Errorf("%q: want %q, got %q", test.Query, test.Want, got)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	const c, ix kv.Component = 1, 2
+	txn := kv.Partitioned{Txn: memory.New(), Partition: 7}
+	texts := map[kv.Entity][]kv.Text{
+		1: {"The big cat sat on the mat."},
+		2: {"A cat, a big dog, and a bigger cat."},
+		3: {"Dogs and cats"},
+		4: {"big", "cat"},
+	}
+	for e, text := range texts {
+		if err := Update(txn, c, ix, e, nil, text); err != nil {
+			t.Fatal(err)
+		}
+	}
+	search := func(q string) []kv.Entity {
+		rs, err := Search(txn, c, ix, ParseQuery(q), 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var es []kv.Entity
+		for i, r := range rs {
+			if i > 0 && r.Score > rs[i-1].Score {
+				t.Errorf("%q: results out of order: %v", q, rs)
+			}
+			es = append(es, r.Entity)
+		}
+		return es
+	}
+	for _, test := range []struct {
+		Query string
+		Want  []kv.Entity
+	}{
+		// Shorter texts and repeated terms rank higher.
+		{"CAT", []kv.Entity{4, 2, 1}},
+		{"big cat", []kv.Entity{4, 2, 1}},
+		{`"big cat"`, []kv.Entity{1}},
+		{`"cat big"`, nil},
+		{"cats", []kv.Entity{3}},
+		{"bird", nil},
+	} {
+		if got := search(test.Query); !reflect.DeepEqual(test.Want, got) {
+			t.Errorf("%q: want %v, got %v", test.Query, test.Want, got)
+		}
+	}
+
+	// Changing and removing texts updates the index.
+	if err := Update(txn, c, ix, 1, texts[1], []kv.Text{"The small cat"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Update(txn, c, ix, 4, texts[4], nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := search("big cat"), []kv.Entity{2}; !reflect.DeepEqual(want, got) {
+		t.Errorf("after update: want %v, got %v", want, got)
+	}
+	if got, want := search(`"small cat"`), []kv.Entity{1}; !reflect.DeepEqual(want, got) {
+		t.Errorf("after update: want %v, got %v", want, got)
+	}
+	if count, total, err := lengths(txn, indexPrefix(txn, c, ix)); err != nil {
+		t.Fatal(err)
+	} else if want := []int{3, 3 + 9 + 3}; !reflect.DeepEqual(want, []int{count, total}) {
+		t.Errorf("want lengths %v, got %v", want, []int{count, total})
+	}
+
+	// Removing every text removes every row.
+	for e, text := range map[kv.Entity][]kv.Text{1: {"The small cat"}, 2: texts[2], 3: texts[3]} {
+		if err := Update(txn, c, ix, e, text, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	iter := txn.PrefixIterator(indexPrefix(txn, c, ix))
+	defer iter.Discard()
+	for iter.Seek(nil); iter.Valid(); iter.Next() {
+		t.Errorf("want no rows, got %q", iter.Key())
+	}
+}
+
+func TestConcurrentUpdates(t *testing.T) {
+	const c, ix kv.Component = 1, 2
+	db := memory.NewDB()
+	update := func(e kv.Entity, text kv.Text) kv.TxnCommitDiscarder {
+		txn := db.NewTxn(true)
+		if err := Update(kv.Partitioned{Txn: txn, Partition: 7}, c, ix, e, nil, []kv.Text{text}); err != nil {
+			t.Fatal(err)
+		}
+		return txn
+	}
+	// Updates to the index for different entities touch different rows, so
+	// both transactions commit.
+	a, b := update(1, "The big cat"), update(2, "A small dog")
+	if err := a.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	txn := db.NewTxn(false)
+	defer txn.Discard()
+	p := kv.Partitioned{Txn: txn, Partition: 7}
+	if count, total, err := lengths(p, indexPrefix(p, c, ix)); err != nil {
+		t.Fatal(err)
+	} else if count != 2 || total != 6 {
+		t.Errorf("want 2 entities with 6 tokens, got %v with %v", count, total)
+	}
+	for q, want := range map[string]kv.Entity{"cat": 1, "dog": 2} {
+		if got, err := Search(p, c, ix, ParseQuery(q), 0); err != nil {
+			t.Error(err)
+		} else if len(got) != 1 || got[0].Entity != want {
+			t.Errorf("%q: want entity %v, got %v", q, want, got)
+		}
+	}
+}
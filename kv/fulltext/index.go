@@ -0,0 +1,318 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fulltext
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/google/note-maps/kv"
+)
+
+// ErrCorrupt is returned when a row of a full-text index cannot be decoded.
+var ErrCorrupt = errors.New("fulltext: corrupt index row")
+
+// Rows of a full-text index share the prefix of an index row for component c
+// and index ix, followed by one of these bytes:
+const (
+	// postingRow keys continue with a term, escaped and terminated by
+	// kv.AppendKeyedIndexValue, and an entity. Their values hold the
+	// positions of the term in the entity's text.
+	postingRow byte = 1 + iota
+
+	// lengthRow keys continue with an entity. Their values hold the number
+	// of tokens in the entity's text.
+	//
+	// Search counts the entities and their tokens from these rows, rather
+	// than from a single row of totals that every update would have to
+	// rewrite, so that updates to different entities never conflict.
+	lengthRow
+)
+
+// Ranking parameters for Okapi BM25.
+const (
+	k1 = 1.2
+	b  = 0.75
+)
+
+// Result is an entity found by Search.
+type Result struct {
+	Entity kv.Entity
+
+	// Score is greater for entities that are more relevant to the query.
+	Score float64
+}
+
+// Update updates the full-text index ix of component c in t so that e, which
+// used to have the texts in old, has the texts in new instead.
+//
+// Positions continue from one text to the next, with a gap between them so
+// that no phrase matches across two texts.
+func Update(t kv.Partitioned, c, ix kv.Component, e kv.Entity, old, new []kv.Text) error {
+	oldPostings, oldLength := postings(old)
+	newPostings, newLength := postings(new)
+	prefix := indexPrefix(t, c, ix)
+	for term := range oldPostings {
+		if _, ok := newPostings[term]; !ok {
+			if err := t.Delete(postingKey(prefix, term, e)); err != nil {
+				return err
+			}
+		}
+	}
+	for term, ps := range newPostings {
+		if equalInts(oldPostings[term], ps) {
+			continue
+		}
+		if err := t.Set(postingKey(prefix, term, e), encodeInts(ps, true)); err != nil {
+			return err
+		}
+	}
+	if oldLength == newLength {
+		return nil
+	}
+	key := append(append(prefix, lengthRow), e.Encode()...)
+	if newLength == 0 {
+		return t.Delete(key)
+	}
+	return t.Set(key, encodeInts([]int{newLength}, false))
+}
+
+// Search returns up to n entities whose text in the full-text index ix of
+// component c matches q, in descending order of their scores.
+//
+// Entities with equal scores are returned in ascending order. A value of n
+// less than or equal to zero will be interpretted as the largest possible
+// value.
+func Search(t kv.Partitioned, c, ix kv.Component, q Query, n int) ([]Result, error) {
+	terms := q.terms()
+	if len(terms) == 0 {
+		return nil, nil
+	}
+	prefix := indexPrefix(t, c, ix)
+	all := make(map[string]map[kv.Entity][]int, len(terms))
+	var candidates map[kv.Entity][]int
+	for _, term := range terms {
+		ps, err := readPostings(t, prefix, term)
+		if err != nil {
+			return nil, err
+		}
+		all[term] = ps
+		if candidates == nil || len(ps) < len(candidates) {
+			candidates = ps
+		}
+	}
+	count, total, err := lengths(t, prefix)
+	if err != nil {
+		return nil, err
+	}
+	docs, avgLength := float64(count), 1.0
+	if count > 0 {
+		avgLength = float64(total) / float64(count)
+	}
+	var results []Result
+	for e := range candidates {
+		if !matches(all, q, e) {
+			continue
+		}
+		length, err := getInts(t, append(append(prefix, lengthRow), e.Encode()...), 1)
+		if err != nil {
+			return nil, err
+		}
+		var score float64
+		for _, term := range terms {
+			df := float64(len(all[term]))
+			tf := float64(len(all[term][e]))
+			idf := math.Log(1 + (docs-df+0.5)/(df+0.5))
+			score += idf * tf * (k1 + 1) /
+				(tf + k1*(1-b+b*float64(length[0])/avgLength))
+		}
+		results = append(results, Result{e, score})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Entity < results[j].Entity
+	})
+	if n > 0 && len(results) > n {
+		results = results[:n]
+	}
+	return results, nil
+}
+
+// matches returns true if the text of e includes every phrase in q.
+func matches(all map[string]map[kv.Entity][]int, q Query, e kv.Entity) bool {
+	for _, phrase := range q {
+		found := false
+		for _, start := range all[phrase[0]][e] {
+			found = true
+			for i, term := range phrase[1:] {
+				ps := all[term][e]
+				j := sort.SearchInts(ps, start+1+i)
+				if j == len(ps) || ps[j] != start+1+i {
+					found = false
+					break
+				}
+			}
+			if found {
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// postings returns the positions of each term in texts, and the number of
+// tokens in all of them.
+func postings(texts []kv.Text) (map[string][]int, int) {
+	ps := make(map[string][]int)
+	offset, length := 0, 0
+	for _, text := range texts {
+		tokens := Tokenize(string(text))
+		for _, token := range tokens {
+			ps[token.Term] = append(ps[token.Term], offset+token.Position)
+		}
+		offset += len(tokens) + 1
+		length += len(tokens)
+	}
+	return ps, length
+}
+
+func readPostings(t kv.Partitioned, prefix kv.Prefix, term string) (map[kv.Entity][]int, error) {
+	iter := t.PrefixIterator(kv.AppendKeyedIndexValue(append(prefix, postingRow), []byte(term)))
+	defer iter.Discard()
+	ps := make(map[kv.Entity][]int)
+	for iter.Seek(nil); iter.Valid(); iter.Next() {
+		if len(iter.Key()) != 8 {
+			return nil, ErrCorrupt
+		}
+		var e kv.Entity
+		e.Decode(iter.Key())
+		if err := iter.Value(func(bs []byte) error {
+			var err error
+			ps[e], err = decodeInts(bs, true)
+			return err
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return ps, nil
+}
+
+// lengths returns the number of entities with any tokens in the index with
+// the given prefix, and the total number of their tokens.
+func lengths(t kv.Partitioned, prefix kv.Prefix) (count, total int, err error) {
+	iter := t.PrefixIterator(append(prefix, lengthRow))
+	defer iter.Discard()
+	for iter.Seek(nil); iter.Valid(); iter.Next() {
+		if err = iter.Value(func(bs []byte) error {
+			is, err := decodeInts(bs, false)
+			if err != nil {
+				return err
+			} else if len(is) != 1 {
+				return ErrCorrupt
+			}
+			count, total = count+1, total+is[0]
+			return nil
+		}); err != nil {
+			return 0, 0, err
+		}
+	}
+	return count, total, nil
+}
+
+func indexPrefix(t kv.Partitioned, c, ix kv.Component) kv.Prefix {
+	p := kv.Prefix(t.Partition.Encode()).AppendComponent(c).ConcatEntityComponent(0, ix)
+	return p[:len(p):len(p)]
+}
+
+func postingKey(prefix kv.Prefix, term string, e kv.Entity) []byte {
+	key := kv.AppendKeyedIndexValue(append(prefix, postingRow), []byte(term))
+	return append(key, e.Encode()...)
+}
+
+// getInts decodes the n integers stored under key, which are all zero if key
+// does not exist.
+func getInts(t kv.Txn, key []byte, n int) ([]int, error) {
+	is := make([]int, n)
+	err := t.Get(key, func(bs []byte) error {
+		if len(bs) == 0 {
+			return nil
+		}
+		stored, err := decodeInts(bs, false)
+		if err != nil {
+			return err
+		} else if len(stored) != n {
+			return ErrCorrupt
+		}
+		copy(is, stored)
+		return nil
+	})
+	return is, err
+}
+
+// encodeInts encodes is as uvarints, each as its difference from the one
+// before if delta is true.
+func encodeInts(is []int, delta bool) []byte {
+	buf := make([]byte, 0, len(is)*2)
+	tmp := make([]byte, binary.MaxVarintLen64)
+	prev := 0
+	for _, i := range is {
+		v := i
+		if delta {
+			v, prev = i-prev, i
+		}
+		buf = append(buf, tmp[:binary.PutUvarint(tmp, uint64(v))]...)
+	}
+	return buf
+}
+
+func decodeInts(bs []byte, delta bool) ([]int, error) {
+	var (
+		is   []int
+		prev int
+	)
+	for len(bs) > 0 {
+		v, n := binary.Uvarint(bs)
+		if n <= 0 {
+			return nil, ErrCorrupt
+		}
+		bs = bs[n:]
+		i := int(v)
+		if delta {
+			i += prev
+			prev = i
+		}
+		is = append(is, i)
+	}
+	return is, nil
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
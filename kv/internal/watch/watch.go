@@ -0,0 +1,139 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package watch helps implement kv.DB.Watch for backends that have no change
+// feed of their own.
+package watch
+
+import (
+	"bytes"
+	"context"
+	"sync"
+
+	"github.com/google/note-maps/kv"
+)
+
+// Hub delivers published changes to watchers.
+//
+// Backends should publish the changes made by each transaction as it is
+// committed, in commit order.
+type Hub struct {
+	watchers map[*watcher]struct{}
+	closed   chan struct{}
+	mutex    sync.Mutex
+}
+
+// NewHub returns a new Hub.
+func NewHub() *Hub {
+	return &Hub{
+		watchers: make(map[*watcher]struct{}),
+		closed:   make(chan struct{}),
+	}
+}
+
+// Watch implements kv.DB.Watch.
+//
+// Watch returns nil after the Hub is closed.
+func (h *Hub) Watch(ctx context.Context, prefix []byte, f func([]kv.Change) error) error {
+	w := &watcher{
+		prefix: append([]byte(nil), prefix...),
+		signal: make(chan struct{}, 1),
+	}
+	h.mutex.Lock()
+	h.watchers[w] = struct{}{}
+	h.mutex.Unlock()
+	defer func() {
+		h.mutex.Lock()
+		delete(h.watchers, w)
+		h.mutex.Unlock()
+	}()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-h.closed:
+			return nil
+		case <-w.signal:
+			for _, cs := range w.take() {
+				if err := f(cs); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// Len returns the number of calls to Watch that are currently watching.
+func (h *Hub) Len() int {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return len(h.watchers)
+}
+
+// Publish queues the changes in cs for each watcher with a matching prefix.
+//
+// Publish never blocks on a slow watcher.
+func (h *Hub) Publish(cs []kv.Change) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	for w := range h.watchers {
+		w.put(cs)
+	}
+}
+
+// Close stops all current and future calls to Watch.
+func (h *Hub) Close() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	select {
+	case <-h.closed:
+	default:
+		close(h.closed)
+	}
+}
+
+// watcher queues batches of changes for a call to Hub.Watch.
+type watcher struct {
+	prefix  []byte
+	pending [][]kv.Change
+	signal  chan struct{}
+	mutex   sync.Mutex
+}
+
+func (w *watcher) put(cs []kv.Change) {
+	var matching []kv.Change
+	for _, c := range cs {
+		if bytes.HasPrefix(c.Key, w.prefix) {
+			matching = append(matching, c)
+		}
+	}
+	if len(matching) == 0 {
+		return
+	}
+	w.mutex.Lock()
+	w.pending = append(w.pending, matching)
+	w.mutex.Unlock()
+	select {
+	case w.signal <- struct{}{}:
+	default:
+	}
+}
+
+func (w *watcher) take() [][]kv.Change {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	pending := w.pending
+	w.pending = nil
+	return pending
+}
@@ -30,6 +30,27 @@
 // with "Index", receive no arguments, and return a slice of a type that also
 // implements Encoder and Decoder.
 //
+// An index method may instead return a slice of a struct type whose fields
+// all implement Encoder, for a composite index. The fields are encoded
+// together by EncodeTuple, and the generated code can also look up or load
+// entities in order by the leading fields of the struct alone.
+//
+// An index method that returns a slice of Text defines a full-text index
+// instead, which the generated code maintains and searches through package
+// fulltext. Likewise, an index method that returns a slice of Fuzzy defines
+// a trigram index for finding similar values through package trigram.
+//
+// Int64, Uint64, Float64, Bool and Time encode their values such that the
+// encodings sort in the same order as the values, so that indexes over them,
+// alone or as fields of a composite index, support range scans by number or
+// time. Index values may also be of types from other packages, such as
+// collation.Key, which sorts strings the way a dictionary does rather than by
+// their bytes.
+//
+// Index rows are stored in SliceLayout unless kvschema is run with
+// -index_layout=keyed, which selects KeyedLayout for indexes where many
+// entities may share a value.
+//
 // Examples are included in the "examples" subdirectory.
 //
 // If `go generate` doesn't produce a kvschema.go file, or the resulting
@@ -38,6 +59,8 @@
 package kv
 
 import (
+	"bytes"
+	"context"
 	"encoding/binary"
 	"encoding/json"
 	"log"
@@ -54,6 +77,29 @@ type DB interface {
 
 	// Close releases any resources held by this DB and closes the connection.
 	Close() error
+
+	// Watch passes each batch of committed changes to keys matching prefix to
+	// f, in the order in which they were committed, until ctx is done or f
+	// returns an error.
+	//
+	// Changes committed before Watch begins watching are not delivered.
+	//
+	// Watch blocks until it stops watching, and then returns either the error
+	// returned by f or ctx.Err().
+	Watch(ctx context.Context, prefix []byte, f func([]Change) error) error
+}
+
+// Change describes a committed update to the value associated with a key.
+type Change struct {
+	// Key is the complete key, including any prefix given to Watch.
+	Key []byte
+
+	// Value is the new value associated with Key, or empty if Key was
+	// deleted.
+	//
+	// Like Txn.Get, Watch does not distinguish between a deleted key and a
+	// key associated with an empty value.
+	Value []byte
 }
 
 // Txn represents the functions a key-value store transaction must implement in
@@ -69,8 +115,19 @@ type Txn interface {
 	Alloc() (Entity, error)
 
 	// Set stores key and value in the underlying key-value store.
+	//
+	// Implementations must not keep references to key or value after Set
+	// returns, so the caller may reuse both.
 	Set(key, value []byte) error
 
+	// Delete removes key and its associated value from the underlying
+	// key-value store.
+	//
+	// If the key does not exist, this is not an error. As for Set,
+	// implementations must not keep references to key, so the caller may
+	// reuse it.
+	Delete(key []byte) error
+
 	// Get finds the value associated with key in the underlying key-value store
 	// and passes it to f.
 	//
@@ -90,6 +147,13 @@ type Txn interface {
 	// prefix, so for prefix {1,2} an underlying key {1,2,3,4} will be visible
 	// through this iterator as merely {3,4}.
 	PrefixIterator(prefix []byte) Iterator
+
+	// ReversePrefixIterator is like PrefixIterator, except that the resulting
+	// iterator visits key-value pairs in descending order of their keys.
+	//
+	// See Iterator for details on how Seek and Next behave when iterating in
+	// reverse.
+	ReversePrefixIterator(prefix []byte) Iterator
 }
 
 // Iterator supports iteration over key-value pairs.
@@ -101,9 +165,16 @@ type Iterator interface {
 	//
 	// If there is no such key-value pair, Seek moves to the item with first key
 	// after the given key.
+	//
+	// For an iterator that visits keys in reverse order, Seek instead moves to
+	// the item with the last key before the given key, and an empty key is
+	// considered to be after all other keys so that Seek(nil) moves to the
+	// last item.
 	Seek(key []byte)
 
-	// Next moves to the iterator to the next key-value pair.
+	// Next moves to the iterator to the next key-value pair, which for an
+	// iterator that visits keys in reverse order is the pair with the previous
+	// key.
 	Next()
 
 	// Valid returns true if the iterator is at a valid key-value pair.
@@ -199,49 +270,172 @@ func (t Partitioned) AllComponentEntities(c Component, start *Entity, n int) (es
 // that using it in a subequent call to EntitiesByComponentIndex would return
 // the next n entities.
 func (s Partitioned) EntitiesByComponentIndex(c, ix Component, cursor *IndexCursor, n int) (es []Entity, err error) {
-	key := make(Prefix, 8+2+8+2)
-	s.Partition.EncodeAt(key)
-	c.EncodeAt(key[8:])
-	Entity(0).EncodeAt(key[10:])
-	ix.EncodeAt(key[18:])
-	iter := s.PrefixIterator(key)
-	defer iter.Discard()
-	iter.Seek(cursor.Key)
-	if !iter.Valid() {
-		return
+	return s.EntitiesByComponentIndexRange(c, ix, nil, nil, false, cursor, n)
+}
+
+// EntitiesByComponentIndexRange is like EntitiesByComponentIndex, except that
+// it only returns entities whose encoded ix values are greater than or equal
+// to lo and less than hi, and that it can read the index in reverse.
+//
+// A nil lo or hi leaves the range unbounded in that direction.
+//
+// If reverse is true, entities are returned in descending order of their ix
+// values, and entities that share an ix value are also returned in descending
+// order.
+func (s Partitioned) EntitiesByComponentIndexRange(c, ix Component, lo, hi []byte, reverse bool, cursor *IndexCursor, n int) (es []Entity, err error) {
+	return s.entitiesByIndexRange(SliceLayout, c, ix, lo, hi, reverse, cursor, n)
+}
+
+func (s Partitioned) entitiesByIndexRange(layout IndexLayout, c, ix Component, lo, hi []byte, reverse bool, cursor *IndexCursor, n int) (es []Entity, err error) {
+	if lo != nil {
+		lo = layout.appendBound(make([]byte, 0, len(lo)), lo)
 	}
-	var buf EntitySlice
-	if err = iter.Value(buf.Decode); err != nil {
-		return
+	if hi != nil {
+		hi = layout.appendBound(make([]byte, 0, len(hi)), hi)
 	}
-	if cursor.Offset < len(buf) {
-		es = append(es, buf[cursor.Offset:]...)
-		if len(es) >= n {
-			cursor.Offset += n
-			if len(es) > n {
-				es = es[:n]
-			}
+	key := s.indexPrefix(c, ix)
+	var iter Iterator
+	if reverse {
+		iter = s.ReversePrefixIterator(key)
+	} else {
+		iter = s.PrefixIterator(key)
+	}
+	defer func() { iter.Discard() }()
+	offset := cursor.Offset
+	last := false
+	switch {
+	case reverse && cursor.Key != nil && len(cursor.Key) == 0:
+		// Seek cannot reach an empty key in reverse, but the row for an
+		// empty index value is always the last one in reverse order.
+		iter.Discard()
+		iter = s.PrefixIterator(key)
+		iter.Seek(nil)
+		if iter.Valid() && len(iter.Key()) != 0 {
 			return
 		}
+		last = true
+	case cursor.Key != nil:
+		iter.Seek(cursor.Key)
+		if iter.Valid() && !bytes.Equal(iter.Key(), cursor.Key) {
+			// The row at cursor no longer exists, so start at the beginning of
+			// the next one.
+			offset = 0
+		}
+	case reverse:
+		iter.Seek(hi)
+		if hi != nil && iter.Valid() && bytes.Compare(iter.Key(), hi) >= 0 {
+			iter.Next()
+		}
+	default:
+		iter.Seek(lo)
 	}
-	for iter.Next(); iter.Valid(); iter.Next() {
-		if err = iter.Value(buf.Decode); err != nil {
+	var buf EntitySlice
+	for ; iter.Valid(); iter.Next() {
+		k := iter.Key()
+		if !reverse && hi != nil && bytes.Compare(k, hi) >= 0 ||
+			reverse && lo != nil && bytes.Compare(k, lo) < 0 {
+			break
+		}
+		if err = layout.decodeRow(iter, &buf); err != nil {
 			return
 		}
-		es = append(es, buf...)
-		cursor.Key = append(cursor.Key[0:0], iter.Key()...)
+		if reverse {
+			for a, b := 0, len(buf)-1; a < b; a, b = a+1, b-1 {
+				buf[a], buf[b] = buf[b], buf[a]
+			}
+		}
+		if cursor.Key == nil {
+			// The row for an empty index value has an empty key, which
+			// must still be distinguishable from no cursor at all.
+			cursor.Key = []byte{}
+		}
+		cursor.Key = append(cursor.Key[:0], k...)
+		if offset < len(buf) {
+			es = append(es, buf[offset:]...)
+		}
 		if len(es) >= n {
 			cursor.Offset = len(buf) - (len(es) - n)
-			if len(es) > n {
-				es = es[:n]
-			}
+			es = es[:n]
 			return
 		}
+		offset = 0
+		if last {
+			break
+		}
 	}
 	cursor.Offset = len(buf)
 	return
 }
 
+// EntitiesWithComponentIndexPrefix returns up to n entities with c values
+// that have at least one encoded ix value starting with prefix, ordered by
+// those ix values.
+//
+// Each entity is included only once, at the position of its first matching ix
+// value.
+//
+// A value of n less than or equal to zero will be interpretted as the largest
+// possible value.
+func (s Partitioned) EntitiesWithComponentIndexPrefix(c, ix Component, prefix []byte, n int) (es []Entity, err error) {
+	return s.entitiesWithIndexPrefix(SliceLayout, c, ix, prefix, n)
+}
+
+func (s Partitioned) entitiesWithIndexPrefix(layout IndexLayout, c, ix Component, prefix []byte, n int) (es []Entity, err error) {
+	iter := s.PrefixIterator(layout.appendBound(s.indexPrefix(c, ix), prefix))
+	defer iter.Discard()
+	var (
+		buf  EntitySlice
+		seen = make(map[Entity]bool)
+	)
+	for iter.Seek(nil); iter.Valid() && (n <= 0 || len(es) < n); iter.Next() {
+		if err = layout.decodeRow(iter, &buf); err != nil {
+			return
+		}
+		for _, e := range buf {
+			if seen[e] {
+				continue
+			}
+			seen[e] = true
+			es = append(es, e)
+			if n > 0 && len(es) >= n {
+				break
+			}
+		}
+	}
+	return
+}
+
+// indexPrefix returns the prefix shared by all rows of index ix of component
+// c.
+func (s Partitioned) indexPrefix(c, ix Component) Prefix {
+	key := make(Prefix, 8+2+8+2)
+	s.Partition.EncodeAt(key)
+	c.EncodeAt(key[8:])
+	Entity(0).EncodeAt(key[10:])
+	ix.EncodeAt(key[18:])
+	return key
+}
+
+// SetEntitySlice stores es as the value associated with key, or deletes key if
+// es is empty.
+//
+// Index rows are typically stored this way so that entities removed from an
+// index do not leave empty rows behind.
+func (s Partitioned) SetEntitySlice(key []byte, es EntitySlice) error {
+	if len(es) == 0 {
+		return s.Delete(key)
+	}
+	return s.Set(key, es.Encode())
+}
+
+// Reserver is implemented by DB implementations that can be told which
+// entities are already in use, such as those copied into a DB by Restore.
+type Reserver interface {
+	// Reserve ensures that Txn.Alloc will never return e or any smaller
+	// Entity.
+	Reserve(e Entity) error
+}
+
 // Allocer provides a generic implementation of the Txn.Alloc function.
 //
 // A single Allocer must be shared by all Txn values derived from the same
@@ -276,17 +470,27 @@ func NewAllocer(db DB, key []byte) *Allocer {
 }
 
 // Alloc uses an atomic counter to produce unique uint64 values.
-func (a Allocer) Alloc() (Entity, error) {
+func (a *Allocer) Alloc() (Entity, error) {
 	return Entity(atomic.AddUint64(&a.last, 1)), nil
 }
 
+// Reserve ensures that a will never return e or any smaller Entity.
+func (a *Allocer) Reserve(e Entity) error {
+	for {
+		last := atomic.LoadUint64(&a.last)
+		if last >= uint64(e) || atomic.CompareAndSwapUint64(&a.last, last, uint64(e)) {
+			return nil
+		}
+	}
+}
+
 // Save stores the last value returned by Allocer so that later calls to
 // NewAllocer for the same DB and key will not produce any values that
 // duplicate values returned by this one.
-func (a Allocer) Save() error {
+func (a *Allocer) Save() error {
 	txn := a.db.NewTxn(true)
 	defer txn.Discard()
-	if err := txn.Set(a.key, Entity(a.last).Encode()); err != nil {
+	if err := txn.Set(a.key, Entity(atomic.LoadUint64(&a.last)).Encode()); err != nil {
 		return err
 	}
 	return txn.Commit()
@@ -474,8 +678,10 @@ func (es EntitySlice) Encode() []byte {
 // Decode decodes src into es.
 func (es *EntitySlice) Decode(src []byte) error {
 	ln := len(src) / 8
-	if len(*es) < ln {
+	if cap(*es) < ln {
 		*es = make([]Entity, ln)
+	} else {
+		*es = (*es)[:ln]
 	}
 	for i := 0; i < ln; i++ {
 		(*es)[i].Decode(src[i*8:])
@@ -496,6 +702,40 @@ func (s *String) Decode(src []byte) error {
 	return nil
 }
 
+// Text is like String, except that an index method that returns a slice of
+// Text defines a full-text index of words within the text, rather than an
+// index of whole values.
+//
+// Code generated by kvschema maintains full-text indexes through package
+// fulltext.
+type Text string
+
+// Encode encodes t into a new slice of bytes.
+func (t Text) Encode() []byte { return []byte(t) }
+
+// Decode decodes src into t.
+func (t *Text) Decode(src []byte) error {
+	*t = Text(src)
+	return nil
+}
+
+// Fuzzy is like String, except that an index method that returns a slice of
+// Fuzzy defines a trigram index, which finds values similar to a given string
+// rather than only equal to it.
+//
+// Code generated by kvschema maintains trigram indexes through package
+// trigram.
+type Fuzzy string
+
+// Encode encodes f into a new slice of bytes.
+func (f Fuzzy) Encode() []byte { return []byte(f) }
+
+// Decode decodes src into f.
+func (f *Fuzzy) Decode(src []byte) error {
+	*f = Fuzzy(src)
+	return nil
+}
+
 // StringSlice is a slice of strings that implements the Encoder and Decoder
 // interfaces.
 type StringSlice []String
@@ -169,6 +169,20 @@ func TestEntitySliceEncodeDecode(t *testing.T) {
 	}
 }
 
+func TestEntitySliceDecodeReuse(t *testing.T) {
+	es := EntitySlice{1, 2, 3}
+	if err := es.Decode(EntitySlice{42}.Encode()); err != nil {
+		t.Fatal(err)
+	} else if want := (EntitySlice{42}); !want.Equal(es) {
+		t.Error("want", want, "got", es)
+	}
+	if err := es.Decode(nil); err != nil {
+		t.Fatal(err)
+	} else if len(es) != 0 {
+		t.Error("want empty slice, got", es)
+	}
+}
+
 func TestEntitySliceEqual(t *testing.T) {
 	for _, test := range [][2]EntitySlice{
 		{{42}, {0, 42}},
@@ -0,0 +1,477 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kvtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/note-maps/kv"
+)
+
+// errConformance is returned by callbacks that are expected to make the
+// function that called them fail.
+var errConformance = errors.New("kvtest: deliberate error")
+
+// RunConformance runs subtests that check that the kv.DB values returned by
+// newDB implement the documented contract of kv.DB, kv.Txn and kv.Iterator.
+//
+// Each subtest calls newDB once and closes the result when it is done, so
+// every call to newDB should return a new, empty database.
+func RunConformance(t *testing.T, newDB func() kv.DB) {
+	for _, test := range []struct {
+		Name string
+		Test func(*testing.T, kv.DB)
+	}{
+		{"Get", testGet},
+		{"SetDelete", testSetDelete},
+		{"ReusedBuffers", testReusedBuffers},
+		{"ReadOnly", testReadOnly},
+		{"CommitDiscard", testCommitDiscard},
+		{"PrefixIterator", testPrefixIterator},
+		{"IteratorValue", testIteratorValue},
+		{"Alloc", testAlloc},
+		{"ConcurrentTxns", testConcurrentTxns},
+		{"Watch", testWatch},
+	} {
+		test := test
+		t.Run(test.Name, func(t *testing.T) {
+			db := newDB()
+			defer db.Close()
+			test.Test(t, db)
+		})
+	}
+}
+
+// update runs f in a new update transaction and commits it.
+func update(t *testing.T, db kv.DB, f func(kv.Txn)) {
+	t.Helper()
+	txn := db.NewTxn(true)
+	defer txn.Discard()
+	f(txn)
+	if err := txn.Commit(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// view runs f in a new read-only transaction.
+func view(t *testing.T, db kv.DB, f func(kv.Txn)) {
+	t.Helper()
+	txn := db.NewTxn(false)
+	defer txn.Discard()
+	f(txn)
+}
+
+func mustSet(t *testing.T, txn kv.Txn, key, value string) {
+	t.Helper()
+	if err := txn.Set([]byte(key), []byte(value)); err != nil {
+		t.Fatalf("Set(%q): %v", key, err)
+	}
+}
+
+func mustDelete(t *testing.T, txn kv.Txn, key string) {
+	t.Helper()
+	if err := txn.Delete([]byte(key)); err != nil {
+		t.Fatalf("Delete(%q): %v", key, err)
+	}
+}
+
+func mustGet(t *testing.T, txn kv.Txn, key string) string {
+	t.Helper()
+	var value string
+	if err := txn.Get([]byte(key), func(bs []byte) error {
+		value = string(bs)
+		return nil
+	}); err != nil {
+		t.Fatalf("Get(%q): %v", key, err)
+	}
+	return value
+}
+
+func testGet(t *testing.T, db kv.DB) {
+	update(t, db, func(txn kv.Txn) {
+		mustSet(t, txn, "key", "value")
+		if got := mustGet(t, txn, "key"); got != "value" {
+			t.Errorf("Get(%q): want %q, got %q", "key", "value", got)
+		}
+		if got := mustGet(t, txn, "missing"); got != "" {
+			t.Errorf("Get(%q): want empty value, got %q", "missing", got)
+		}
+		for _, key := range []string{"key", "missing"} {
+			err := txn.Get([]byte(key), func([]byte) error { return errConformance })
+			if err == nil {
+				t.Errorf("Get(%q): want error returned by f, got nil", key)
+			}
+		}
+	})
+}
+
+func testSetDelete(t *testing.T, db kv.DB) {
+	update(t, db, func(txn kv.Txn) {
+		mustSet(t, txn, "key", "value1")
+		mustSet(t, txn, "key", "value2")
+		if got := mustGet(t, txn, "key"); got != "value2" {
+			t.Errorf("want %q after overwriting, got %q", "value2", got)
+		}
+		mustDelete(t, txn, "key")
+		if got := mustGet(t, txn, "key"); got != "" {
+			t.Errorf("want empty value after deleting, got %q", got)
+		}
+		mustDelete(t, txn, "missing")
+		mustSet(t, txn, "key", "value3")
+	})
+	view(t, db, func(txn kv.Txn) {
+		if got := mustGet(t, txn, "key"); got != "value3" {
+			t.Errorf("want %q after committing, got %q", "value3", got)
+		}
+	})
+	update(t, db, func(txn kv.Txn) { mustDelete(t, txn, "key") })
+	view(t, db, func(txn kv.Txn) {
+		if got := mustGet(t, txn, "key"); got != "" {
+			t.Errorf("want empty value after committing delete, got %q", got)
+		}
+	})
+}
+
+func testReusedBuffers(t *testing.T, db kv.DB) {
+	update(t, db, func(txn kv.Txn) { mustSet(t, txn, "k/0", "v0") })
+	want := map[string]string{"1": "v1", "2": "v2"}
+	check := func(txn kv.Txn, when string) {
+		got := make(map[string]string)
+		iter := txn.PrefixIterator([]byte("k/"))
+		defer iter.Discard()
+		for iter.Seek(nil); iter.Valid(); iter.Next() {
+			key := string(iter.Key())
+			if err := iter.Value(func(bs []byte) error {
+				got[key] = string(bs)
+				return nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if !reflect.DeepEqual(want, got) {
+			t.Errorf("%v: want %q, got %q", when, want, got)
+		}
+	}
+	update(t, db, func(txn kv.Txn) {
+		// Callers may overwrite their buffers as soon as Set and Delete
+		// return.
+		key, value := []byte("k/0"), []byte("v1")
+		if err := txn.Delete(key); err != nil {
+			t.Fatal(err)
+		}
+		key[2] = '1'
+		if err := txn.Set(key, value); err != nil {
+			t.Fatal(err)
+		}
+		key[2], value[1] = '2', '2'
+		if err := txn.Set(key, value); err != nil {
+			t.Fatal(err)
+		}
+		key[2], value[1] = '3', '3'
+		check(txn, "before committing")
+	})
+	view(t, db, func(txn kv.Txn) { check(txn, "after committing") })
+}
+
+func testReadOnly(t *testing.T, db kv.DB) {
+	update(t, db, func(txn kv.Txn) { mustSet(t, txn, "key", "value") })
+	view(t, db, func(txn kv.Txn) {
+		if err := txn.Set([]byte("key"), []byte("other")); err == nil {
+			t.Error("want error from Set in a read-only transaction, got nil")
+		}
+		if err := txn.Delete([]byte("key")); err == nil {
+			t.Error("want error from Delete in a read-only transaction, got nil")
+		}
+		if got := mustGet(t, txn, "key"); got != "value" {
+			t.Errorf("want %q, got %q", "value", got)
+		}
+	})
+}
+
+func testCommitDiscard(t *testing.T, db kv.DB) {
+	committed := db.NewTxn(true)
+	mustSet(t, committed, "committed", "value")
+	view(t, db, func(txn kv.Txn) {
+		if got := mustGet(t, txn, "committed"); got != "" {
+			t.Errorf("want uncommitted write to be invisible, got %q", got)
+		}
+	})
+	if err := committed.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	// Discarding after committing is the usual deferred cleanup, and must be
+	// harmless.
+	committed.Discard()
+
+	discarded := db.NewTxn(true)
+	mustSet(t, discarded, "discarded", "value")
+	discarded.Discard()
+
+	view(t, db, func(txn kv.Txn) {
+		if got := mustGet(t, txn, "committed"); got != "value" {
+			t.Errorf("want committed write %q, got %q", "value", got)
+		}
+		if got := mustGet(t, txn, "discarded"); got != "" {
+			t.Errorf("want discarded write to be invisible, got %q", got)
+		}
+	})
+}
+
+func testPrefixIterator(t *testing.T, db kv.DB) {
+	update(t, db, func(txn kv.Txn) {
+		for _, k := range []string{"a", "b", "b1", "b3", "bx", "c"} {
+			mustSet(t, txn, k, k)
+		}
+	})
+	txn := db.NewTxn(true)
+	defer txn.Discard()
+	// Iterators must merge writes that are not yet committed with those that
+	// are.
+	mustSet(t, txn, "b2", "b2")
+	mustSet(t, txn, "\xff", "\xff")
+	mustDelete(t, txn, "bx")
+	for _, test := range []struct {
+		Reverse bool
+		Prefix  string
+		Seek    string
+		Want    []string
+	}{
+		{false, "b", "", []string{"", "1", "2", "3"}},
+		{false, "b", "2", []string{"2", "3"}},
+		{false, "b", "15", []string{"2", "3"}},
+		{false, "b", "4", nil},
+		{false, "", "b", []string{"b", "b1", "b2", "b3", "c", "\xff"}},
+		{false, "d", "", nil},
+		{true, "b", "", []string{"3", "2", "1", ""}},
+		{true, "b", "2", []string{"2", "1", ""}},
+		{true, "b", "25", []string{"2", "1", ""}},
+		{true, "", "b1", []string{"b1", "b", "a"}},
+		{true, "", "", []string{"\xff", "c", "b3", "b2", "b1", "b", "a"}},
+		{true, "\xff", "", []string{""}},
+		{true, "d", "", nil},
+	} {
+		var (
+			got  []string
+			iter kv.Iterator
+		)
+		if test.Reverse {
+			iter = txn.ReversePrefixIterator([]byte(test.Prefix))
+		} else {
+			iter = txn.PrefixIterator([]byte(test.Prefix))
+		}
+		for iter.Seek([]byte(test.Seek)); iter.Valid(); iter.Next() {
+			got = append(got, string(iter.Key()))
+		}
+		iter.Discard()
+		if !reflect.DeepEqual(test.Want, got) {
+			t.Errorf("reverse=%v %q: Seek(%q): want %q, got %q",
+				test.Reverse, test.Prefix, test.Seek, test.Want, got)
+		}
+	}
+}
+
+func testIteratorValue(t *testing.T, db kv.DB) {
+	update(t, db, func(txn kv.Txn) {
+		mustSet(t, txn, "p/1", "one")
+		mustSet(t, txn, "p/2", "two")
+	})
+	view(t, db, func(txn kv.Txn) {
+		for _, reverse := range []bool{false, true} {
+			var iter kv.Iterator
+			if reverse {
+				iter = txn.ReversePrefixIterator([]byte("p/"))
+			} else {
+				iter = txn.PrefixIterator([]byte("p/"))
+			}
+			got := make(map[string]string)
+			for iter.Seek(nil); iter.Valid(); iter.Next() {
+				key := string(iter.Key())
+				if err := iter.Value(func(bs []byte) error {
+					got[key] = string(bs)
+					return nil
+				}); err != nil {
+					t.Fatal(err)
+				}
+				if err := iter.Value(func([]byte) error { return errConformance }); err == nil {
+					t.Errorf("reverse=%v: want error returned by f, got nil", reverse)
+				}
+			}
+			iter.Discard()
+			want := map[string]string{"1": "one", "2": "two"}
+			if !reflect.DeepEqual(want, got) {
+				t.Errorf("reverse=%v: want %q, got %q", reverse, want, got)
+			}
+		}
+	})
+}
+
+func testAlloc(t *testing.T, db kv.DB) {
+	const (
+		goroutines = 8
+		perTxn     = 50
+	)
+	var (
+		wg    sync.WaitGroup
+		mutex sync.Mutex
+		seen  = make(map[kv.Entity]bool)
+		errs  = make(chan error, goroutines)
+	)
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(update bool) {
+			defer wg.Done()
+			txn := db.NewTxn(update)
+			defer txn.Discard()
+			for i := 0; i < perTxn; i++ {
+				e, err := txn.Alloc()
+				if err != nil {
+					errs <- err
+					return
+				}
+				mutex.Lock()
+				duplicate := seen[e]
+				seen[e] = true
+				mutex.Unlock()
+				if duplicate {
+					errs <- fmt.Errorf("Alloc returned %v twice", e)
+					return
+				}
+			}
+		}(g%2 == 0)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+	if _, ok := seen[0]; ok {
+		t.Error("Alloc returned zero")
+	}
+}
+
+func testConcurrentTxns(t *testing.T, db kv.DB) {
+	const (
+		goroutines = 8
+		perTxn     = 10
+	)
+	var (
+		wg   sync.WaitGroup
+		errs = make(chan error, goroutines*2)
+	)
+	for g := 0; g < goroutines; g++ {
+		wg.Add(2)
+		go func(g int) {
+			defer wg.Done()
+			txn := db.NewTxn(true)
+			defer txn.Discard()
+			for i := 0; i < perTxn; i++ {
+				key := []byte(fmt.Sprintf("c/%02d/%02d", g, i))
+				if err := txn.Set(key, key); err != nil {
+					errs <- err
+					return
+				}
+			}
+			if err := txn.Commit(); err != nil {
+				errs <- err
+			}
+		}(g)
+		go func() {
+			defer wg.Done()
+			txn := db.NewTxn(false)
+			defer txn.Discard()
+			iter := txn.PrefixIterator([]byte("c/"))
+			defer iter.Discard()
+			for iter.Seek(nil); iter.Valid(); iter.Next() {
+				if err := iter.Value(func([]byte) error { return nil }); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+	view(t, db, func(txn kv.Txn) {
+		iter := txn.PrefixIterator([]byte("c/"))
+		defer iter.Discard()
+		n := 0
+		for iter.Seek(nil); iter.Valid(); iter.Next() {
+			n++
+		}
+		if n != goroutines*perTxn {
+			t.Errorf("want %v keys after concurrent commits, got %v", goroutines*perTxn, n)
+		}
+	})
+}
+
+func testWatch(t *testing.T, db kv.DB) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := make(chan []kv.Change, 1)
+	done := make(chan error)
+	go func() {
+		done <- db.Watch(ctx, []byte("a/"), func(cs []kv.Change) error {
+			select {
+			case ch <- cs:
+			case <-ctx.Done():
+			}
+			return nil
+		})
+	}()
+	// Changes committed before Watch begins watching are not delivered, so
+	// commit until one is.
+	for ready := false; !ready; {
+		update(t, db, func(txn kv.Txn) { mustSet(t, txn, "a/ready", "") })
+		select {
+		case <-ch:
+			ready = true
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	update(t, db, func(txn kv.Txn) {
+		mustSet(t, txn, "a/1", "x")
+		mustSet(t, txn, "b/1", "y")
+		mustDelete(t, txn, "a/2")
+	})
+	// The changes in a batch may be delivered in any order.
+	want := map[string]string{"a/1": "x", "a/2": ""}
+	for {
+		cs := <-ch
+		if len(cs) == 1 && string(cs[0].Key) == "a/ready" {
+			continue
+		}
+		got := make(map[string]string)
+		for _, c := range cs {
+			got[string(c.Key)] = string(c.Value)
+		}
+		if len(cs) != len(want) || !reflect.DeepEqual(want, got) {
+			t.Errorf("want %q, got %q", want, cs)
+		}
+		break
+	}
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("want %v, got %v", context.Canceled, err)
+	}
+}
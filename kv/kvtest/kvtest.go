@@ -32,7 +32,7 @@ import (
 //
 // It's still important to call Close() in order to delete any temporary files
 // created by the kv.Txn.
-func NewDB(t *testing.T) kv.DB {
+func NewDB(t testing.TB) kv.DB {
 	dir, err := ioutil.TempDir("", "kvtest-badger")
 	if err != nil {
 		t.Fatal(err)
@@ -93,7 +93,7 @@ func (s *tmpTxn) Discard() {
 }
 
 type badgerLogger struct {
-	*testing.T
+	testing.TB
 }
 
 func (l badgerLogger) Errorf(f string, v ...interface{})   { l.Logf(f, v...) }
@@ -182,6 +182,14 @@ func (s *Flaky) Set(k, v []byte) error {
 	return s.Txn.Set(k, v)
 }
 
+// Delete fails if the count of error checks has reached failAtCount.
+func (s *Flaky) Delete(k []byte) error {
+	if s.fail() {
+		return s.err
+	}
+	return s.Txn.Delete(k)
+}
+
 // Deflake calls test repeatedly to check that all errors returned from
 // kv.Txn methods produce failures in the test.
 //
@@ -36,6 +36,7 @@ const (
 	Alloc flakyMethod = iota
 	Get
 	Set
+	Delete
 	FlakyMethodCount
 )
 
@@ -66,6 +67,9 @@ func callMethod(s kv.Txn, m flakyMethod) error {
 	case Set:
 		err := s.Set(randBytes(4), randBytes(32))
 		return err
+	case Delete:
+		err := s.Delete(randBytes(4))
+		return err
 	default:
 		panic(fmt.Sprintf("unrecognized flakyMethod %v", m))
 	}
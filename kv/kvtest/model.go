@@ -0,0 +1,494 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kvtest
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/note-maps/kv"
+	"github.com/google/note-maps/kv/memory"
+)
+
+// Model describes a kvschema-generated package to RunModel.
+type Model struct {
+	// New is the generated New function of the package, such as docs.New.
+	New interface{}
+
+	// Values maps the name of each component to exercise to a function that
+	// returns a random value suitable for passing to its Set method.
+	//
+	// Values drawn from a small set make collisions between index values,
+	// and therefore index bugs, more likely.
+	Values map[string]func(*rand.Rand) interface{}
+
+	// NewTxn returns the transaction that each sequence runs in. If NewTxn
+	// is nil, memory.New is used.
+	NewTxn func() kv.TxnCommitDiscarder
+
+	// NewDB, if it is not nil, returns the database that each sequence runs
+	// against instead. Each call runs in a transaction of its own that is
+	// committed before the next call, so that later calls read what
+	// earlier calls stored. RunModel closes each database it gets from
+	// NewDB.
+	NewDB func() kv.DB
+
+	// Seed seeds the random source used to generate sequences.
+	Seed int64
+
+	// Runs is the number of random sequences to run, and Steps is the number
+	// of calls in each sequence. Both default to 100 if they are zero.
+	Runs, Steps int
+
+	// Entities is the number of distinct entities to use. It defaults to 8
+	// if it is zero.
+	Entities int
+}
+
+// RunModel checks a kvschema-generated package by running random sequences of
+// calls to its Set, Delete, Get, EntitiesMatching and EntitiesBy methods, and
+// comparing each result to that of a simple in-memory reference model,
+// including the kv.UniqueViolation errors expected from unique indexes.
+//
+// The components to exercise are those named in m.Values, and their indexes
+// are discovered through reflection. When a sequence fails, RunModel reports
+// a minimal subsequence of its calls that still fails.
+func RunModel(t *testing.T, m Model) {
+	t.Helper()
+	s, err := newModelSchema(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := rand.New(rand.NewSource(m.Seed))
+	for run := 0; run < s.runs; run++ {
+		ops := s.generate(r)
+		n, err := s.run(ops)
+		if err == nil {
+			continue
+		}
+		ops = minimize(ops[:n+1], func(ops []modelOp) bool {
+			_, err := s.run(ops)
+			return err != nil
+		})
+		_, err = s.run(ops)
+		var lines []string
+		for _, op := range ops {
+			lines = append(lines, "\t"+op.String())
+		}
+		t.Fatalf("seed %v, run %v: %v\nminimal failing sequence:\n%s",
+			m.Seed, run, err, strings.Join(lines, "\n"))
+	}
+}
+
+// modelSchema holds everything RunModel learns about a package.
+type modelSchema struct {
+	newTxn     func() kv.TxnCommitDiscarder
+	newDB      func() kv.DB
+	new        reflect.Value
+	components []*modelComponent
+	runs       int
+	steps      int
+	entities   int
+}
+
+type modelComponent struct {
+	name    string
+	value   func(*rand.Rand) interface{}
+	zero    reflect.Type
+	indexes []*modelIndex
+}
+
+type modelIndex struct {
+	name string
+
+	// method is the name of the index method, and unique is true if it
+	// names a unique index.
+	method string
+	unique bool
+
+	// seen holds index values produced by generated values, to use as
+	// arguments to EntitiesMatching.
+	seen []reflect.Value
+}
+
+func newModelSchema(m Model) (*modelSchema, error) {
+	s := &modelSchema{
+		newTxn:   m.NewTxn,
+		newDB:    m.NewDB,
+		new:      reflect.ValueOf(m.New),
+		runs:     m.Runs,
+		steps:    m.Steps,
+		entities: m.Entities,
+	}
+	if s.newTxn == nil {
+		s.newTxn = memory.New
+	}
+	if s.runs == 0 {
+		s.runs = 100
+	}
+	if s.steps == 0 {
+		s.steps = 100
+	}
+	if s.entities == 0 {
+		s.entities = 8
+	}
+	if s.new.Kind() != reflect.Func || s.new.Type().NumIn() != 1 || s.new.Type().NumOut() != 1 {
+		return nil, fmt.Errorf("kvtest: Model.New must be a generated New function, got %T", m.New)
+	}
+	if len(m.Values) == 0 {
+		return nil, fmt.Errorf("kvtest: Model.Values names no components")
+	}
+	txnType := s.new.Type().Out(0)
+	var names []string
+	for name := range m.Values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		c := &modelComponent{name: name, value: m.Values[name]}
+		for _, method := range []string{"Set", "Delete", "Get"} {
+			if _, ok := txnType.MethodByName(method + name); !ok {
+				return nil, fmt.Errorf("kvtest: %v has no method %v%v", txnType, method, name)
+			}
+		}
+		get, _ := txnType.MethodByName("Get" + name)
+		c.zero = get.Type.Out(0)
+		sample := reflect.ValueOf(c.value(rand.New(rand.NewSource(0))))
+		for i := 0; i < txnType.NumMethod(); i++ {
+			ix := strings.TrimPrefix(txnType.Method(i).Name, "EntitiesMatching"+name)
+			if ix == txnType.Method(i).Name {
+				continue
+			}
+			switch {
+			case sample.MethodByName("Index" + ix).IsValid():
+				c.indexes = append(c.indexes, &modelIndex{name: ix, method: "Index" + ix})
+			case sample.MethodByName("IndexUnique" + ix).IsValid():
+				c.indexes = append(c.indexes, &modelIndex{name: ix, method: "IndexUnique" + ix, unique: true})
+			}
+		}
+		s.components = append(s.components, c)
+	}
+	return s, nil
+}
+
+// modelOp is a single call made by RunModel.
+type modelOp struct {
+	method string
+	c      *modelComponent
+	ix     *modelIndex
+	e      kv.Entity
+	v      reflect.Value
+	n      int
+}
+
+func (op modelOp) String() string {
+	switch {
+	case strings.HasPrefix(op.method, "Set"):
+		return fmt.Sprintf("%v(%v, %+v)", op.method, op.e, op.v.Interface())
+	case strings.HasPrefix(op.method, "EntitiesMatching"):
+		return fmt.Sprintf("%v(%q)", op.method, op.v.Interface())
+	case strings.HasPrefix(op.method, "EntitiesBy"):
+		return fmt.Sprintf("%v(cursor, %v)", op.method, op.n)
+	default:
+		return fmt.Sprintf("%v(%v)", op.method, op.e)
+	}
+}
+
+// generate returns a random sequence of calls.
+func (s *modelSchema) generate(r *rand.Rand) []modelOp {
+	ops := make([]modelOp, 0, s.steps)
+	for len(ops) < s.steps {
+		c := s.components[r.Intn(len(s.components))]
+		op := modelOp{c: c, e: kv.Entity(1 + r.Intn(s.entities))}
+		switch k := r.Intn(10); {
+		case k < 4:
+			op.method = "Set" + c.name
+			op.v = reflect.ValueOf(c.value(r))
+			for _, ix := range c.indexes {
+				ix.seen = append(ix.seen, indexValues(op.v, ix)...)
+			}
+		case k < 5:
+			op.method = "Delete" + c.name
+		case k < 7:
+			op.method = "Get" + c.name
+		case len(c.indexes) == 0:
+			continue
+		default:
+			op.ix = c.indexes[r.Intn(len(c.indexes))]
+			if k < 9 && len(op.ix.seen) > 0 {
+				op.method = "EntitiesMatching" + c.name + op.ix.name
+				op.v = op.ix.seen[r.Intn(len(op.ix.seen))]
+			} else {
+				op.method = "EntitiesBy" + c.name + op.ix.name
+				op.n = 1 + r.Intn(4)
+			}
+		}
+		ops = append(ops, op)
+	}
+	return ops
+}
+
+// run makes the calls in ops, and returns the index of the first call whose
+// result differs from that of the model along with a description of the
+// difference.
+func (s *modelSchema) run(ops []modelOp) (i int, err error) {
+	var (
+		db  kv.DB
+		txn kv.TxnCommitDiscarder
+		sut reflect.Value
+	)
+	if s.newDB != nil {
+		db = s.newDB()
+		defer db.Close()
+	} else {
+		txn = s.newTxn()
+		sut = s.new.Call([]reflect.Value{reflect.ValueOf(kv.Txn(txn))})[0]
+	}
+	defer func() {
+		if txn != nil {
+			txn.Discard()
+		}
+	}()
+	values := make(map[*modelComponent]map[kv.Entity]reflect.Value)
+	for _, c := range s.components {
+		values[c] = make(map[kv.Entity]reflect.Value)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v: panic: %v", ops[i], r)
+		}
+	}()
+	for i = range ops {
+		op := ops[i]
+		if db != nil {
+			txn = db.NewTxn(true)
+			sut = s.new.Call([]reflect.Value{reflect.ValueOf(kv.Txn(txn))})[0]
+		}
+		method := sut.MethodByName(op.method)
+		var (
+			want, got interface{}
+			equal     bool
+		)
+		switch {
+		case strings.HasPrefix(op.method, "Set"):
+			err = callErr(method, reflect.ValueOf(op.e), op.v)
+			if conflicts := violations(values[op.c], op); len(conflicts) > 0 {
+				v, ok := err.(kv.UniqueViolation)
+				want, got = "kv.UniqueViolation", err
+				equal = ok && conflicts[fmt.Sprint(v.Value, v.Entity)]
+				err = nil
+				break
+			}
+			values[op.c][op.e] = op.v
+			equal = true
+		case strings.HasPrefix(op.method, "Delete"):
+			err = callErr(method, reflect.ValueOf(op.e))
+			delete(values[op.c], op.e)
+			equal = true
+		case strings.HasPrefix(op.method, "Get"):
+			out := method.Call([]reflect.Value{reflect.ValueOf(op.e)})
+			err, _ = out[1].Interface().(error)
+			w := encodeValue(reflect.Zero(op.c.zero))
+			if v, ok := values[op.c][op.e]; ok {
+				w = encodeValue(v)
+			}
+			g := encodeValue(out[0])
+			want, got, equal = w, g, bytes.Equal(w, g)
+		case strings.HasPrefix(op.method, "EntitiesMatching"):
+			out := method.Call([]reflect.Value{op.v})
+			err, _ = out[1].Interface().(error)
+			w := matching(values[op.c], op.ix, encodeValue(op.v))
+			g := out[0].Convert(reflect.TypeOf([]kv.Entity(nil))).Interface().([]kv.Entity)
+			want, got, equal = w, g, equalEntities(w, g)
+		case strings.HasPrefix(op.method, "EntitiesBy"):
+			w := ordered(values[op.c], op.ix)
+			var (
+				g      []kv.Entity
+				cursor kv.IndexCursor
+			)
+			// Stop paging after more pages than the model allows for, in
+			// case the cursor never advances.
+			for page := 0; page <= len(w)+1 && err == nil; page++ {
+				out := method.Call([]reflect.Value{reflect.ValueOf(&cursor), reflect.ValueOf(op.n)})
+				err, _ = out[1].Interface().(error)
+				es := out[0].Interface().([]kv.Entity)
+				g = append(g, es...)
+				if len(es) < op.n {
+					break
+				}
+			}
+			want, got, equal = w, g, equalEntities(w, g)
+		}
+		if db != nil {
+			if cerr := txn.Commit(); err == nil {
+				err = cerr
+			}
+			txn.Discard()
+			txn = nil
+		}
+		if err != nil {
+			return i, fmt.Errorf("%v: %v", op, err)
+		}
+		if !equal {
+			return i, fmt.Errorf("%v: want %v, got %v", op, want, got)
+		}
+	}
+	return len(ops), nil
+}
+
+// callErr calls f with args and returns its only result, an error.
+func callErr(f reflect.Value, args ...reflect.Value) error {
+	err, _ := f.Call(args)[0].Interface().(error)
+	return err
+}
+
+// violations returns the pairs of encoded value and entity, formatted by
+// fmt.Sprint, that would make the Set call op violate a unique index.
+func violations(values map[kv.Entity]reflect.Value, op modelOp) map[string]bool {
+	conflicts := make(map[string]bool)
+	for _, ix := range op.c.indexes {
+		if !ix.unique {
+			continue
+		}
+		for _, x := range indexValues(op.v, ix) {
+			iv := encodeValue(x)
+			for _, e := range matching(values, ix, iv) {
+				if e != op.e {
+					conflicts[fmt.Sprint(iv, e)] = true
+				}
+			}
+		}
+	}
+	return conflicts
+}
+
+// indexValues returns the encoded values that v's index method for ix
+// returns.
+func indexValues(v reflect.Value, ix *modelIndex) []reflect.Value {
+	ivs := v.MethodByName(ix.method).Call(nil)[0]
+	result := make([]reflect.Value, ivs.Len())
+	for i := range result {
+		result[i] = ivs.Index(i)
+	}
+	return result
+}
+
+// matching returns the sorted entities whose values have iv among the values
+// for index ix.
+func matching(values map[kv.Entity]reflect.Value, ix *modelIndex, iv []byte) []kv.Entity {
+	var es []kv.Entity
+	for e, v := range values {
+		for _, x := range indexValues(v, ix) {
+			if bytes.Equal(encodeValue(x), iv) {
+				es = append(es, e)
+				break
+			}
+		}
+	}
+	sort.Slice(es, func(a, b int) bool { return es[a] < es[b] })
+	return es
+}
+
+// ordered returns entities ordered by their encoded values for index ix, and
+// then by entity, with each distinct pair of value and entity appearing once.
+func ordered(values map[kv.Entity]reflect.Value, ix *modelIndex) []kv.Entity {
+	type row struct {
+		iv []byte
+		e  kv.Entity
+	}
+	var rows []row
+	seen := make(map[string]bool)
+	for e, v := range values {
+		for _, x := range indexValues(v, ix) {
+			iv := encodeValue(x)
+			if k := fmt.Sprint(iv, e); !seen[k] {
+				seen[k] = true
+				rows = append(rows, row{iv, e})
+			}
+		}
+	}
+	sort.Slice(rows, func(a, b int) bool {
+		if c := bytes.Compare(rows[a].iv, rows[b].iv); c != 0 {
+			return c < 0
+		}
+		return rows[a].e < rows[b].e
+	})
+	es := make([]kv.Entity, len(rows))
+	for i := range rows {
+		es[i] = rows[i].e
+	}
+	return es
+}
+
+// encodeValue calls Encode on v, or on a pointer to a copy of v if only the
+// pointer type implements kv.Encoder, or encodes the fields of v as a tuple if
+// neither does.
+func encodeValue(v reflect.Value) []byte {
+	if enc, ok := v.Interface().(kv.Encoder); ok {
+		if v.Kind() == reflect.Ptr && v.IsNil() {
+			v = reflect.New(v.Type().Elem())
+			return v.Interface().(kv.Encoder).Encode()
+		}
+		return enc.Encode()
+	}
+	p := reflect.New(v.Type())
+	p.Elem().Set(v)
+	if enc, ok := p.Interface().(kv.Encoder); ok {
+		return enc.Encode()
+	}
+	// Otherwise v is a tuple for a composite index.
+	fields := make([]kv.Encoder, v.NumField())
+	for i := range fields {
+		fields[i] = encoded(encodeValue(v.Field(i)))
+	}
+	return kv.EncodeTuple(fields...)
+}
+
+// encoded implements kv.Encoder for a value that is already encoded.
+type encoded []byte
+
+func (e encoded) Encode() []byte { return e }
+
+func equalEntities(a, b []kv.Entity) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// minimize removes calls from ops for as long as the result still fails.
+func minimize(ops []modelOp, fails func([]modelOp) bool) []modelOp {
+	for chunk := len(ops) / 2; chunk >= 1; chunk /= 2 {
+		for i := 0; i+chunk <= len(ops); {
+			candidate := append(append([]modelOp(nil), ops[:i]...), ops[i+chunk:]...)
+			if fails(candidate) {
+				ops = candidate
+			} else {
+				i += chunk
+			}
+		}
+	}
+	return ops
+}
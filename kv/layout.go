@@ -0,0 +1,235 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import (
+	"errors"
+	"sort"
+)
+
+// IndexLayout identifies one of the ways in which the rows of an index can be
+// stored.
+type IndexLayout int
+
+const (
+	// SliceLayout stores one row for each index value, with a key that ends
+	// with the encoded index value and an EntitySlice value that holds every
+	// entity with that index value.
+	//
+	// Adding an entity to, or removing an entity from, a row means reading
+	// and rewriting the whole row, which becomes slow when many entities
+	// share an index value.
+	SliceLayout IndexLayout = iota
+
+	// KeyedLayout stores one row for each pair of index value and entity,
+	// with a key that ends with the escaped index value followed by the
+	// entity, and an empty value.
+	//
+	// Adding or removing an entity writes a single small row no matter how
+	// many other entities share its index value.
+	KeyedLayout
+)
+
+// ErrInvalidIndexRow is returned when an index row cannot be decoded
+// according to its layout.
+var ErrInvalidIndexRow = errors.New("kv: invalid index row")
+
+// String returns the name of l as used by the kvschema -index_layout flag.
+func (l IndexLayout) String() string {
+	switch l {
+	case SliceLayout:
+		return "slice"
+	case KeyedLayout:
+		return "keyed"
+	default:
+		return "invalid"
+	}
+}
+
+// Escaping for KeyedLayout replaces each zero byte in an encoded index value
+// with escapedZero, and then terminates the value with keyedTerminator. Since
+// keyedTerminator sorts before escapedZero and every other byte, escaped
+// values sort in the same order as the values themselves, and no escaped and
+// terminated value is a prefix of another.
+var (
+	escapedZero     = []byte{0x00, 0xff}
+	keyedTerminator = []byte{0x00, 0x01}
+)
+
+// AppendKeyedIndexValue appends v to dst, escaped and terminated as in the
+// keys of KeyedLayout index rows, and returns the result.
+func AppendKeyedIndexValue(dst, v []byte) []byte {
+	return append(appendEscaped(dst, v), keyedTerminator...)
+}
+
+func appendEscaped(dst, v []byte) []byte {
+	for _, b := range v {
+		if b == 0 {
+			dst = append(dst, escapedZero...)
+		} else {
+			dst = append(dst, b)
+		}
+	}
+	return dst
+}
+
+// SplitKeyedIndexRow splits the key of a KeyedLayout index row, relative to
+// the prefix of its index, into the encoded index value and the entity.
+func SplitKeyedIndexRow(key []byte) (v []byte, e Entity, err error) {
+	for i := 0; i < len(key); i++ {
+		if key[i] != 0 {
+			v = append(v, key[i])
+			continue
+		}
+		i++
+		switch {
+		case i == len(key):
+			return nil, 0, ErrInvalidIndexRow
+		case key[i] == escapedZero[1]:
+			v = append(v, 0)
+		case key[i] == keyedTerminator[1] && len(key)-i-1 == 8:
+			e.Decode(key[i+1:])
+			return v, e, nil
+		default:
+			return nil, 0, ErrInvalidIndexRow
+		}
+	}
+	return nil, 0, ErrInvalidIndexRow
+}
+
+// appendBound appends the form of encoded index value v, or a prefix of one,
+// that bounds the relative keys of rows in layout l.
+func (l IndexLayout) appendBound(dst, v []byte) []byte {
+	if l == KeyedLayout {
+		return appendEscaped(dst, v)
+	}
+	return append(dst, v...)
+}
+
+// decodeRow decodes the entities in the row at iter.
+func (l IndexLayout) decodeRow(iter Iterator, es *EntitySlice) error {
+	if l == KeyedLayout {
+		k := iter.Key()
+		if len(k) < 8 {
+			return ErrInvalidIndexRow
+		}
+		*es = append((*es)[:0], 0)
+		return (*es)[0].Decode(k[len(k)-8:])
+	}
+	return iter.Value(es.Decode)
+}
+
+// EntitiesMatchingKeyedIndex returns the entities with c values that have v
+// as an encoded ix value, where ix is stored in KeyedLayout.
+func (s Partitioned) EntitiesMatchingKeyedIndex(c, ix Component, v []byte) (es EntitySlice, err error) {
+	iter := s.PrefixIterator(AppendKeyedIndexValue(s.indexPrefix(c, ix), v))
+	defer iter.Discard()
+	for iter.Seek(nil); iter.Valid(); iter.Next() {
+		var e Entity
+		if len(iter.Key()) != 8 {
+			return nil, ErrInvalidIndexRow
+		}
+		e.Decode(iter.Key())
+		es = append(es, e)
+	}
+	return es, nil
+}
+
+// EntitiesByComponentKeyedIndex is like EntitiesByComponentIndex for an index
+// stored in KeyedLayout.
+func (s Partitioned) EntitiesByComponentKeyedIndex(c, ix Component, cursor *IndexCursor, n int) (es []Entity, err error) {
+	return s.EntitiesByComponentKeyedIndexRange(c, ix, nil, nil, false, cursor, n)
+}
+
+// EntitiesByComponentKeyedIndexRange is like EntitiesByComponentIndexRange
+// for an index stored in KeyedLayout.
+func (s Partitioned) EntitiesByComponentKeyedIndexRange(c, ix Component, lo, hi []byte, reverse bool, cursor *IndexCursor, n int) (es []Entity, err error) {
+	return s.entitiesByIndexRange(KeyedLayout, c, ix, lo, hi, reverse, cursor, n)
+}
+
+// EntitiesWithComponentKeyedIndexPrefix is like
+// EntitiesWithComponentIndexPrefix for an index stored in KeyedLayout.
+func (s Partitioned) EntitiesWithComponentKeyedIndexPrefix(c, ix Component, prefix []byte, n int) (es []Entity, err error) {
+	return s.entitiesWithIndexPrefix(KeyedLayout, c, ix, prefix, n)
+}
+
+// ChangeIndexLayout rewrites all the rows of index ix of component c from
+// layout from to layout to.
+//
+// All the rows of the index are held in memory while they are rewritten.
+// ChangeIndexLayout is intended for use in migrations.
+func (s Partitioned) ChangeIndexLayout(c, ix Component, from, to IndexLayout) error {
+	if from == to {
+		return nil
+	}
+	prefix := s.indexPrefix(c, ix)
+	var (
+		keys [][]byte
+		rows = make(map[string]EntitySlice)
+	)
+	iter := s.PrefixIterator(prefix)
+	for iter.Seek(nil); iter.Valid(); iter.Next() {
+		k := append([]byte(nil), iter.Key()...)
+		keys = append(keys, k)
+		switch from {
+		case KeyedLayout:
+			v, e, err := SplitKeyedIndexRow(k)
+			if err != nil {
+				iter.Discard()
+				return err
+			}
+			es := rows[string(v)]
+			es.Insert(e)
+			rows[string(v)] = es
+		default:
+			var es EntitySlice
+			if err := iter.Value(es.Decode); err != nil {
+				iter.Discard()
+				return err
+			}
+			rows[string(k)] = append(rows[string(k)], es...)
+		}
+	}
+	iter.Discard()
+	for _, k := range keys {
+		if err := s.Delete(append(prefix[:len(prefix):len(prefix)], k...)); err != nil {
+			return err
+		}
+	}
+	vs := make([]string, 0, len(rows))
+	for v := range rows {
+		vs = append(vs, v)
+	}
+	sort.Strings(vs)
+	for _, v := range vs {
+		es := rows[v]
+		switch to {
+		case KeyedLayout:
+			for _, e := range es {
+				key := AppendKeyedIndexValue(prefix[:len(prefix):len(prefix)], []byte(v))
+				if err := s.Set(append(key, e.Encode()...), []byte{}); err != nil {
+					return err
+				}
+			}
+		default:
+			sort.Sort(es)
+			key := append(prefix[:len(prefix):len(prefix)], v...)
+			if err := s.SetEntitySlice(key, es); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/google/note-maps/kv"
+	"github.com/google/note-maps/kv/memory"
+)
+
+// layoutValues are encoded index values in ascending order, including values
+// that contain zero bytes and values that are prefixes of others.
+var layoutValues = [][]byte{
+	{},
+	{0},
+	{0, 0},
+	{0, 1},
+	{0, 0xff},
+	{1},
+	{1, 0},
+	{1, 0, 0xff},
+	{1, 1},
+	{0xff},
+	{0xff, 0},
+}
+
+func TestKeyedIndexValueOrder(t *testing.T) {
+	var prev []byte
+	for i, v := range layoutValues {
+		for _, e := range []kv.Entity{1, 0xffffffffffffffff} {
+			key := append(kv.AppendKeyedIndexValue(nil, v), e.Encode()...)
+			if i > 0 && bytes.Compare(prev, key) >= 0 {
+				t.Errorf("want key for %v/%v after %v, got %v", v, e, prev, key)
+			}
+			gotV, gotE, err := kv.SplitKeyedIndexRow(key)
+			if err != nil {
+				t.Fatal(err)
+			} else if !bytes.Equal(gotV, v) || gotE != e {
+				t.Errorf("want %v and %v from %v, got %v and %v", v, e, key, gotV, gotE)
+			}
+			prev = key
+		}
+	}
+	for _, key := range [][]byte{{}, {0}, {1, 0, 2}, {0, 1, 0, 0}} {
+		if _, _, err := kv.SplitKeyedIndexRow(key); err != kv.ErrInvalidIndexRow {
+			t.Errorf("want %v for %v, got %v", kv.ErrInvalidIndexRow, key, err)
+		}
+	}
+}
+
+// indexReads describes the results of reading an index in every supported
+// way, so that they can be compared across layouts.
+func indexReads(t *testing.T, p kv.Partitioned, layout kv.IndexLayout) string {
+	const c, ix kv.Component = 1, 2
+	var (
+		buf      bytes.Buffer
+		byRange  = p.EntitiesByComponentIndexRange
+		byPrefix = p.EntitiesWithComponentIndexPrefix
+	)
+	if layout == kv.KeyedLayout {
+		byRange = p.EntitiesByComponentKeyedIndexRange
+		byPrefix = p.EntitiesWithComponentKeyedIndexPrefix
+	}
+	for _, v := range layoutValues {
+		var (
+			es  []kv.Entity
+			err error
+		)
+		if layout == kv.KeyedLayout {
+			es, err = p.EntitiesMatchingKeyedIndex(c, ix, v)
+		} else {
+			es, err = byRange(c, ix, v, append(v, 0), false, &kv.IndexCursor{}, 100)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		fmt.Fprintln(&buf, "match", v, es)
+		if es, err = byPrefix(c, ix, v, 0); err != nil {
+			t.Fatal(err)
+		}
+		fmt.Fprintln(&buf, "prefix", v, es)
+	}
+	for _, r := range []struct{ lo, hi []byte }{
+		{nil, nil},
+		{[]byte{0}, []byte{1}},
+		{[]byte{0, 1}, nil},
+		{nil, []byte{1, 0}},
+	} {
+		for _, reverse := range []bool{false, true} {
+			for n := 1; n <= 4; n++ {
+				var (
+					cursor kv.IndexCursor
+					all    []kv.Entity
+				)
+				for {
+					es, err := byRange(c, ix, r.lo, r.hi, reverse, &cursor, n)
+					if err != nil {
+						t.Fatal(err)
+					}
+					all = append(all, es...)
+					if len(es) < n {
+						break
+					}
+				}
+				fmt.Fprintln(&buf, "range", r.lo, r.hi, reverse, n, all)
+			}
+		}
+	}
+	return buf.String()
+}
+
+func TestChangeIndexLayout(t *testing.T) {
+	const c, ix kv.Component = 1, 2
+	p := kv.Partitioned{Txn: memory.New(), Partition: 3}
+	for i, v := range layoutValues {
+		key := kv.Prefix(p.Partition.Encode()).AppendComponent(c).
+			ConcatEntityComponentBytes(0, ix, v)
+		es := kv.EntitySlice{kv.Entity(i + 1), kv.Entity(i + 2), 100}
+		if err := p.SetEntitySlice(key, es); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := indexReads(t, p, kv.SliceLayout)
+	if err := p.ChangeIndexLayout(c, ix, kv.SliceLayout, kv.KeyedLayout); err != nil {
+		t.Fatal(err)
+	}
+	if got := indexReads(t, p, kv.KeyedLayout); got != want {
+		t.Errorf("keyed layout: want\n%v\ngot\n%v", want, got)
+	}
+	if err := p.ChangeIndexLayout(c, ix, kv.KeyedLayout, kv.SliceLayout); err != nil {
+		t.Fatal(err)
+	}
+	if got := indexReads(t, p, kv.SliceLayout); got != want {
+		t.Errorf("slice layout again: want\n%v\ngot\n%v", want, got)
+	}
+}
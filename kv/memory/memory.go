@@ -12,98 +12,468 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-// Package memory provides an in-memory implementation of kv.Txn.
+// Package memory provides in-memory implementations of kv.Txn and kv.DB.
+//
+// Transactions behave like those of kv/badger: each one reads from a snapshot
+// of the database taken when it was created, buffers its writes until it is
+// committed, and fails to commit with ErrConflict if any key it read was
+// changed by another transaction that committed first.
 package memory
 
 import (
-	"sort"
+	"context"
+	"errors"
 	"strings"
 	"sync"
 
 	"github.com/google/note-maps/kv"
+	"github.com/google/note-maps/kv/internal/watch"
+)
+
+var (
+	// ErrConflict is returned by Commit when a key read by the transaction
+	// has been updated by another transaction since this one began. It is
+	// the same error as kv.ErrConflict.
+	ErrConflict = kv.ErrConflict
+
+	// ErrReadOnlyTxn is returned by Set and Delete in a read-only
+	// transaction.
+	ErrReadOnlyTxn = errors.New("memory: no sets or deletes are allowed in a read-only transaction")
+
+	// ErrDiscardedTxn is returned when a transaction is used after it has
+	// been committed or discarded.
+	ErrDiscardedTxn = errors.New("memory: transaction has been discarded")
 )
 
 // New returns a memory-backed implementation of the kv.Txn interface
 // intended exclusively for use in tests, and not in production.
+//
+// The result is an update transaction in a new, empty database.
 func New() kv.TxnCommitDiscarder {
-	return &txn{
-		m: make(map[string][]byte),
+	return NewDB().NewTxn(true)
+}
+
+// NewDB returns a memory-backed implementation of the kv.DB interface
+// intended exclusively for use in tests, and not in production.
+func NewDB() kv.DB {
+	return &db{
+		versions: newSkiplist(),
+		active:   make(map[uint64]int),
+		hub:      watch.NewHub(),
 	}
 }
 
-type txn struct {
-	m     map[string][]byte
+type db struct {
+	// versions holds every version of each key that may still be visible to
+	// an active transaction, in ascending order of commit timestamp.
+	versions *skiplist
+
+	// clock is the commit timestamp of the most recent commit.
+	clock uint64
+
+	// active counts the active transactions by their read timestamps.
+	active map[uint64]int
+
 	next  kv.Entity
+	hub   *watch.Hub
+	mutex sync.Mutex
+}
+
+// version is a value committed at a specific timestamp.
+type version struct {
+	ts      uint64
+	value   []byte
+	deleted bool
+}
+
+// at returns the version of a key visible at timestamp ts.
+func at(vs []version, ts uint64) (version, bool) {
+	for i := len(vs) - 1; i >= 0; i-- {
+		if vs[i].ts <= ts {
+			return vs[i], !vs[i].deleted
+		}
+	}
+	return version{}, false
+}
+
+func (d *db) NewTxn(update bool) kv.TxnCommitDiscarder {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.active[d.clock]++
+	t := &txn{db: d, update: update, readTs: d.clock}
+	if update {
+		t.writes = newSkiplist()
+		t.reads = make(map[string]struct{})
+	}
+	return t
+}
+
+// Reserve ensures that transactions will never allocate e or any smaller
+// Entity.
+func (d *db) Reserve(e kv.Entity) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if d.next < e {
+		d.next = e
+	}
+	return nil
+}
+
+func (d *db) Close() error {
+	d.hub.Close()
+	return nil
+}
+
+func (d *db) Watch(ctx context.Context, prefix []byte, f func([]kv.Change) error) error {
+	return d.hub.Watch(ctx, prefix, f)
+}
+
+// release forgets an active transaction that began at readTs.
+//
+// The caller must hold d.mutex.
+func (d *db) release(readTs uint64) {
+	if d.active[readTs]--; d.active[readTs] <= 0 {
+		delete(d.active, readTs)
+	}
+}
+
+// oldest returns the read timestamp of the oldest active transaction, or the
+// current time if there are none.
+//
+// The caller must hold d.mutex.
+func (d *db) oldest() uint64 {
+	oldest := d.clock
+	for ts := range d.active {
+		if ts < oldest {
+			oldest = ts
+		}
+	}
+	return oldest
+}
+
+// prune discards versions in x that are no longer visible to any transaction
+// with a read timestamp of oldest or later.
+//
+// The caller must hold d.mutex.
+func (d *db) prune(x *node, oldest uint64) {
+	i := len(x.vs) - 1
+	for i > 0 && x.vs[i].ts > oldest {
+		i--
+	}
+	x.vs = x.vs[i:]
+	if len(x.vs) == 1 && x.vs[0].deleted {
+		d.versions.remove(x.key)
+	}
+}
+
+// txn reads from the versions of its db committed at or before readTs, and
+// buffers its own writes until they are committed.
+type txn struct {
+	db     *db
+	update bool
+	readTs uint64
+	done   bool
+
+	// changes holds buffered writes in the order they were made, and writes
+	// holds the latest buffered write to each key as its only version.
+	changes []kv.Change
+	writes  *skiplist
+
+	// reads holds the keys read by an update transaction, for conflict
+	// detection.
+	reads map[string]struct{}
+
 	mutex sync.Mutex
 }
 
 func (s *txn) Alloc() (kv.Entity, error) {
-	s.mutex.Lock()
-	defer s.mutex.Unlock()
-	s.next++
-	return s.next, nil
+	s.db.mutex.Lock()
+	defer s.db.mutex.Unlock()
+	s.db.next++
+	return s.db.next, nil
 }
 
 func (s *txn) Get(k []byte, f func([]byte) error) error {
 	s.mutex.Lock()
-	defer s.mutex.Unlock()
-	return f(s.m[string(k)])
+	if s.done {
+		s.mutex.Unlock()
+		return ErrDiscardedTxn
+	}
+	value, _ := s.get(string(k))
+	s.mutex.Unlock()
+	return f(value)
+}
+
+// get returns the value of k as seen by s.
+//
+// The caller must hold s.mutex.
+func (s *txn) get(k string) ([]byte, bool) {
+	if s.update {
+		if x := s.writes.find(k); x != nil {
+			return x.vs[0].value, !x.vs[0].deleted
+		}
+		s.reads[k] = struct{}{}
+	}
+	s.db.mutex.Lock()
+	defer s.db.mutex.Unlock()
+	var v version
+	ok := false
+	if x := s.db.versions.find(k); x != nil {
+		v, ok = at(x.vs, s.readTs)
+	}
+	return v.value, ok
 }
 
 func (s *txn) Set(k, v []byte) error {
+	return s.write(kv.Change{
+		Key:   append([]byte(nil), k...),
+		Value: append([]byte{}, v...),
+	})
+}
+
+func (s *txn) Delete(k []byte) error {
+	return s.write(kv.Change{Key: append([]byte(nil), k...)})
+}
+
+// write buffers c, where a nil c.Value represents a deletion.
+func (s *txn) write(c kv.Change) error {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
-	s.m[string(k)] = v
+	if s.done {
+		return ErrDiscardedTxn
+	}
+	if !s.update {
+		return ErrReadOnlyTxn
+	}
+	x := s.writes.insert(string(c.Key))
+	x.vs = []version{{value: c.Value, deleted: c.Value == nil}}
+	s.changes = append(s.changes, c)
 	return nil
 }
 
 func (s *txn) PrefixIterator(prefix []byte) kv.Iterator {
-	var iter iterator
-	p := string(prefix)
-	for k, v := range s.m {
-		if strings.HasPrefix(k, p) {
-			iter.pairs = append(iter.pairs, pair{
-				key:   k[len(p):],
-				value: v,
-			})
+	return s.prefixIterator(prefix, false)
+}
+
+func (s *txn) ReversePrefixIterator(prefix []byte) kv.Iterator {
+	return s.prefixIterator(prefix, true)
+}
+
+func (s *txn) prefixIterator(prefix []byte, reverse bool) kv.Iterator {
+	return &iterator{txn: s, prefix: string(prefix), reverse: reverse}
+}
+
+func (s *txn) Discard() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.done {
+		return
+	}
+	s.done = true
+	s.db.mutex.Lock()
+	s.db.release(s.readTs)
+	s.db.mutex.Unlock()
+}
+
+func (s *txn) Commit() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.done {
+		return ErrDiscardedTxn
+	}
+	s.done = true
+	d := s.db
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.release(s.readTs)
+	if len(s.changes) == 0 {
+		return nil
+	}
+	for k := range s.reads {
+		if x := d.versions.find(k); x != nil && x.vs[len(x.vs)-1].ts > s.readTs {
+			return ErrConflict
 		}
 	}
-	sort.Slice(
-		iter.pairs,
-		func(a, b int) bool { return iter.pairs[a].key < iter.pairs[b].key })
-	return &iter
+	d.clock++
+	oldest := d.oldest()
+	for w := s.writes.head.next[0]; w != nil; w = w.next[0] {
+		x := d.versions.insert(w.key)
+		v := w.vs[0]
+		v.ts = d.clock
+		x.vs = append(x.vs, v)
+		d.prune(x, oldest)
+	}
+	d.hub.Publish(s.changes)
+	return nil
 }
 
-func (s *txn) Discard()      {}
-func (s *txn) Commit() error { return nil }
+// iterator merges the versions committed before its transaction began with
+// the transaction's own buffered writes, moving through both skiplists lazily.
+type iterator struct {
+	txn     *txn
+	prefix  string
+	reverse bool
+
+	// dn and wn are the current nodes of the committed and buffered
+	// skiplists, and are nil once exhausted.
+	dn, wn *node
 
-type pair struct {
 	key   string
 	value []byte
+	valid bool
 }
 
-type iterator struct {
-	pairs []pair
-	i     int
+func (i *iterator) Seek(key []byte) {
+	s := i.txn
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.done {
+		i.valid = false
+		return
+	}
+	s.db.mutex.Lock()
+	defer s.db.mutex.Unlock()
+	target := i.prefix + string(key)
+	i.dn, i.wn = nil, nil
+	if i.reverse {
+		// Find the last key less than or equal to target, or the last key
+		// with the prefix if key is empty.
+		limit := target + "\x00"
+		if len(key) == 0 {
+			limit = successor(i.prefix)
+		}
+		i.dn = lastBefore(s.db.versions, limit)
+		if s.writes != nil {
+			i.wn = lastBefore(s.writes, limit)
+		}
+	} else {
+		i.dn = s.db.versions.seek(target)
+		if s.writes != nil {
+			i.wn = s.writes.seek(target)
+		}
+	}
+	i.settle()
 }
 
-func (i *iterator) Seek(key []byte) {
-	k := string(key)
-	i.i = 0
-	for i.i = 0; i.i < len(i.pairs) && i.pairs[i.i].key < k; i.i++ {
+func (i *iterator) Next() {
+	s := i.txn
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if !i.valid || s.done {
+		i.valid = false
+		return
 	}
+	s.db.mutex.Lock()
+	defer s.db.mutex.Unlock()
+	i.advance(i.key)
+	i.settle()
 }
 
-func (i *iterator) Next() { i.i++ }
+// advance moves past key in both skiplists.
+//
+// The caller must hold the mutexes of i.txn and its db.
+func (i *iterator) advance(key string) {
+	if i.dn != nil && i.dn.key == key {
+		i.dn = i.step(i.txn.db.versions, i.dn)
+	}
+	if i.wn != nil && i.wn.key == key {
+		i.wn = i.step(i.txn.writes, i.wn)
+	}
+}
 
-func (i *iterator) Valid() bool {
-	return 0 <= i.i && i.i < len(i.pairs)
+func (i *iterator) step(l *skiplist, x *node) *node {
+	if i.reverse {
+		return l.before(x.key)
+	}
+	return x.next[0]
 }
 
-func (i *iterator) Key() []byte { return []byte(i.pairs[i.i].key) }
+// settle moves the iterator to the first visible key at or after the current
+// nodes, in the direction of iteration.
+//
+// The caller must hold the mutexes of i.txn and its db.
+func (i *iterator) settle() {
+	for {
+		if i.dn != nil && !strings.HasPrefix(i.dn.key, i.prefix) {
+			i.dn = nil
+		}
+		if i.wn != nil && !strings.HasPrefix(i.wn.key, i.prefix) {
+			i.wn = nil
+		}
+		if i.dn == nil && i.wn == nil {
+			i.valid = false
+			return
+		}
+		var key string
+		switch {
+		case i.dn == nil:
+			key = i.wn.key
+		case i.wn == nil:
+			key = i.dn.key
+		case (i.wn.key < i.dn.key) != i.reverse:
+			key = i.wn.key
+		default:
+			key = i.dn.key
+		}
+		var (
+			v  version
+			ok bool
+		)
+		if i.wn != nil && i.wn.key == key {
+			v, ok = i.wn.vs[0], !i.wn.vs[0].deleted
+		} else {
+			v, ok = at(i.dn.vs, i.txn.readTs)
+		}
+		if ok {
+			i.key, i.value, i.valid = key, v.value, true
+			return
+		}
+		i.advance(key)
+	}
+}
+
+// lastBefore returns the last node of l with a key less than limit, or the
+// last node of all if limit is empty, as successor returns when there is no
+// limit.
+func lastBefore(l *skiplist, limit string) *node {
+	if limit == "" {
+		return l.last()
+	}
+	return l.before(limit)
+}
+
+// successor returns the least string greater than all strings with the given
+// prefix, or the empty string if there is no such string.
+func successor(prefix string) string {
+	bs := []byte(prefix)
+	for n := len(bs) - 1; n >= 0; n-- {
+		if bs[n] != 0xff {
+			bs[n]++
+			return string(bs[:n+1])
+		}
+	}
+	return ""
+}
+
+func (i *iterator) Valid() bool { return i.valid }
+
+func (i *iterator) Key() []byte {
+	i.read()
+	return []byte(i.key[len(i.prefix):])
+}
 
 func (i *iterator) Value(f func([]byte) error) error {
-	return f(i.pairs[i.i].value)
+	i.read()
+	return f(i.value)
+}
+
+// read records that the current key has been read, for conflict detection.
+func (i *iterator) read() {
+	if i.txn.update {
+		i.txn.mutex.Lock()
+		i.txn.reads[i.key] = struct{}{}
+		i.txn.mutex.Unlock()
+	}
 }
 
 func (i *iterator) Discard() {}
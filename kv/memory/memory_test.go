@@ -15,6 +15,7 @@
 package memory
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"sync"
@@ -83,6 +84,31 @@ func TestSetGet(t *testing.T) {
 	}
 }
 
+func TestDelete(t *testing.T) {
+	txn := New()
+	key := kv.Entity(42).Encode()
+	if err := txn.Set(key, kv.String("value").Encode()); err != nil {
+		t.Fatal(err)
+	}
+	if err := txn.Delete(key); err != nil {
+		t.Fatal(err)
+	}
+	var got kv.String
+	if err := txn.Get(key, got.Decode); err != nil {
+		t.Error(err)
+	} else if got != "" {
+		t.Error("want empty value, got", got)
+	}
+	iter := txn.PrefixIterator(nil)
+	defer iter.Discard()
+	if iter.Seek(nil); iter.Valid() {
+		t.Errorf("want no keys, got %#v", iter.Key())
+	}
+	if err := txn.Delete(key); err != nil {
+		t.Error("want no error deleting a missing key, got", err)
+	}
+}
+
 func TestIterator(t *testing.T) {
 	txn := New()
 	for _, salutation := range []string{"hello", "good morning", "good afternoon"} {
@@ -119,3 +145,245 @@ func TestIterator(t *testing.T) {
 		t.Error("want", want, "got", got)
 	}
 }
+
+func TestReverseIterator(t *testing.T) {
+	txn := New()
+	for _, k := range []string{"a", "b1", "b2", "b3", "c"} {
+		if err := txn.Set([]byte(k), []byte(k)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, test := range []struct {
+		Seek string
+		Want []string
+	}{
+		{"", []string{"3", "2", "1"}},
+		{"2", []string{"2", "1"}},
+		{"25", []string{"2", "1"}},
+		{"0", nil},
+	} {
+		var got []string
+		iter := txn.ReversePrefixIterator([]byte("b"))
+		for iter.Seek([]byte(test.Seek)); iter.Valid(); iter.Next() {
+			got = append(got, string(iter.Key()))
+		}
+		iter.Discard()
+		if !reflect.DeepEqual(test.Want, got) {
+			t.Errorf("Seek(%#v): want %#v, got %#v", test.Seek, test.Want, got)
+		}
+	}
+}
+
+func TestReverseIteratorEmptyPrefix(t *testing.T) {
+	db := NewDB()
+	defer db.Close()
+	txn := db.NewTxn(true)
+	for _, k := range []string{"", "a", "c"} {
+		if err := txn.Set([]byte(k), []byte("committed")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := txn.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	txn = db.NewTxn(true)
+	defer txn.Discard()
+	if err := txn.Set([]byte("b"), []byte("written")); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"c", "b", "a", ""}
+	var got []string
+	iter := txn.ReversePrefixIterator(nil)
+	// Stop after more keys than there are, in case the iterator wraps around.
+	for iter.Seek(nil); iter.Valid() && len(got) <= len(want); iter.Next() {
+		got = append(got, string(iter.Key()))
+	}
+	iter.Discard()
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("want %#v, got %#v", want, got)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	d := NewDB()
+	defer d.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := make(chan []kv.Change)
+	done := make(chan error)
+	go func() {
+		done <- d.Watch(ctx, []byte("a/"), func(cs []kv.Change) error {
+			ch <- cs
+			return nil
+		})
+	}()
+	for d.(*db).hub.Len() == 0 {
+	}
+	txn := d.NewTxn(true)
+	if err := txn.Set([]byte("a/1"), []byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	if err := txn.Set([]byte("b/1"), []byte("y")); err != nil {
+		t.Fatal(err)
+	}
+	if err := txn.Delete([]byte("a/2")); err != nil {
+		t.Fatal(err)
+	}
+	if err := txn.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	discarded := d.NewTxn(true)
+	if err := discarded.Set([]byte("a/3"), []byte("z")); err != nil {
+		t.Fatal(err)
+	}
+	discarded.Discard()
+	want := []kv.Change{
+		{Key: []byte("a/1"), Value: []byte("x")},
+		{Key: []byte("a/2")},
+	}
+	if got := <-ch; !reflect.DeepEqual(want, got) {
+		t.Errorf("want %q, got %q", want, got)
+	}
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("want %v, got %v", context.Canceled, err)
+	}
+}
+
+func TestIsolation(t *testing.T) {
+	d := NewDB()
+	defer d.Close()
+	get := func(txn kv.Txn, key string) string {
+		var v []byte
+		if err := txn.Get([]byte(key), func(bs []byte) error {
+			v = bs
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		return string(v)
+	}
+
+	writer := d.NewTxn(true)
+	if err := writer.Set([]byte("key"), []byte("v1")); err != nil {
+		t.Fatal(err)
+	}
+	if got := get(writer, "key"); got != "v1" {
+		t.Errorf("want to read own write %q, got %q", "v1", got)
+	}
+	before := d.NewTxn(false)
+	defer before.Discard()
+	if err := writer.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if got := get(before, "key"); got != "" {
+		t.Errorf("want snapshot without %q, got %q", "key", got)
+	}
+	after := d.NewTxn(false)
+	defer after.Discard()
+	if got := get(after, "key"); got != "v1" {
+		t.Errorf("want %q, got %q", "v1", got)
+	}
+	if err := after.Set([]byte("key"), nil); err != ErrReadOnlyTxn {
+		t.Errorf("want %v, got %v", ErrReadOnlyTxn, err)
+	}
+
+	discarded := d.NewTxn(true)
+	if err := discarded.Delete([]byte("key")); err != nil {
+		t.Fatal(err)
+	}
+	discarded.Discard()
+	if err := discarded.Commit(); err != ErrDiscardedTxn {
+		t.Errorf("want %v, got %v", ErrDiscardedTxn, err)
+	}
+	latest := d.NewTxn(false)
+	defer latest.Discard()
+	if got := get(latest, "key"); got != "v1" {
+		t.Errorf("want discarded delete to have no effect, got %q", got)
+	}
+}
+
+func TestConflict(t *testing.T) {
+	d := NewDB()
+	defer d.Close()
+	a, b := d.NewTxn(true), d.NewTxn(true)
+	defer a.Discard()
+	defer b.Discard()
+	for _, txn := range []kv.TxnCommitDiscarder{a, b} {
+		iter := txn.PrefixIterator([]byte("counter"))
+		for iter.Seek(nil); iter.Valid(); iter.Next() {
+		}
+		iter.Discard()
+		if err := txn.Get([]byte("counter"), func([]byte) error { return nil }); err != nil {
+			t.Fatal(err)
+		}
+		if err := txn.Set([]byte("counter"), []byte{1}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := a.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Commit(); err != ErrConflict {
+		t.Errorf("want %v, got %v", ErrConflict, err)
+	}
+
+	// Blind writes do not conflict.
+	c, e := d.NewTxn(true), d.NewTxn(true)
+	for _, txn := range []kv.TxnCommitDiscarder{c, e} {
+		if err := txn.Set([]byte("counter"), []byte{2}); err != nil {
+			t.Fatal(err)
+		}
+		if err := txn.Commit(); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestIteratorMergesWrites(t *testing.T) {
+	d := NewDB()
+	defer d.Close()
+	txn := d.NewTxn(true)
+	for _, k := range []string{"p/a", "p/c", "p/e", "q/a"} {
+		if err := txn.Set([]byte(k), []byte("old")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := txn.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	txn = d.NewTxn(true)
+	defer txn.Discard()
+	txn.Set([]byte("p/b"), []byte("new"))
+	txn.Delete([]byte("p/c"))
+	txn.Set([]byte("p/e"), []byte("new"))
+	for _, test := range []struct {
+		Reverse bool
+		Seek    string
+		Want    []string
+	}{
+		{false, "", []string{"a=old", "b=new", "e=new"}},
+		{false, "c", []string{"e=new"}},
+		{true, "", []string{"e=new", "b=new", "a=old"}},
+		{true, "d", []string{"b=new", "a=old"}},
+	} {
+		var iter kv.Iterator
+		if test.Reverse {
+			iter = txn.ReversePrefixIterator([]byte("p/"))
+		} else {
+			iter = txn.PrefixIterator([]byte("p/"))
+		}
+		var got []string
+		for iter.Seek([]byte(test.Seek)); iter.Valid(); iter.Next() {
+			iter.Value(func(v []byte) error {
+				got = append(got, fmt.Sprintf("%s=%s", iter.Key(), v))
+				return nil
+			})
+		}
+		iter.Discard()
+		if !reflect.DeepEqual(test.Want, got) {
+			t.Errorf("reverse=%v seek=%q: want %q, got %q",
+				test.Reverse, test.Seek, test.Want, got)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import "math/rand"
+
+const (
+	maxLevel = 24
+
+	// Each node is promoted to the next level with probability 1/branching.
+	branching = 4
+)
+
+// skiplist is an ordered map from keys to versions of their values.
+//
+// A skiplist is not safe for concurrent use. Removing a node leaves its own
+// links intact, so that an iterator positioned at a removed node can still
+// move forward.
+type skiplist struct {
+	head   node
+	level  int
+	length int
+	rand   *rand.Rand
+}
+
+type node struct {
+	key  string
+	vs   []version
+	next []*node
+}
+
+func newSkiplist() *skiplist {
+	return &skiplist{
+		head:  node{next: make([]*node, maxLevel)},
+		level: 1,
+		rand:  rand.New(rand.NewSource(1)),
+	}
+}
+
+func (l *skiplist) randomLevel() int {
+	level := 1
+	for level < maxLevel && l.rand.Intn(branching) == 0 {
+		level++
+	}
+	return level
+}
+
+// path fills update with the last node before key at each level, and returns
+// the first node with a key greater than or equal to key.
+func (l *skiplist) path(key string, update []*node) *node {
+	x := &l.head
+	for i := l.level - 1; i >= 0; i-- {
+		for x.next[i] != nil && x.next[i].key < key {
+			x = x.next[i]
+		}
+		if update != nil {
+			update[i] = x
+		}
+	}
+	return x.next[0]
+}
+
+// find returns the node with the given key, or nil if there is none.
+func (l *skiplist) find(key string) *node {
+	if x := l.path(key, nil); x != nil && x.key == key {
+		return x
+	}
+	return nil
+}
+
+// insert returns the node with the given key, creating it if necessary.
+func (l *skiplist) insert(key string) *node {
+	var update [maxLevel]*node
+	if x := l.path(key, update[:]); x != nil && x.key == key {
+		return x
+	}
+	level := l.randomLevel()
+	for ; l.level < level; l.level++ {
+		update[l.level] = &l.head
+	}
+	x := &node{key: key, next: make([]*node, level)}
+	for i := 0; i < level; i++ {
+		x.next[i] = update[i].next[i]
+		update[i].next[i] = x
+	}
+	l.length++
+	return x
+}
+
+// remove removes the node with the given key, if there is one.
+func (l *skiplist) remove(key string) {
+	var update [maxLevel]*node
+	x := l.path(key, update[:])
+	if x == nil || x.key != key {
+		return
+	}
+	for i := range x.next {
+		update[i].next[i] = x.next[i]
+	}
+	for l.level > 1 && l.head.next[l.level-1] == nil {
+		l.level--
+	}
+	l.length--
+}
+
+// seek returns the first node with a key greater than or equal to key.
+func (l *skiplist) seek(key string) *node { return l.path(key, nil) }
+
+// before returns the last node with a key less than key, or nil if there is
+// no such node.
+func (l *skiplist) before(key string) *node {
+	x := &l.head
+	for i := l.level - 1; i >= 0; i-- {
+		for x.next[i] != nil && x.next[i].key < key {
+			x = x.next[i]
+		}
+	}
+	if x == &l.head {
+		return nil
+	}
+	return x
+}
+
+// last returns the last node of all, or nil if l is empty.
+func (l *skiplist) last() *node {
+	x := &l.head
+	for i := l.level - 1; i >= 0; i-- {
+		for x.next[i] != nil {
+			x = x.next[i]
+		}
+	}
+	if x == &l.head {
+		return nil
+	}
+	return x
+}
@@ -0,0 +1,181 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package migrate tracks the version of the schema used to write each
+// partition of a kv database, and upgrades partitions through an ordered
+// series of migrations.
+//
+// A package that defines a schema through kvschema would typically define a
+// Registry, register a migration each time its storage format changes, and
+// run the migrations whenever a database is opened:
+//
+//   var Migrations migrate.Registry
+//
+//   func init() {
+//     Migrations.Register(1, "initial schema", func(kv.Partitioned) error {
+//       return nil
+//     })
+//   }
+//
+// The version of each partition is stored under a key made of the partition
+// followed by the zero Component, which is never used for component values.
+package migrate
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/google/note-maps/kv"
+)
+
+// Migration upgrades the data in a single partition from the previous version
+// of a schema to Version.
+type Migration struct {
+	Version     int
+	Description string
+	Func        func(kv.Partitioned) error
+}
+
+// Registry holds an ordered series of migrations.
+//
+// The zero value is an empty Registry ready to use.
+type Registry struct {
+	ms []Migration
+}
+
+// Register adds a migration to r.
+//
+// Migrations must be registered in order, starting at version 1 and
+// continuing without gaps. Register panics otherwise, since this is a
+// programming error.
+func (r *Registry) Register(version int, description string, f func(kv.Partitioned) error) {
+	if version != r.Latest()+1 {
+		panic(fmt.Sprintf("migrate: registering version %v after version %v", version, r.Latest()))
+	}
+	r.ms = append(r.ms, Migration{version, description, f})
+}
+
+// Latest returns the version of the last migration registered in r, or zero
+// if there are none.
+func (r *Registry) Latest() int { return len(r.ms) }
+
+// Migrations returns all the migrations registered in r, in order.
+func (r *Registry) Migrations() []Migration {
+	return append([]Migration(nil), r.ms...)
+}
+
+// VersionError indicates that a partition was written with a version of a
+// schema that is newer than the latest known version, perhaps by a newer
+// release of the same program.
+type VersionError struct {
+	Partition kv.Entity
+	Version   int
+	Latest    int
+}
+
+func (e VersionError) Error() string {
+	return fmt.Sprintf("migrate: partition %v has schema version %v, but the latest known version is %v",
+		e.Partition, e.Version, e.Latest)
+}
+
+// Version returns the schema version recorded for t.Partition, or zero if no
+// version has been recorded.
+func Version(t kv.Partitioned) (int, error) {
+	var v uint64
+	err := t.Get(versionKey(t.Partition), func(bs []byte) error {
+		if len(bs) == 8 {
+			v = binary.BigEndian.Uint64(bs)
+		} else if len(bs) != 0 {
+			return fmt.Errorf("migrate: invalid schema version %x", bs)
+		}
+		return nil
+	})
+	return int(v), err
+}
+
+// SetVersion records v as the schema version of t.Partition.
+func SetVersion(t kv.Partitioned, v int) error {
+	var bs [8]byte
+	binary.BigEndian.PutUint64(bs[:], uint64(v))
+	return t.Set(versionKey(t.Partition), bs[:])
+}
+
+func versionKey(p kv.Entity) []byte {
+	key := make(kv.Prefix, 8+2)
+	p.EncodeAt(key)
+	kv.Component(0).EncodeAt(key[8:])
+	return key
+}
+
+// Stamp records the latest version in r as the schema version of
+// t.Partition.
+//
+// Stamp should be used when creating a new partition, so that migrations
+// meant for data written by older versions will not be applied to it.
+func (r *Registry) Stamp(t kv.Partitioned) error {
+	return SetVersion(t, r.Latest())
+}
+
+// Migrate applies each migration in r that is newer than the schema version
+// of t.Partition, in order, and records the resulting version.
+//
+// If the partition already has a version newer than any in r, Migrate returns
+// a VersionError.
+func (r *Registry) Migrate(t kv.Partitioned) error {
+	v, err := Version(t)
+	if err != nil {
+		return err
+	}
+	if v > r.Latest() {
+		return VersionError{t.Partition, v, r.Latest()}
+	}
+	if v == r.Latest() {
+		return nil
+	}
+	for _, m := range r.ms[v:] {
+		if err = m.Func(t); err != nil {
+			return fmt.Errorf("migrate: partition %v to version %v (%v): %v",
+				t.Partition, m.Version, m.Description, err)
+		}
+	}
+	return r.Stamp(t)
+}
+
+// MigrateDB applies Migrate to each partition returned by partitions, each
+// through its own transaction.
+//
+// The partitions function is called with a read-only transaction.
+func (r *Registry) MigrateDB(db kv.DB, partitions func(kv.Txn) ([]kv.Entity, error)) error {
+	txn := db.NewTxn(false)
+	ps, err := partitions(txn)
+	txn.Discard()
+	if err != nil {
+		return err
+	}
+	for _, p := range ps {
+		if err = r.migratePartition(db, p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Registry) migratePartition(db kv.DB, p kv.Entity) error {
+	txn := db.NewTxn(true)
+	defer txn.Discard()
+	if err := r.Migrate(kv.Partitioned{Txn: txn, Partition: p}); err != nil {
+		return err
+	}
+	return txn.Commit()
+}
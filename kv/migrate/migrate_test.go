@@ -0,0 +1,124 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrate
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/google/note-maps/kv"
+	"github.com/google/note-maps/kv/memory"
+)
+
+func TestMigrateDB(t *testing.T) {
+	var (
+		r    Registry
+		runs []string
+	)
+	record := func(s string) func(kv.Partitioned) error {
+		return func(t kv.Partitioned) error {
+			runs = append(runs, s+" "+string('0'+byte(t.Partition)))
+			return nil
+		}
+	}
+	r.Register(1, "first", record("first"))
+	r.Register(2, "second", record("second"))
+
+	db := memory.NewDB()
+	defer db.Close()
+	txn := db.NewTxn(true)
+	if err := SetVersion(kv.Partitioned{Txn: txn, Partition: 2}, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Stamp(kv.Partitioned{Txn: txn, Partition: 3}); err != nil {
+		t.Fatal(err)
+	}
+	if err := txn.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	partitions := func(kv.Txn) ([]kv.Entity, error) { return []kv.Entity{1, 2, 3}, nil }
+	if err := r.MigrateDB(db, partitions); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"first 1", "second 1", "second 2"}
+	if !reflect.DeepEqual(want, runs) {
+		t.Errorf("want %q, got %q", want, runs)
+	}
+
+	txn = db.NewTxn(false)
+	defer txn.Discard()
+	for _, p := range []kv.Entity{1, 2, 3} {
+		if v, err := Version(kv.Partitioned{Txn: txn, Partition: p}); err != nil {
+			t.Error(err)
+		} else if v != 2 {
+			t.Errorf("partition %v: want version 2, got %v", p, v)
+		}
+	}
+
+	// Running again should have no effect.
+	runs = nil
+	if err := r.MigrateDB(db, partitions); err != nil {
+		t.Fatal(err)
+	} else if len(runs) != 0 {
+		t.Errorf("want no migrations, got %q", runs)
+	}
+}
+
+func TestMigrateError(t *testing.T) {
+	var r Registry
+	failure := errors.New("failure")
+	r.Register(1, "ok", func(t kv.Partitioned) error {
+		return t.Set([]byte("migrated"), []byte("yes"))
+	})
+	r.Register(2, "fails", func(kv.Partitioned) error { return failure })
+	db := memory.NewDB()
+	defer db.Close()
+	partitions := func(kv.Txn) ([]kv.Entity, error) { return []kv.Entity{0}, nil }
+	if err := r.MigrateDB(db, partitions); err == nil {
+		t.Error("want error, got nil")
+	}
+	txn := db.NewTxn(false)
+	defer txn.Discard()
+	if v, err := Version(kv.Partitioned{Txn: txn}); err != nil {
+		t.Error(err)
+	} else if v != 0 {
+		t.Errorf("want version 0 after failure, got %v", v)
+	}
+}
+
+func TestMigrateFutureVersion(t *testing.T) {
+	var r Registry
+	r.Register(1, "only", func(kv.Partitioned) error { return nil })
+	txn := kv.Partitioned{Txn: memory.New(), Partition: 5}
+	if err := SetVersion(txn, 2); err != nil {
+		t.Fatal(err)
+	}
+	want := VersionError{Partition: 5, Version: 2, Latest: 1}
+	if err := r.Migrate(txn); err != want {
+		t.Errorf("want %v, got %v", want, err)
+	}
+}
+
+func TestRegisterOutOfOrder(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("want panic, got none")
+		}
+	}()
+	var r Registry
+	r.Register(2, "skipped 1", func(kv.Partitioned) error { return nil })
+}
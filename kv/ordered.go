@@ -0,0 +1,195 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"time"
+)
+
+// ErrInvalidEncoding is returned when decoding a value from a slice of bytes
+// that is not the encoding of any value of its type.
+var ErrInvalidEncoding = errors.New("kv: invalid encoded value")
+
+// The types below implement the Encoder and Decoder interfaces such that the
+// encoded values sort, byte by byte, in the same order as the values
+// themselves. They can be used as component values, as index values, and as
+// the fields of tuples for composite indexes, where range scans over them
+// visit entities in numeric or chronological order.
+//
+// Decoding an empty slice of bytes produces the zero value, as for a
+// component that has not been set.
+
+// Int64 is an int64 that encodes into eight bytes in an order-preserving
+// way.
+type Int64 int64
+
+// Encode encodes i into a new slice of bytes.
+func (i Int64) Encode() []byte {
+	var bs [8]byte
+	binary.BigEndian.PutUint64(bs[:], uint64(i)^(1<<63))
+	return bs[:]
+}
+
+// Decode decodes src into i.
+func (i *Int64) Decode(src []byte) error {
+	if len(src) == 0 {
+		*i = 0
+		return nil
+	}
+	u, err := decodeUint64(src)
+	if err != nil {
+		return err
+	}
+	*i = Int64(u ^ (1 << 63))
+	return nil
+}
+
+// Uint64 is a uint64 that encodes into eight bytes in an order-preserving
+// way.
+type Uint64 uint64
+
+// Encode encodes u into a new slice of bytes.
+func (u Uint64) Encode() []byte {
+	var bs [8]byte
+	binary.BigEndian.PutUint64(bs[:], uint64(u))
+	return bs[:]
+}
+
+// Decode decodes src into u.
+func (u *Uint64) Decode(src []byte) error {
+	v, err := decodeUint64(src)
+	*u = Uint64(v)
+	return err
+}
+
+// Float64 is a float64 that encodes into eight bytes in an order-preserving
+// way.
+//
+// Negative zero sorts before positive zero, and NaN values sort before
+// negative infinity or after positive infinity depending on their sign bits.
+type Float64 float64
+
+// Encode encodes f into a new slice of bytes.
+func (f Float64) Encode() []byte {
+	bits := math.Float64bits(float64(f))
+	if bits&(1<<63) != 0 {
+		bits = ^bits
+	} else {
+		bits ^= 1 << 63
+	}
+	var bs [8]byte
+	binary.BigEndian.PutUint64(bs[:], bits)
+	return bs[:]
+}
+
+// Decode decodes src into f.
+func (f *Float64) Decode(src []byte) error {
+	if len(src) == 0 {
+		*f = 0
+		return nil
+	}
+	bits, err := decodeUint64(src)
+	if err != nil {
+		return err
+	}
+	if bits&(1<<63) != 0 {
+		bits ^= 1 << 63
+	} else {
+		bits = ^bits
+	}
+	*f = Float64(math.Float64frombits(bits))
+	return nil
+}
+
+// Bool is a bool that encodes into one byte, such that false sorts before
+// true.
+type Bool bool
+
+// Encode encodes b into a new slice of bytes.
+func (b Bool) Encode() []byte {
+	if b {
+		return []byte{1}
+	}
+	return []byte{0}
+}
+
+// Decode decodes src into b.
+func (b *Bool) Decode(src []byte) error {
+	switch {
+	case len(src) == 0:
+		*b = false
+	case len(src) != 1 || src[0] > 1:
+		return ErrInvalidEncoding
+	default:
+		*b = src[0] == 1
+	}
+	return nil
+}
+
+// Time is a time.Time that encodes into twelve bytes in an order-preserving
+// way: eight for the seconds since the Unix epoch, as for Int64, and four for
+// the nanoseconds within that second.
+//
+// The location and monotonic clock reading of a Time are not encoded, so
+// decoded values are in UTC and have no monotonic clock reading. Compare them
+// with Equal rather than ==.
+//
+// The zero Time encodes into twelve bytes like any other, so that it sorts
+// before later times, and decodes to exactly the zero Time. As for the other
+// encoders in this package, an empty slice of bytes also decodes to the zero
+// Time.
+type Time struct{ time.Time }
+
+// Encode encodes t into a new slice of bytes.
+func (t Time) Encode() []byte {
+	bs := make([]byte, 12)
+	binary.BigEndian.PutUint64(bs, uint64(t.Unix())^(1<<63))
+	binary.BigEndian.PutUint32(bs[8:], uint32(t.Nanosecond()))
+	return bs
+}
+
+// Decode decodes src into t.
+func (t *Time) Decode(src []byte) error {
+	switch len(src) {
+	case 0:
+		t.Time = time.Time{}
+	case 12:
+		sec := int64(binary.BigEndian.Uint64(src) ^ (1 << 63))
+		nsec := int64(binary.BigEndian.Uint32(src[8:]))
+		if nsec >= int64(time.Second) {
+			return ErrInvalidEncoding
+		}
+		if t.Time = time.Unix(sec, nsec).UTC(); t.IsZero() {
+			t.Time = time.Time{}
+		}
+	default:
+		return ErrInvalidEncoding
+	}
+	return nil
+}
+
+func decodeUint64(src []byte) (uint64, error) {
+	switch len(src) {
+	case 0:
+		return 0, nil
+	case 8:
+		return binary.BigEndian.Uint64(src), nil
+	default:
+		return 0, ErrInvalidEncoding
+	}
+}
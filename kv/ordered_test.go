@@ -0,0 +1,127 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv_test
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/note-maps/kv"
+)
+
+type encoderDecoder interface {
+	kv.Encoder
+	kv.Decoder
+}
+
+func TestOrderedEncoders(t *testing.T) {
+	t0 := time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC)
+	for _, test := range []struct {
+		Name string
+		// Values are in ascending order.
+		Values []kv.Encoder
+		// New returns a pointer to a zero value of the same type.
+		New func() encoderDecoder
+	}{
+		{
+			"Int64",
+			[]kv.Encoder{kv.Int64(math.MinInt64), kv.Int64(-256), kv.Int64(-1), kv.Int64(0), kv.Int64(1), kv.Int64(255), kv.Int64(math.MaxInt64)},
+			func() encoderDecoder { return new(kv.Int64) },
+		},
+		{
+			"Uint64",
+			[]kv.Encoder{kv.Uint64(0), kv.Uint64(1), kv.Uint64(256), kv.Uint64(math.MaxUint64)},
+			func() encoderDecoder { return new(kv.Uint64) },
+		},
+		{
+			"Float64",
+			[]kv.Encoder{kv.Float64(math.Inf(-1)), kv.Float64(-math.MaxFloat64), kv.Float64(-1.5), kv.Float64(-math.SmallestNonzeroFloat64), kv.Float64(math.Copysign(0, -1)), kv.Float64(0), kv.Float64(math.SmallestNonzeroFloat64), kv.Float64(0.5), kv.Float64(2), kv.Float64(math.Inf(1))},
+			func() encoderDecoder { return new(kv.Float64) },
+		},
+		{
+			"Bool",
+			[]kv.Encoder{kv.Bool(false), kv.Bool(true)},
+			func() encoderDecoder { return new(kv.Bool) },
+		},
+		{
+			"Time",
+			[]kv.Encoder{kv.Time{}, kv.Time{Time: time.Unix(-1, 999999999).UTC()}, kv.Time{Time: time.Unix(0, 0).UTC()}, kv.Time{Time: t0}, kv.Time{Time: t0.Add(time.Nanosecond)}, kv.Time{Time: t0.Add(time.Second)}, kv.Time{Time: t0.AddDate(100, 0, 0)}},
+			func() encoderDecoder { return new(kv.Time) },
+		},
+	} {
+		for i, v := range test.Values {
+			bs := v.Encode()
+			if i > 0 {
+				if prev := test.Values[i-1].Encode(); bytes.Compare(prev, bs) >= 0 {
+					t.Errorf("%s: want %v before %v, got %x and %x", test.Name, test.Values[i-1], v, prev, bs)
+				}
+			}
+			decoded := test.New()
+			if err := decoded.Decode(bs); err != nil {
+				t.Errorf("%s: %v", test.Name, err)
+			} else if got := reflect.ValueOf(decoded).Elem().Interface(); !reflect.DeepEqual(v, got) {
+				t.Errorf("%s: want %v, got %v", test.Name, v, got)
+			}
+		}
+		zero := test.New()
+		if err := zero.Decode(nil); err != nil {
+			t.Errorf("%s: %v", test.Name, err)
+		} else if got := reflect.ValueOf(zero).Elem(); !reflect.DeepEqual(got.Interface(), reflect.Zero(got.Type()).Interface()) {
+			t.Errorf("%s: want zero value from empty slice, got %v", test.Name, got)
+		}
+		if err := test.New().Decode([]byte{1, 2, 3}); err != kv.ErrInvalidEncoding {
+			t.Errorf("%s: want %v, got %v", test.Name, kv.ErrInvalidEncoding, err)
+		}
+	}
+}
+
+func TestTimeRoundTrip(t *testing.T) {
+	for _, v := range []time.Time{
+		{},
+		time.Now(),
+		time.Date(2019, 6, 1, 12, 0, 0, 1, time.FixedZone("UTC+1", 60*60)),
+	} {
+		var got kv.Time
+		if err := got.Decode(kv.Time{Time: v}.Encode()); err != nil {
+			t.Error(err)
+		} else if !got.Equal(v) {
+			t.Errorf("want %v, got %v", v, got)
+		} else if v.IsZero() && got.Time != v {
+			t.Errorf("want exactly the zero time, got %#v", got.Time)
+		}
+	}
+}
+
+func TestOrderedTuple(t *testing.T) {
+	// Tuples of ordered values sort by their first field, then their second.
+	t0 := time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC)
+	tuples := [][]kv.Encoder{
+		{kv.Int64(-1), kv.Time{Time: t0.Add(time.Hour)}},
+		{kv.Int64(0), kv.Time{Time: t0}},
+		{kv.Int64(0), kv.Time{Time: t0.Add(time.Second)}},
+		{kv.Int64(1), kv.Time{}},
+		{kv.Int64(256), kv.Time{Time: t0}},
+	}
+	for i := 1; i < len(tuples); i++ {
+		a := kv.EncodeTuple(tuples[i-1]...)
+		b := kv.EncodeTuple(tuples[i]...)
+		if bytes.Compare(a, b) >= 0 {
+			t.Errorf("want %v before %v, got %x and %x", tuples[i-1], tuples[i], a, b)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import "errors"
+
+// ErrSamePartition is returned by CopyTo and MoveTo when the destination
+// partition is the source partition.
+var ErrSamePartition = errors.New("kv: source and destination partitions are the same")
+
+// partitionChunk is the number of key-value pairs that functions which write
+// while reading a partition read at a time, so that they never write while an
+// iterator is open.
+const partitionChunk = 1000
+
+// PartitionStats describes the contents of a partition.
+type PartitionStats struct {
+	// Keys is the number of keys in the partition.
+	Keys int64
+
+	// KeyBytes is the total length of the keys in the partition, including
+	// their partition prefixes.
+	KeyBytes int64
+
+	// ValueBytes is the total length of the values in the partition.
+	ValueBytes int64
+}
+
+// Stats counts the keys in s.Partition and measures their keys and values.
+func (s Partitioned) Stats() (PartitionStats, error) {
+	var stats PartitionStats
+	iter := s.PrefixIterator(s.Partition.Encode())
+	defer iter.Discard()
+	for iter.Seek(nil); iter.Valid(); iter.Next() {
+		stats.Keys++
+		stats.KeyBytes += int64(8 + len(iter.Key()))
+		if err := iter.Value(func(v []byte) error {
+			stats.ValueBytes += int64(len(v))
+			return nil
+		}); err != nil {
+			return stats, err
+		}
+	}
+	return stats, nil
+}
+
+// Clear deletes every key in s.Partition.
+//
+// If s.Txn is a *Batch, Clear may commit its deletions in several
+// transactions.
+func (s Partitioned) Clear() error {
+	return s.deleteAll(s.Partition.Encode())
+}
+
+// deleteAll deletes every key that begins with prefix.
+func (s Partitioned) deleteAll(prefix []byte) error {
+	return s.eachChunk(prefix, func(keys, values [][]byte) error {
+		for _, key := range keys {
+			if err := s.Delete(append(prefix[:len(prefix):len(prefix)], key...)); err != nil {
+				return err
+			} else if err = Checkpoint(s.Txn); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// CopyTo copies every key-value pair in s.Partition to partition dst,
+// replacing any pairs in dst with the same keys.
+//
+// If rewrite is not nil, each pair is passed to rewrite, which returns the
+// pair to store in dst instead. Keys passed to and returned by rewrite do not
+// include the partition prefix. Since the contents of a partition may refer
+// to the entity that identifies it, rewrite can replace such references with
+// dst. If rewrite returns a nil key, the pair is not copied.
+//
+// If s.Txn is a *Batch, CopyTo may commit its writes in several transactions.
+func (s Partitioned) CopyTo(dst Entity, rewrite func(key, value []byte) ([]byte, []byte, error)) error {
+	if dst == s.Partition {
+		return ErrSamePartition
+	}
+	prefix := dst.Encode()
+	return s.eachChunk(s.Partition.Encode(), func(keys, values [][]byte) error {
+		for i, key := range keys {
+			value := values[i]
+			if rewrite != nil {
+				var err error
+				if key, value, err = rewrite(key, value); err != nil {
+					return err
+				} else if key == nil {
+					continue
+				}
+			}
+			if err := s.Set(append(prefix[:8:8], key...), value); err != nil {
+				return err
+			} else if err = Checkpoint(s.Txn); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// MoveTo is like CopyTo, except that it also clears s.Partition.
+func (s Partitioned) MoveTo(dst Entity, rewrite func(key, value []byte) ([]byte, []byte, error)) error {
+	if err := s.CopyTo(dst, rewrite); err != nil {
+		return err
+	}
+	return s.Clear()
+}
+
+// eachChunk calls f with successive chunks of the key-value pairs with keys
+// that begin with prefix, in order of their keys, where keys do not include
+// prefix.
+//
+// No iterator is open while f runs, so f may write to s.Txn. However, f must
+// not write keys that begin with prefix and sort after the last key in its
+// chunk.
+func (s Partitioned) eachChunk(prefix []byte, f func(keys, values [][]byte) error) error {
+	var start []byte
+	for {
+		var keys, values [][]byte
+		iter := s.PrefixIterator(prefix)
+		for iter.Seek(start); iter.Valid() && len(keys) < partitionChunk; iter.Next() {
+			keys = append(keys, append([]byte(nil), iter.Key()...))
+			if err := iter.Value(func(v []byte) error {
+				values = append(values, append([]byte{}, v...))
+				return nil
+			}); err != nil {
+				iter.Discard()
+				return err
+			}
+		}
+		iter.Discard()
+		if len(keys) == 0 {
+			return nil
+		}
+		if err := f(keys, values); err != nil {
+			return err
+		}
+		if len(keys) < partitionChunk {
+			return nil
+		}
+		start = append(keys[len(keys)-1], 0)
+	}
+}
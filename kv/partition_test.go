@@ -0,0 +1,149 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/google/note-maps/kv"
+	"github.com/google/note-maps/kv/memory"
+)
+
+// fillPartition sets n keys in partition p, and one key in each neighbouring
+// partition.
+func fillPartition(t *testing.T, txn kv.Txn, p kv.Entity, n int) {
+	for _, e := range []kv.Entity{p - 1, p + 1} {
+		if err := txn.Set(kv.Prefix(e.Encode()).ConcatEntity(0), []byte("neighbour")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < n; i++ {
+		key := kv.Prefix(p.Encode()).ConcatEntity(kv.Entity(i))
+		if err := txn.Set(key, []byte(fmt.Sprint(i))); err != nil {
+			t.Fatal(err)
+		} else if err = kv.Checkpoint(txn); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestPartitionStats(t *testing.T) {
+	txn := memory.New()
+	defer txn.Discard()
+	fillPartition(t, txn, 10, 12)
+	stats, err := kv.Partitioned{Txn: txn, Partition: 10}.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := kv.PartitionStats{Keys: 12, KeyBytes: 12 * 16, ValueBytes: 10 + 2*2}
+	if stats != want {
+		t.Errorf("want %+v, got %+v", want, stats)
+	}
+}
+
+func TestPartitionClear(t *testing.T) {
+	db := &limitedDB{DB: memory.NewDB(), limit: 100}
+	defer db.Close()
+	b := kv.NewBatch(db)
+	defer b.Discard()
+	fillPartition(t, b, 10, 2500)
+	if err := b.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if err := (kv.Partitioned{Txn: b, Partition: 10}).Clear(); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got := countKeys(t, db); got != 2 {
+		t.Errorf("want only 2 neighbouring keys after Clear, got %v keys", got)
+	}
+}
+
+func TestPartitionCopyTo(t *testing.T) {
+	txn := memory.New()
+	defer txn.Discard()
+	fillPartition(t, txn, 10, 2500)
+	// Rewrite references to the source partition, and skip one key.
+	rewrite := func(key, value []byte) ([]byte, []byte, error) {
+		if string(value) == "7" {
+			return nil, nil, nil
+		}
+		return key, bytes.Replace(value, []byte("10"), []byte("ten"), 1), nil
+	}
+	if err := (kv.Partitioned{Txn: txn, Partition: 10}).CopyTo(10, nil); err != kv.ErrSamePartition {
+		t.Errorf("want %v, got %v", kv.ErrSamePartition, err)
+	}
+	if err := (kv.Partitioned{Txn: txn, Partition: 10}).CopyTo(20, rewrite); err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		E     kv.Entity
+		Key   kv.Entity
+		Value string
+	}{
+		{10, 7, "7"},
+		{10, 10, "10"},
+		{10, 2499, "2499"},
+		{20, 7, ""},
+		{20, 10, "ten"},
+		{20, 1010, "ten10"},
+		{20, 2499, "2499"},
+	} {
+		var got string
+		if err := txn.Get(kv.Prefix(test.E.Encode()).ConcatEntity(test.Key), func(v []byte) error {
+			got = string(v)
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		} else if got != test.Value {
+			t.Errorf("partition %v key %v: want %q, got %q", test.E, test.Key, test.Value, got)
+		}
+	}
+	src, err := kv.Partitioned{Txn: txn, Partition: 10}.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst, err := kv.Partitioned{Txn: txn, Partition: 20}.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if src.Keys != 2500 || dst.Keys != 2499 {
+		t.Errorf("want 2500 and 2499 keys, got %v and %v", src.Keys, dst.Keys)
+	}
+}
+
+func TestPartitionMoveTo(t *testing.T) {
+	txn := memory.New()
+	defer txn.Discard()
+	fillPartition(t, txn, 10, 3)
+	if err := (kv.Partitioned{Txn: txn, Partition: 10}).MoveTo(20, nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		E    kv.Entity
+		Keys int64
+	}{{10, 0}, {20, 3}} {
+		stats, err := kv.Partitioned{Txn: txn, Partition: test.E}.Stats()
+		if err != nil {
+			t.Fatal(err)
+		} else if stats.Keys != test.Keys {
+			t.Errorf("partition %v: want %v keys, got %v", test.E, test.Keys, stats.Keys)
+		}
+	}
+}
@@ -0,0 +1,416 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package query composes index lookups on a kv.Partitioned into queries.
+//
+// A query is built from predicates, each of which describes a set of entities
+// within a partition. Predicates are evaluated as streams of entities in
+// ascending order, and compound predicates merge the streams of their operands
+// rather than materializing intermediate results.
+//
+// Code generated by kvschema includes constructors for predicates that match
+// each component and index in a schema.
+package query
+
+import (
+	"errors"
+
+	"github.com/google/note-maps/kv"
+)
+
+// ErrUnboundedNot is reported by streams of predicates that would include an
+// unbounded set of entities, such as Not(p) when it is not combined with a
+// positive predicate through And.
+var ErrUnboundedNot = errors.New("query: Not must be combined with a positive predicate through And")
+
+// Predicate describes a set of entities within a partition.
+type Predicate interface {
+	// Stream returns a Stream over the entities in t that satisfy this
+	// predicate.
+	Stream(t kv.Partitioned) Stream
+}
+
+// Stream supports iteration over a set of entities in ascending order.
+//
+// The initial state of a Stream is not valid: use Seek() to move the stream to
+// a valid entity.
+type Stream interface {
+	// Streams must be discarded when no longer in use.
+	kv.Discarder
+
+	// Seek moves the stream to the first entity greater than or equal to e.
+	Seek(e kv.Entity)
+
+	// Next moves the stream to the next entity.
+	Next()
+
+	// Valid returns true if the stream is at a valid entity.
+	Valid() bool
+
+	// Entity returns the stream's current entity.
+	//
+	// May panic if Valid() returns false.
+	Entity() kv.Entity
+
+	// Err returns the first error encountered by the stream, if any.
+	//
+	// A stream that encounters an error is no longer valid.
+	Err() error
+}
+
+// Entities returns the first n entities in t that satisfy p, beginning with
+// the first entity greater than or equal to *start.
+//
+// A nil start value will be interpreted as a pointer to zero. If start is not
+// nil, it is updated such that using it in a subsequent call to Entities would
+// return the next n entities.
+//
+// A value of n less than or equal to zero will be interpretted as the largest
+// possible value.
+func Entities(t kv.Partitioned, p Predicate, start *kv.Entity, n int) (es []kv.Entity, err error) {
+	s := p.Stream(t)
+	defer s.Discard()
+	var actualStart kv.Entity
+	if start != nil {
+		actualStart = *start
+	}
+	for s.Seek(actualStart); s.Valid() && (n <= 0 || len(es) < n); s.Next() {
+		es = append(es, s.Entity())
+	}
+	if err = s.Err(); err != nil {
+		return nil, err
+	}
+	if start != nil && len(es) > 0 {
+		*start = es[len(es)-1] + 1
+	}
+	return
+}
+
+// Match returns a Predicate satisfied by entities with c values that have an
+// ix index value encoded as v.
+func Match(c, ix kv.Component, v []byte) Predicate {
+	return match{c, ix, v}
+}
+
+type match struct {
+	c, ix kv.Component
+	v     []byte
+}
+
+func (m match) Stream(t kv.Partitioned) Stream {
+	key := make(kv.Prefix, 8+2+8+2, 8+2+8+2+len(m.v))
+	t.Partition.EncodeAt(key)
+	m.c.EncodeAt(key[8:])
+	kv.Entity(0).EncodeAt(key[10:])
+	m.ix.EncodeAt(key[18:])
+	key = append(key, m.v...)
+	var s sliceStream
+	s.err = t.Get(key, s.es.Decode)
+	s.i = len(s.es)
+	return &s
+}
+
+// MatchKeyed is like Match for an index stored in kv.KeyedLayout.
+func MatchKeyed(c, ix kv.Component, v []byte) Predicate {
+	return matchKeyed{c, ix, v}
+}
+
+type matchKeyed match
+
+func (m matchKeyed) Stream(t kv.Partitioned) Stream {
+	key := make(kv.Prefix, 8+2+8+2, 8+2+8+2+len(m.v)+2)
+	t.Partition.EncodeAt(key)
+	m.c.EncodeAt(key[8:])
+	kv.Entity(0).EncodeAt(key[10:])
+	m.ix.EncodeAt(key[18:])
+	return hasStream{t.PrefixIterator(kv.AppendKeyedIndexValue(key, m.v))}
+}
+
+// sliceStream streams the entities of a sorted EntitySlice.
+type sliceStream struct {
+	es  kv.EntitySlice
+	i   int
+	err error
+}
+
+func (s *sliceStream) Seek(e kv.Entity)  { s.i = s.es.Search(e) }
+func (s *sliceStream) Next()             { s.i++ }
+func (s *sliceStream) Valid() bool       { return s.err == nil && s.i < len(s.es) }
+func (s *sliceStream) Entity() kv.Entity { return s.es[s.i] }
+func (s *sliceStream) Err() error        { return s.err }
+func (s *sliceStream) Discard()          {}
+
+// Has returns a Predicate satisfied by all entities that have c values.
+func Has(c kv.Component) Predicate { return has{c} }
+
+type has struct{ c kv.Component }
+
+func (h has) Stream(t kv.Partitioned) Stream {
+	prefix := make(kv.Prefix, 8+2)
+	t.Partition.EncodeAt(prefix)
+	h.c.EncodeAt(prefix[8:])
+	return hasStream{t.PrefixIterator(prefix)}
+}
+
+// hasStream streams the entities of keys visited by an iterator, such as
+// component keys or the keys of keyed index rows.
+type hasStream struct{ iter kv.Iterator }
+
+func (s hasStream) Seek(e kv.Entity) {
+	// Entity zero is reserved for index rows.
+	if e == 0 {
+		e = 1
+	}
+	s.iter.Seek(e.Encode())
+}
+
+func (s hasStream) Next()       { s.iter.Next() }
+func (s hasStream) Valid() bool { return s.iter.Valid() }
+func (s hasStream) Err() error  { return nil }
+func (s hasStream) Discard()    { s.iter.Discard() }
+
+func (s hasStream) Entity() kv.Entity {
+	var e kv.Entity
+	e.Decode(s.iter.Key())
+	return e
+}
+
+// Project returns a Predicate satisfied by the entities that f maps any of the
+// entities satisfying p to, such as the topics of the names that match a
+// value.
+//
+// Unlike other predicates, a projection reads every entity satisfying p before
+// its stream is first used, and then sorts the results of f and removes
+// duplicates. Entity zero, which is reserved for index rows, is left out.
+func Project(p Predicate, f func(t kv.Partitioned, e kv.Entity) ([]kv.Entity, error)) Predicate {
+	return project{p, f}
+}
+
+type project struct {
+	p Predicate
+	f func(kv.Partitioned, kv.Entity) ([]kv.Entity, error)
+}
+
+func (pr project) Stream(t kv.Partitioned) Stream {
+	var s sliceStream
+	sub := pr.p.Stream(t)
+	defer sub.Discard()
+	for sub.Seek(0); sub.Valid(); sub.Next() {
+		es, err := pr.f(t, sub.Entity())
+		if err != nil {
+			s.err = err
+			return &s
+		}
+		for _, e := range es {
+			if e != 0 {
+				s.es = append(s.es, e)
+			}
+		}
+	}
+	if s.err = sub.Err(); s.err != nil {
+		return &s
+	}
+	s.es.Sort()
+	n := 0
+	for i, e := range s.es {
+		if i == 0 || e != s.es[n-1] {
+			s.es[n], n = e, n+1
+		}
+	}
+	s.es = s.es[:n]
+	s.i = len(s.es)
+	return &s
+}
+
+// Not returns a Predicate satisfied by entities that do not satisfy p.
+//
+// Since the set of such entities is unbounded, Not may only be used as an
+// operand of And alongside at least one other predicate that is not the result
+// of Not. Otherwise, its stream will report ErrUnboundedNot.
+func Not(p Predicate) Predicate { return not{p} }
+
+type not struct{ p Predicate }
+
+func (n not) Stream(t kv.Partitioned) Stream { return errStream{ErrUnboundedNot} }
+
+// errStream is an invalid stream that reports an error.
+type errStream struct{ err error }
+
+func (s errStream) Seek(kv.Entity)    {}
+func (s errStream) Next()             {}
+func (s errStream) Valid() bool       { return false }
+func (s errStream) Entity() kv.Entity { panic("query: Entity called on invalid stream") }
+func (s errStream) Err() error        { return s.err }
+func (s errStream) Discard()          {}
+
+// And returns a Predicate satisfied by entities that satisfy all of ps.
+//
+// Operands that are the result of Not exclude entities from the result, and
+// at least one operand must not be the result of Not.
+func And(ps ...Predicate) Predicate { return and(ps) }
+
+type and []Predicate
+
+func (a and) Stream(t kv.Partitioned) Stream {
+	var s andStream
+	for _, p := range a {
+		if n, ok := p.(not); ok {
+			s.exclude = append(s.exclude, n.p.Stream(t))
+		} else {
+			s.include = append(s.include, p.Stream(t))
+		}
+	}
+	if len(s.include) == 0 {
+		s.Discard()
+		return errStream{ErrUnboundedNot}
+	}
+	return &s
+}
+
+// andStream intersects the streams in include by repeatedly seeking each of
+// them to the greatest entity found so far, and then skips entities that are
+// found in any of the streams in exclude.
+type andStream struct {
+	include, exclude []Stream
+	e                kv.Entity
+	valid            bool
+}
+
+func (s *andStream) Seek(e kv.Entity) {
+	s.valid = false
+	for {
+		found := true
+		for _, sub := range s.include {
+			sub.Seek(e)
+			if !sub.Valid() {
+				return
+			}
+			if sub.Entity() != e {
+				e = sub.Entity()
+				found = false
+				break
+			}
+		}
+		if !found {
+			continue
+		}
+		for _, sub := range s.exclude {
+			sub.Seek(e)
+			if sub.Err() != nil {
+				return
+			}
+			if sub.Valid() && sub.Entity() == e {
+				found = false
+				break
+			}
+		}
+		if found {
+			s.e, s.valid = e, true
+			return
+		}
+		if e+1 == 0 {
+			return
+		}
+		e++
+	}
+}
+
+func (s *andStream) Next() {
+	if !s.valid || s.e+1 == 0 {
+		s.valid = false
+		return
+	}
+	s.Seek(s.e + 1)
+}
+
+func (s *andStream) Valid() bool       { return s.valid && s.Err() == nil }
+func (s *andStream) Entity() kv.Entity { return s.e }
+func (s *andStream) Err() error        { return firstErr(s.include, s.exclude) }
+func (s *andStream) Discard()          { discardAll(s.include, s.exclude) }
+
+// Or returns a Predicate satisfied by entities that satisfy any of ps.
+//
+// None of ps may be the result of Not.
+func Or(ps ...Predicate) Predicate { return or(ps) }
+
+type or []Predicate
+
+func (o or) Stream(t kv.Partitioned) Stream {
+	var s orStream
+	for _, p := range o {
+		if _, ok := p.(not); ok {
+			s.Discard()
+			return errStream{ErrUnboundedNot}
+		}
+		s.subs = append(s.subs, p.Stream(t))
+	}
+	return &s
+}
+
+// orStream merges its sorted sub-streams, yielding each entity once.
+type orStream struct {
+	subs  []Stream
+	e     kv.Entity
+	valid bool
+}
+
+func (s *orStream) Seek(e kv.Entity) {
+	for _, sub := range s.subs {
+		sub.Seek(e)
+	}
+	s.update()
+}
+
+func (s *orStream) Next() {
+	for _, sub := range s.subs {
+		if sub.Valid() && sub.Entity() == s.e {
+			sub.Next()
+		}
+	}
+	s.update()
+}
+
+// update moves s to the least entity of any of its valid sub-streams.
+func (s *orStream) update() {
+	s.valid = false
+	for _, sub := range s.subs {
+		if sub.Valid() && (!s.valid || sub.Entity() < s.e) {
+			s.e, s.valid = sub.Entity(), true
+		}
+	}
+}
+
+func (s *orStream) Valid() bool       { return s.valid && s.Err() == nil }
+func (s *orStream) Entity() kv.Entity { return s.e }
+func (s *orStream) Err() error        { return firstErr(s.subs) }
+func (s *orStream) Discard()          { discardAll(s.subs) }
+
+func firstErr(sss ...[]Stream) error {
+	for _, ss := range sss {
+		for _, s := range ss {
+			if err := s.Err(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func discardAll(sss ...[]Stream) {
+	for _, ss := range sss {
+		for _, s := range ss {
+			s.Discard()
+		}
+	}
+}
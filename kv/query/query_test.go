@@ -0,0 +1,174 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package query
+
+import (
+	"testing"
+
+	"github.com/google/note-maps/kv"
+	"github.com/google/note-maps/kv/memory"
+)
+
+const (
+	colorPrefix kv.Component = 1
+	shapePrefix kv.Component = 2
+	valuePrefix kv.Component = 3
+)
+
+// setup stores component values and index rows directly, as kvschema
+// generated code would, for colors and shapes of a handful of entities.
+func setup(t *testing.T) kv.Partitioned {
+	p := kv.Partitioned{Txn: memory.New(), Partition: 7}
+	partition := kv.Prefix(p.Partition.Encode())
+	for _, row := range []struct {
+		c  kv.Component
+		v  string
+		es kv.EntitySlice
+	}{
+		{colorPrefix, "red", kv.EntitySlice{1, 3, 5, 7}},
+		{colorPrefix, "blue", kv.EntitySlice{2, 4, 6}},
+		{shapePrefix, "round", kv.EntitySlice{1, 2, 3, 4}},
+		{shapePrefix, "square", kv.EntitySlice{5, 6, 7, 8}},
+	} {
+		for _, e := range row.es {
+			key := partition.AppendComponent(row.c).ConcatEntity(e)
+			if err := p.Set(key, []byte(row.v)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		key := partition.AppendComponent(row.c).
+			ConcatEntityComponentBytes(0, valuePrefix, []byte(row.v))
+		if err := p.SetEntitySlice(key, row.es); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return p
+}
+
+func color(v string) Predicate { return Match(colorPrefix, valuePrefix, []byte(v)) }
+func shape(v string) Predicate { return Match(shapePrefix, valuePrefix, []byte(v)) }
+
+func TestEntities(t *testing.T) {
+	p := setup(t)
+	for _, test := range []struct {
+		Name string
+		P    Predicate
+		Want kv.EntitySlice
+		Err  error
+	}{
+		{"match", color("red"), kv.EntitySlice{1, 3, 5, 7}, nil},
+		{"match none", color("green"), nil, nil},
+		{"has", Has(shapePrefix), kv.EntitySlice{1, 2, 3, 4, 5, 6, 7, 8}, nil},
+		{"and", And(color("red"), shape("square")), kv.EntitySlice{5, 7}, nil},
+		{"and none", And(color("red"), color("blue")), nil, nil},
+		{"or", Or(color("blue"), shape("square")), kv.EntitySlice{2, 4, 5, 6, 7, 8}, nil},
+		{"and not", And(Has(shapePrefix), Not(color("red"))), kv.EntitySlice{2, 4, 6, 8}, nil},
+		{"nested", And(Or(color("red"), color("blue")), Not(shape("round"))), kv.EntitySlice{5, 6, 7}, nil},
+		{"not", Not(color("red")), nil, ErrUnboundedNot},
+		{"and only not", And(Not(color("red"))), nil, ErrUnboundedNot},
+		{"or not", Or(color("red"), Not(color("blue"))), nil, ErrUnboundedNot},
+	} {
+		t.Run(test.Name, func(t *testing.T) {
+			got, err := Entities(p, test.P, nil, 0)
+			if err != test.Err {
+				t.Fatalf("want error %v, got %v", test.Err, err)
+			} else if !test.Want.Equal(got) {
+				t.Errorf("want %v, got %v", test.Want, got)
+			}
+		})
+	}
+}
+
+func TestProject(t *testing.T) {
+	p := setup(t)
+	// Each entity refers to the entity twice its own, or to entity zero.
+	double := func(q Predicate) Predicate {
+		return Project(q, func(t kv.Partitioned, e kv.Entity) ([]kv.Entity, error) {
+			return []kv.Entity{e * 2 % 8, e * 2 % 8}, nil
+		})
+	}
+	for _, test := range []struct {
+		Name string
+		P    Predicate
+		Want kv.EntitySlice
+		Err  error
+	}{
+		{"project", double(color("blue")), kv.EntitySlice{4}, nil},
+		{"dedupe", double(color("red")), kv.EntitySlice{2, 6}, nil},
+		{"and", And(double(shape("round")), color("red")), nil, nil},
+		{"and projections", And(double(color("red")), double(shape("square"))), kv.EntitySlice{2, 6}, nil},
+		{"none", double(color("green")), nil, nil},
+		{"not", double(Not(color("red"))), nil, ErrUnboundedNot},
+	} {
+		t.Run(test.Name, func(t *testing.T) {
+			got, err := Entities(p, test.P, nil, 0)
+			if err != test.Err {
+				t.Fatalf("want error %v, got %v", test.Err, err)
+			} else if !test.Want.Equal(got) {
+				t.Errorf("want %v, got %v", test.Want, got)
+			}
+		})
+	}
+}
+
+func TestEntitiesPaging(t *testing.T) {
+	p := setup(t)
+	want := kv.EntitySlice{2, 4, 5, 6, 7, 8}
+	for n := 1; n <= len(want)+1; n++ {
+		var (
+			start kv.Entity
+			got   kv.EntitySlice
+		)
+		for {
+			es, err := Entities(p, Or(color("blue"), shape("square")), &start, n)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, es...)
+			if len(es) < n {
+				break
+			}
+		}
+		if !want.Equal(got) {
+			t.Errorf("n=%v: want %v, got %v", n, want, got)
+		}
+	}
+}
+
+func TestMatchKeyed(t *testing.T) {
+	p := setup(t)
+	if err := p.ChangeIndexLayout(colorPrefix, valuePrefix, kv.SliceLayout, kv.KeyedLayout); err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		Name string
+		P    Predicate
+		Want kv.EntitySlice
+	}{
+		{"match", MatchKeyed(colorPrefix, valuePrefix, []byte("red")), kv.EntitySlice{1, 3, 5, 7}},
+		{"match none", MatchKeyed(colorPrefix, valuePrefix, []byte("re")), nil},
+		{"and", And(MatchKeyed(colorPrefix, valuePrefix, []byte("blue")), shape("square")), kv.EntitySlice{6}},
+		{"has", Has(colorPrefix), kv.EntitySlice{1, 2, 3, 4, 5, 6, 7}},
+	} {
+		t.Run(test.Name, func(t *testing.T) {
+			got, err := Entities(p, test.P, nil, 0)
+			if err != nil {
+				t.Fatal(err)
+			} else if !test.Want.Equal(got) {
+				t.Errorf("want %v, got %v", test.Want, got)
+			}
+		})
+	}
+}
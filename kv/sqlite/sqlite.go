@@ -0,0 +1,381 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sqlite provides a SQLite-backed implementation of kv.DB.
+//
+// All key-value pairs are stored in a single table that other tools can read:
+//
+//     CREATE TABLE kv (key BLOB PRIMARY KEY, value BLOB) WITHOUT ROWID
+//
+// The state of the kv.Allocer is stored in the same table, under a key that
+// is a single byte with value 1.
+//
+// The database is opened with a cgo-free SQLite driver and in write-ahead
+// logging mode, so that read transactions do not block the update
+// transaction. Only one update transaction can be open at a time, so
+// NewTxn(true) blocks until any other update transaction is committed or
+// discarded.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/google/note-maps/kv"
+	"github.com/google/note-maps/kv/internal/watch"
+
+	_ "modernc.org/sqlite" // registers the "sqlite" driver
+)
+
+// driverName is the name of the database/sql driver used to open databases.
+const driverName = "sqlite"
+
+// pageSize is the number of rows fetched by each query made by an iterator.
+const pageSize = 64
+
+var (
+	// ErrReadOnlyTxn is returned by Set and Delete in a read-only
+	// transaction.
+	ErrReadOnlyTxn = errors.New("sqlite: no sets or deletes are allowed in a read-only transaction")
+
+	// ErrDiscardedTxn is returned when a transaction is used after it has
+	// been committed or discarded.
+	ErrDiscardedTxn = errors.New("sqlite: transaction has been discarded")
+)
+
+// DB holds some kv-specific state in addition to mixing in a sql.DB.
+type DB struct {
+	*sql.DB
+	a   *kv.Allocer
+	hub *watch.Hub
+
+	// updateMutex is held by the open update transaction, if there is one.
+	updateMutex sync.Mutex
+}
+
+// Open creates or opens the database in the file at path.
+func Open(path string) (*DB, error) {
+	sdb, err := sql.Open(driverName, path)
+	if err != nil {
+		return nil, err
+	}
+	for _, stmt := range []string{
+		"PRAGMA journal_mode=WAL",
+		"CREATE TABLE IF NOT EXISTS kv (key BLOB PRIMARY KEY, value BLOB) WITHOUT ROWID",
+	} {
+		if _, err = sdb.Exec(stmt); err != nil {
+			sdb.Close()
+			return nil, err
+		}
+	}
+	db := &DB{DB: sdb, hub: watch.NewHub()}
+	db.a = kv.NewAllocer(db, []byte{1})
+	return db, nil
+}
+
+// Reserve ensures that transactions will never allocate e or any smaller
+// Entity.
+func (db *DB) Reserve(e kv.Entity) error { return db.a.Reserve(e) }
+
+// Close releases unallocated Entity values and closes the database.
+func (db *DB) Close() error {
+	if db == nil {
+		return nil
+	}
+	db.hub.Close()
+	if db.a != nil {
+		db.a.Save()
+	}
+	return db.DB.Close()
+}
+
+// NewTxn creates a new kv.Txn.
+//
+// If the underlying SQL transaction cannot be started, every method of the
+// resulting kv.Txn will return the same error.
+func (db *DB) NewTxn(update bool) kv.TxnCommitDiscarder {
+	if update {
+		db.updateMutex.Lock()
+	}
+	t := &txn{db: db, update: update}
+	t.tx, t.err = db.DB.Begin()
+	if t.err != nil {
+		t.release()
+	}
+	return t
+}
+
+// Watch passes committed changes to keys matching prefix to f until ctx is
+// done or f returns an error.
+func (db *DB) Watch(ctx context.Context, prefix []byte, f func([]kv.Change) error) error {
+	return db.hub.Watch(ctx, prefix, f)
+}
+
+type txn struct {
+	db      *DB
+	tx      *sql.Tx
+	update  bool
+	done    bool
+	err     error
+	changes []kv.Change
+	mutex   sync.Mutex
+}
+
+// check returns the error, if any, that prevents s from being used.
+//
+// The caller must hold s.mutex.
+func (s *txn) check() error {
+	if s.err != nil {
+		return s.err
+	}
+	if s.done {
+		return ErrDiscardedTxn
+	}
+	return nil
+}
+
+// release marks s as done and allows the next update transaction to begin.
+//
+// The caller must hold s.mutex.
+func (s *txn) release() {
+	if s.done {
+		return
+	}
+	s.done = true
+	if s.update {
+		s.db.updateMutex.Unlock()
+	}
+}
+
+func (s *txn) Alloc() (kv.Entity, error) {
+	return s.db.a.Alloc()
+}
+
+func (s *txn) Set(key, value []byte) error {
+	return s.write(kv.Change{
+		Key:   append([]byte{}, key...),
+		Value: append([]byte{}, value...),
+	})
+}
+
+func (s *txn) Delete(key []byte) error {
+	return s.write(kv.Change{Key: append([]byte{}, key...)})
+}
+
+// write applies c, where a nil c.Value represents a deletion.
+func (s *txn) write(c kv.Change) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if err := s.check(); err != nil {
+		return err
+	}
+	if !s.update {
+		return ErrReadOnlyTxn
+	}
+	var err error
+	if c.Value == nil {
+		_, err = s.tx.Exec("DELETE FROM kv WHERE key = ?", c.Key)
+	} else {
+		_, err = s.tx.Exec("INSERT OR REPLACE INTO kv (key, value) VALUES (?, ?)", c.Key, c.Value)
+	}
+	if err != nil {
+		return err
+	}
+	s.changes = append(s.changes, c)
+	return nil
+}
+
+func (s *txn) Get(key []byte, f func([]byte) error) error {
+	s.mutex.Lock()
+	if err := s.check(); err != nil {
+		s.mutex.Unlock()
+		return err
+	}
+	var value []byte
+	err := s.tx.QueryRow("SELECT value FROM kv WHERE key = ?", append([]byte{}, key...)).Scan(&value)
+	s.mutex.Unlock()
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	return f(value)
+}
+
+func (s *txn) PrefixIterator(prefix []byte) kv.Iterator {
+	return s.iterator(prefix, false)
+}
+
+func (s *txn) ReversePrefixIterator(prefix []byte) kv.Iterator {
+	return s.iterator(prefix, true)
+}
+
+func (s *txn) iterator(prefix []byte, reverse bool) kv.Iterator {
+	p := append([]byte{}, prefix...)
+	return &iterator{txn: s, prefix: p, end: successor(p), reverse: reverse}
+}
+
+func (s *txn) Discard() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.done {
+		return
+	}
+	if s.tx != nil {
+		s.tx.Rollback()
+	}
+	s.release()
+}
+
+func (s *txn) Commit() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if err := s.check(); err != nil {
+		return err
+	}
+	defer s.release()
+	if !s.update {
+		return s.tx.Rollback()
+	}
+	if err := s.tx.Commit(); err != nil {
+		return err
+	}
+	// Publishing before release keeps changes in commit order.
+	if len(s.changes) > 0 {
+		s.db.hub.Publish(s.changes)
+	}
+	return nil
+}
+
+// iterator visits the key-value pairs with a prefix by querying a range of
+// keys one page at a time.
+//
+// If a query fails, the iterator becomes invalid and the error is returned by
+// every later use of its transaction.
+type iterator struct {
+	txn     *txn
+	prefix  []byte
+	end     []byte
+	reverse bool
+
+	// page holds the current page of results, and more reports whether
+	// there may be more results after it.
+	page []pair
+	i    int
+	more bool
+}
+
+type pair struct {
+	key, value []byte
+}
+
+func (i *iterator) Seek(key []byte) {
+	bound := append(append([]byte{}, i.prefix...), key...)
+	inclusive := true
+	if i.reverse && len(key) == 0 {
+		bound, inclusive = i.end, false
+	}
+	i.fetch(bound, inclusive)
+}
+
+func (i *iterator) Next() {
+	if !i.Valid() {
+		return
+	}
+	if i.i++; i.i == len(i.page) && i.more {
+		i.fetch(i.page[len(i.page)-1].key, false)
+	}
+}
+
+// fetch queries the next page of results starting from bound, which is the
+// least key in a forward iterator and the greatest in a reverse iterator.
+//
+// A nil bound means there is no greatest key in a reverse iterator.
+func (i *iterator) fetch(bound []byte, inclusive bool) {
+	i.page, i.i, i.more = i.page[:0], 0, false
+	op := ""
+	if inclusive {
+		op = "="
+	}
+	var (
+		where []string
+		args  []interface{}
+		order = "ASC"
+	)
+	if i.reverse {
+		order = "DESC"
+		where, args = append(where, "key >= ?"), append(args, i.prefix)
+		if bound != nil {
+			where, args = append(where, "key <"+op+" ?"), append(args, bound)
+		}
+	} else {
+		where, args = append(where, "key >"+op+" ?"), append(args, bound)
+		if i.end != nil {
+			where, args = append(where, "key < ?"), append(args, i.end)
+		}
+	}
+	args = append(args, pageSize)
+	query := "SELECT key, value FROM kv WHERE " + strings.Join(where, " AND ") +
+		" ORDER BY key " + order + " LIMIT ?"
+
+	s := i.txn
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.check() != nil {
+		return
+	}
+	rows, err := s.tx.Query(query, args...)
+	if err != nil {
+		s.err = err
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var p pair
+		if err = rows.Scan(&p.key, &p.value); err != nil {
+			break
+		}
+		i.page = append(i.page, p)
+	}
+	if err == nil {
+		err = rows.Err()
+	}
+	if err != nil {
+		i.page, s.err = i.page[:0], err
+		return
+	}
+	i.more = len(i.page) == pageSize
+}
+
+func (i *iterator) Valid() bool { return i.i < len(i.page) }
+
+func (i *iterator) Key() []byte { return i.page[i.i].key[len(i.prefix):] }
+
+func (i *iterator) Value(f func([]byte) error) error { return f(i.page[i.i].value) }
+
+func (i *iterator) Discard() {}
+
+// successor returns the least key greater than all keys with the given
+// prefix, or nil if there is no such key.
+func successor(prefix []byte) []byte {
+	for n := len(prefix) - 1; n >= 0; n-- {
+		if prefix[n] != 0xff {
+			s := append([]byte(nil), prefix[:n+1]...)
+			s[n]++
+			return s
+		}
+	}
+	return nil
+}
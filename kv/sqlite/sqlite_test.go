@@ -0,0 +1,205 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlite
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/note-maps/kv"
+)
+
+// Generic behaviour is covered by TestConformance; these tests cover what is
+// particular to SQLite.
+
+func open(t *testing.T) (*DB, string) {
+	dir, err := ioutil.TempDir("", "kv-sqlite-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "test.db")
+	db, err := Open(path)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return db, path
+}
+
+func TestReopen(t *testing.T) {
+	db, path := open(t)
+	defer os.RemoveAll(filepath.Dir(path))
+	txn := db.NewTxn(true)
+	if err := txn.Set([]byte("key"), []byte("value")); err != nil {
+		t.Fatal(err)
+	}
+	allocated := make(map[kv.Entity]bool)
+	for i := 0; i < 10; i++ {
+		e, err := txn.Alloc()
+		if err != nil {
+			t.Fatal(err)
+		}
+		allocated[e] = true
+	}
+	if err := txn.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	txn = db.NewTxn(false)
+	defer txn.Discard()
+	var got string
+	if err = txn.Get([]byte("key"), func(bs []byte) error {
+		got = string(bs)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	} else if got != "value" {
+		t.Errorf("want %#v, got %#v", "value", got)
+	}
+	if e, err := txn.Alloc(); err != nil {
+		t.Fatal(err)
+	} else if allocated[e] {
+		t.Errorf("allocated %v again after reopening", e)
+	}
+}
+
+func TestPaging(t *testing.T) {
+	db, path := open(t)
+	defer os.RemoveAll(filepath.Dir(path))
+	defer db.Close()
+	txn := db.NewTxn(true)
+	defer txn.Discard()
+	n := pageSize*2 + 1
+	for i := 0; i < n; i++ {
+		k := []byte(fmt.Sprintf("p%04d", i))
+		if err := txn.Set(k, k); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, reverse := range []bool{false, true} {
+		var iter kv.Iterator
+		if reverse {
+			iter = txn.ReversePrefixIterator([]byte("p"))
+		} else {
+			iter = txn.PrefixIterator([]byte("p"))
+		}
+		count := 0
+		for iter.Seek(nil); iter.Valid(); iter.Next() {
+			i := count
+			if reverse {
+				i = n - 1 - count
+			}
+			if want, got := fmt.Sprintf("%04d", i), string(iter.Key()); want != got {
+				t.Fatalf("reverse=%v: want key %#v, got %#v", reverse, want, got)
+			}
+			count++
+		}
+		iter.Discard()
+		if count != n {
+			t.Errorf("reverse=%v: want %v keys, got %v", reverse, n, count)
+		}
+	}
+}
+
+func TestTable(t *testing.T) {
+	db, path := open(t)
+	defer os.RemoveAll(filepath.Dir(path))
+	defer db.Close()
+	txn := db.NewTxn(true)
+	defer txn.Discard()
+	if err := txn.Set([]byte("key"), []byte("value")); err != nil {
+		t.Fatal(err)
+	}
+	if err := txn.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	var got []byte
+	if err := db.QueryRow("SELECT value FROM kv WHERE key = ?", []byte("key")).Scan(&got); err != nil {
+		t.Fatal(err)
+	} else if string(got) != "value" {
+		t.Errorf("want %#v, got %#v", "value", string(got))
+	}
+}
+
+func TestReadDuringUpdate(t *testing.T) {
+	db, path := open(t)
+	defer os.RemoveAll(filepath.Dir(path))
+	defer db.Close()
+	w := db.NewTxn(true)
+	defer w.Discard()
+	if err := w.Set([]byte("key"), []byte("value")); err != nil {
+		t.Fatal(err)
+	}
+	// In write-ahead logging mode, a reader neither waits for the open
+	// update transaction nor sees its writes.
+	done := make(chan string)
+	go func() {
+		r := db.NewTxn(false)
+		defer r.Discard()
+		var got string
+		if err := r.Get([]byte("key"), func(bs []byte) error {
+			got = string(bs)
+			return nil
+		}); err != nil {
+			got = err.Error()
+		}
+		done <- got
+	}()
+	select {
+	case got := <-done:
+		if got != "" {
+			t.Errorf("want no value before commit, got %#v", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("read transaction blocked by update transaction")
+	}
+	if err := w.Commit(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUpdateBlocks(t *testing.T) {
+	db, path := open(t)
+	defer os.RemoveAll(filepath.Dir(path))
+	defer db.Close()
+	first := db.NewTxn(true)
+	started := make(chan kv.TxnCommitDiscarder)
+	go func() { started <- db.NewTxn(true) }()
+	select {
+	case second := <-started:
+		second.Discard()
+		t.Fatal("want second update transaction to wait for the first")
+	case <-time.After(50 * time.Millisecond):
+	}
+	first.Discard()
+	select {
+	case second := <-started:
+		second.Discard()
+	case <-time.After(5 * time.Second):
+		t.Fatal("second update transaction still blocked after the first was discarded")
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trigram
+
+import (
+	"sort"
+
+	"github.com/google/note-maps/kv"
+)
+
+// Rows of a trigram index share the prefix of an index row for component c
+// and index ix, followed by one of these bytes:
+const (
+	// postingRow keys continue with a trigram, escaped and terminated by
+	// kv.AppendKeyedIndexValue, and an entity. Their values are empty.
+	postingRow byte = 1 + iota
+
+	// valuesRow keys continue with an entity. Their values hold the indexed
+	// values of the entity as a kv.StringSlice, so that Search can rank
+	// candidates without decoding their components.
+	valuesRow
+)
+
+// Result is an entity found by Search.
+type Result struct {
+	Entity kv.Entity
+
+	// Value is the indexed value of Entity that is most similar to the string
+	// passed to Search.
+	Value string
+
+	// Score is the similarity of Value to the string passed to Search, from
+	// zero to one.
+	Score float64
+}
+
+// Update updates the trigram index ix of component c in t so that e, which
+// used to have the values in old, has the values in new instead.
+func Update(t kv.Partitioned, c, ix kv.Component, e kv.Entity, old, new []kv.Fuzzy) error {
+	oldTrigrams := trigramSet(old)
+	newTrigrams := trigramSet(new)
+	prefix := indexPrefix(t, c, ix)
+	for tg := range oldTrigrams {
+		if !newTrigrams[tg] {
+			if err := t.Delete(postingKey(prefix, tg, e)); err != nil {
+				return err
+			}
+		}
+	}
+	for tg := range newTrigrams {
+		if !oldTrigrams[tg] {
+			if err := t.Set(postingKey(prefix, tg, e), []byte{}); err != nil {
+				return err
+			}
+		}
+	}
+	key := append(append(prefix, valuesRow), e.Encode()...)
+	if len(new) == 0 {
+		return t.Delete(key)
+	}
+	vs := make(kv.StringSlice, len(new))
+	for i, v := range new {
+		vs[i] = kv.String(v)
+	}
+	return t.Set(key, vs.Encode())
+}
+
+// Search returns up to n entities with values in the trigram index ix of
+// component c that are similar to s, with the most similar first.
+//
+// A value is similar to s if it shares at least Threshold of their trigrams
+// with s, or if a run of as many of its words as s has does, so that "Lenon"
+// is similar to "John Lennon". Entities with equal scores are returned in
+// ascending order.
+//
+// A value of n less than or equal to zero will be interpretted as the largest
+// possible value.
+func Search(t kv.Partitioned, c, ix kv.Component, s string, n int) ([]Result, error) {
+	trigrams := Trigrams(s)
+	if len(trigrams) == 0 {
+		return nil, nil
+	}
+	prefix := indexPrefix(t, c, ix)
+	shared := make(map[kv.Entity]int)
+	for _, tg := range trigrams {
+		iter := t.PrefixIterator(kv.AppendKeyedIndexValue(append(prefix, postingRow), []byte(tg)))
+		for iter.Seek(nil); iter.Valid(); iter.Next() {
+			var e kv.Entity
+			if err := e.Decode(iter.Key()); err != nil {
+				iter.Discard()
+				return nil, err
+			}
+			shared[e]++
+		}
+		iter.Discard()
+	}
+	var results []Result
+	for e, count := range shared {
+		// Even a value with only the shared trigrams cannot reach the
+		// threshold if too few of the trigrams of s are shared.
+		if float64(count)/float64(len(trigrams)) < Threshold {
+			continue
+		}
+		var vs kv.StringSlice
+		if err := t.Get(append(append(prefix, valuesRow), e.Encode()...), func(bs []byte) error {
+			if len(bs) == 0 {
+				return nil
+			}
+			return vs.Decode(bs)
+		}); err != nil {
+			return nil, err
+		}
+		best := Result{Entity: e}
+		for _, v := range vs {
+			if score := match(s, trigrams, string(v)); score > best.Score {
+				best.Value, best.Score = string(v), score
+			}
+		}
+		if best.Score > 0 {
+			results = append(results, best)
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Entity < results[j].Entity
+	})
+	if n > 0 && len(results) > n {
+		results = results[:n]
+	}
+	return results, nil
+}
+
+func trigramSet(vs []kv.Fuzzy) map[string]bool {
+	set := make(map[string]bool)
+	for _, v := range vs {
+		for _, tg := range Trigrams(string(v)) {
+			set[tg] = true
+		}
+	}
+	return set
+}
+
+func indexPrefix(t kv.Partitioned, c, ix kv.Component) kv.Prefix {
+	p := kv.Prefix(t.Partition.Encode()).AppendComponent(c).ConcatEntityComponent(0, ix)
+	return p[:len(p):len(p)]
+}
+
+func postingKey(prefix kv.Prefix, tg string, e kv.Entity) []byte {
+	key := kv.AppendKeyedIndexValue(append(prefix, postingRow), []byte(tg))
+	return append(key, e.Encode()...)
+}
@@ -0,0 +1,172 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package trigram maintains trigram indexes in a kv.Txn and uses them to find
+// values similar to a given string, such as names that have been misspelled.
+//
+// A trigram is a sequence of three characters. Similar strings share most of
+// their trigrams, so a trigram index stores, for each trigram, the entities
+// with values that include it. Search finds candidates that share trigrams
+// with a string, and then ranks them by their similarity to it.
+//
+// Code generated by kvschema uses this package for each index method that
+// returns a slice of kv.Fuzzy.
+package trigram
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/google/note-maps/kv/fulltext"
+)
+
+// Threshold is the least trigram similarity a value must have to a string for
+// Search to consider it similar.
+const Threshold = 0.3
+
+// Trigrams returns the distinct trigrams of s in ascending order.
+//
+// The trigrams of s are those of each word in the normalized form of s, where
+// each word is padded with two spaces before it and one after, so that short
+// words have trigrams and so that the start of a word counts for more than
+// its end.
+func Trigrams(s string) []string {
+	seen := make(map[string]bool)
+	for _, word := range words(s) {
+		rs := append([]rune("  "+word), ' ')
+		for i := 0; i+3 <= len(rs); i++ {
+			seen[string(rs[i:i+3])] = true
+		}
+	}
+	ts := make([]string, 0, len(seen))
+	for t := range seen {
+		ts = append(ts, t)
+	}
+	sort.Strings(ts)
+	return ts
+}
+
+// Similarity returns a measure of the similarity of a and b, from zero for
+// strings with no trigrams in common to one for strings with the same
+// normalized form.
+//
+// Similarity is the mean of the proportion of trigrams a and b share and of
+// one minus their edit distance relative to the length of the longer one.
+func Similarity(a, b string) float64 {
+	return (overlap(Trigrams(a), Trigrams(b)) + closeness(a, b)) / 2
+}
+
+// match returns the similarity of s to v, or zero if v is not similar to s.
+// The trigrams of s are given by trigrams.
+//
+// If v shares at least Threshold of their trigrams with s, then it matches
+// with their Similarity. Runs of as many words of v as s has may also match,
+// with the mean of their Similarity and that of v, so that matching a whole
+// value counts for more. The result is the greatest of these.
+func match(s string, trigrams []string, v string) float64 {
+	var (
+		best  float64
+		whole = Similarity(s, v)
+		ws    = words(v)
+		k     = len(words(s))
+	)
+	if overlap(trigrams, Trigrams(v)) >= Threshold {
+		best = whole
+	}
+	for i := 0; k < len(ws) && i+k <= len(ws); i++ {
+		span := strings.Join(ws[i:i+k], " ")
+		if overlap(trigrams, Trigrams(span)) < Threshold {
+			continue
+		}
+		if score := (Similarity(s, span) + whole) / 2; score > best {
+			best = score
+		}
+	}
+	return best
+}
+
+// overlap returns the number of trigrams in both a and b divided by the number
+// in either, where a and b are sorted.
+func overlap(a, b []string) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	shared := 0
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] < b[j]:
+			i++
+		case a[i] > b[j]:
+			j++
+		default:
+			shared++
+			i++
+			j++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
+
+// closeness returns one minus the Levenshtein distance between the normalized
+// forms of a and b divided by the length of the longer one.
+func closeness(a, b string) float64 {
+	ra := []rune(strings.Join(words(a), " "))
+	rb := []rune(strings.Join(words(b), " "))
+	longer := len(ra)
+	if len(rb) > longer {
+		longer = len(rb)
+	}
+	if longer == 0 {
+		return 1
+	}
+	return 1 - float64(distance(ra, rb))/float64(longer)
+}
+
+// distance returns the Levenshtein distance between a and b.
+func distance(a, b []rune) int {
+	row := make([]int, len(b)+1)
+	for j := range row {
+		row[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		prev := row[0]
+		row[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			next := min(row[j]+1, row[j-1]+1, prev+cost)
+			prev, row[j] = row[j], next
+		}
+	}
+	return row[len(b)]
+}
+
+func min(a int, bs ...int) int {
+	for _, b := range bs {
+		if b < a {
+			a = b
+		}
+	}
+	return a
+}
+
+// words returns the normalized words of s.
+func words(s string) []string {
+	return strings.FieldsFunc(fulltext.Normalize(s), func(r rune) bool {
+		return !unicode.In(r, unicode.Letter, unicode.Number, unicode.Mark)
+	})
+}
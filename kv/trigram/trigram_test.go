@@ -0,0 +1,105 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trigram
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/note-maps/kv"
+	"github.com/google/note-maps/kv/memory"
+)
+
+func TestTrigrams(t *testing.T) {
+	for _, test := range []struct {
+		S    string
+		Want []string
+	}{
+		{"", []string{}},
+		{"a", []string{"  a", " a "}},
+		{"Cat", []string{"  c", " ca", "at ", "cat"}},
+		{"Ab ab", []string{"  a", " ab", "ab "}},
+	} {
+		if got := Trigrams(test.S); !reflect.DeepEqual(test.Want, got) {
+			t.Errorf("%q: want %q, got %q", test.S, test.Want, got)
+		}
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	if got := Similarity("Lennon", "lennon"); got != 1 {
+		t.Errorf("want 1 for the same normalized form, got %v", got)
+	}
+	if got := Similarity("Lennon", "xyz"); got != 0 {
+		t.Errorf("want 0 for nothing in common, got %v", got)
+	}
+	// A typo is more similar than a different name.
+	if typo, other := Similarity("Lenon", "Lennon"), Similarity("Lenon", "Lemmon"); typo <= other {
+		t.Errorf("want %v > %v", typo, other)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	const c, ix kv.Component = 1, 2
+	txn := kv.Partitioned{Txn: memory.New(), Partition: 7}
+	values := map[kv.Entity][]kv.Fuzzy{
+		1: {"John Lennon"},
+		2: {"Paul McCartney"},
+		3: {"Jack Lemmon"},
+		4: {"Lennon", "Sean Ono Lennon"},
+	}
+	for e, vs := range values {
+		if err := Update(txn, c, ix, e, nil, vs); err != nil {
+			t.Fatal(err)
+		}
+	}
+	search := func(s string) []Result {
+		rs, err := Search(txn, c, ix, s, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return rs
+	}
+	rs := search("Lenon")
+	var got []kv.Entity
+	for _, r := range rs {
+		got = append(got, r.Entity)
+	}
+	if want := []kv.Entity{4, 1, 3}; !reflect.DeepEqual(want, got) {
+		t.Errorf("want %v, got %v", want, got)
+	} else if rs[0].Value != "Lennon" {
+		t.Errorf("want the most similar value %q, got %q", "Lennon", rs[0].Value)
+	}
+	if rs := search("McCartny"); len(rs) != 1 || rs[0].Entity != 2 {
+		t.Errorf("want only entity 2, got %v", rs)
+	}
+	if rs := search("Ringo"); len(rs) != 0 {
+		t.Errorf("want no results, got %v", rs)
+	}
+
+	// Changing and removing values updates the index.
+	if err := Update(txn, c, ix, 4, values[4], []kv.Fuzzy{"Ringo Starr"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Update(txn, c, ix, 1, values[1], nil); err != nil {
+		t.Fatal(err)
+	}
+	if rs := search("Lenon"); len(rs) != 1 || rs[0].Entity != 3 {
+		t.Errorf("after update: want only entity 3, got %v", rs)
+	}
+	if rs := search("Ringo"); len(rs) != 1 || rs[0].Entity != 4 {
+		t.Errorf("after update: want only entity 4, got %v", rs)
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+// EncodeTuple encodes the values of fields, in order, as a single index
+// value for a composite index.
+//
+// Each field is escaped and terminated as by AppendKeyedIndexValue, so that
+// encoded tuples sort by their first field, then by their second, and so on,
+// and so that the encoding of the leading fields of a tuple is a prefix of
+// the encoding of the whole tuple.
+func EncodeTuple(fields ...Encoder) []byte {
+	var dst []byte
+	for _, f := range fields {
+		dst = AppendKeyedIndexValue(dst, f.Encode())
+	}
+	return dst
+}
+
+// TupleEnd returns the least encoded tuple that sorts after every tuple
+// whose encoding begins with prefix, where prefix is the result of
+// EncodeTuple for the leading fields of those tuples.
+//
+// Together, prefix and TupleEnd(prefix) bound the range of index values that
+// share those leading fields.
+func TupleEnd(prefix []byte) []byte {
+	if len(prefix) == 0 {
+		return nil
+	}
+	end := append([]byte(nil), prefix...)
+	end[len(end)-1]++
+	return end
+}
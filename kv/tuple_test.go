@@ -0,0 +1,53 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/note-maps/kv"
+)
+
+func TestEncodeTupleOrder(t *testing.T) {
+	// tuples are in ascending order of their first field, then their second.
+	tuples := [][2]kv.String{
+		{"", ""},
+		{"", "a"},
+		{"a", ""},
+		{"a", "\x00"},
+		{"a", "b"},
+		{"a\x00", ""},
+		{"ab", ""},
+	}
+	for i := 1; i < len(tuples); i++ {
+		a := kv.EncodeTuple(tuples[i-1][0], tuples[i-1][1])
+		b := kv.EncodeTuple(tuples[i][0], tuples[i][1])
+		if bytes.Compare(a, b) >= 0 {
+			t.Errorf("want %q before %q, got %v and %v", tuples[i-1], tuples[i], a, b)
+		}
+	}
+	for _, tuple := range tuples {
+		lo := kv.EncodeTuple(tuple[0])
+		hi := kv.TupleEnd(lo)
+		for _, other := range tuples {
+			v := kv.EncodeTuple(other[0], other[1])
+			in := bytes.Compare(lo, v) <= 0 && bytes.Compare(v, hi) < 0
+			if want := other[0] == tuple[0]; in != want {
+				t.Errorf("%q in range for leading field %q: want %v, got %v", other, tuple[0], want, in)
+			}
+		}
+	}
+}
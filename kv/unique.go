@@ -0,0 +1,66 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import "fmt"
+
+// UniqueViolation is returned by generated Set methods when a value for a
+// unique index already belongs to another entity.
+//
+// An index is unique when its index method is named with the "IndexUnique"
+// prefix.
+type UniqueViolation struct {
+	// Component and Index identify the unique index.
+	Component, Index Component
+
+	// Value is the encoded index value.
+	Value []byte
+
+	// Entity is the entity that already has Value.
+	Entity Entity
+}
+
+func (e UniqueViolation) Error() string {
+	return fmt.Sprintf("kv: value %q of unique index %v of component %v already belongs to entity %v",
+		e.Value, e.Index, e.Component, e.Entity)
+}
+
+// CheckUnique returns a UniqueViolation if an entity other than e has v as an
+// encoded ix value of its c value, where ix is stored in layout.
+func (s Partitioned) CheckUnique(layout IndexLayout, c, ix Component, v []byte, e Entity) error {
+	var (
+		es  EntitySlice
+		err error
+	)
+	if layout == KeyedLayout {
+		es, err = s.EntitiesMatchingKeyedIndex(c, ix, v)
+	} else {
+		err = s.Get(append(s.indexPrefix(c, ix), v...), es.Decode)
+	}
+	if err != nil {
+		return err
+	}
+	for _, other := range es {
+		if other != e {
+			return UniqueViolation{
+				Component: c,
+				Index:     ix,
+				Value:     append([]byte(nil), v...),
+				Entity:    other,
+			}
+		}
+	}
+	return nil
+}
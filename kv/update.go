@@ -0,0 +1,97 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+)
+
+// ErrConflict is returned by Commit when the transaction read a key that
+// another transaction changed and committed first.
+//
+// Every DB implementation in this module returns ErrConflict itself, rather
+// than an error of its own, so that callers such as Update can recognize it.
+var ErrConflict = errors.New("kv: transaction conflict")
+
+const (
+	// MaxUpdateAttempts is the number of times Update runs a function
+	// before giving up on conflicts.
+	MaxUpdateAttempts = 10
+
+	minBackoff = time.Millisecond
+	maxBackoff = 100 * time.Millisecond
+)
+
+// Update runs f in a new update transaction and commits it.
+//
+// If f returns an error, the transaction is discarded and Update returns that
+// error. If Commit returns ErrConflict, Update waits for a short, growing and
+// randomized delay and then runs f again in a new transaction, up to
+// MaxUpdateAttempts times in all. Since f may run more than once, it should
+// not have effects outside of the transaction.
+//
+// Update returns ctx.Err() without committing if ctx is done before the
+// transaction can be committed.
+func Update(ctx context.Context, db DB, f func(Txn) error) error {
+	backoff := minBackoff
+	for attempt := 1; ; attempt++ {
+		err := update(ctx, db, f)
+		if err != ErrConflict || attempt == MaxUpdateAttempts {
+			return err
+		}
+		wait := backoff/2 + time.Duration(rand.Int63n(int64(backoff)))
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+func update(ctx context.Context, db DB, f func(Txn) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	txn := db.NewTxn(true)
+	defer txn.Discard()
+	if err := f(txn); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return txn.Commit()
+}
+
+// View runs f in a new read-only transaction.
+//
+// View returns ctx.Err() without running f if ctx is already done. Otherwise
+// it returns the result of f, which may itself watch ctx while it works.
+func View(ctx context.Context, db DB, f func(Txn) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	txn := db.NewTxn(false)
+	defer txn.Discard()
+	return f(txn)
+}
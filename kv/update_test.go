@@ -0,0 +1,169 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/note-maps/kv"
+	"github.com/google/note-maps/kv/kvtest"
+	"github.com/google/note-maps/kv/memory"
+)
+
+// conflictingBackends are backends that allow concurrent update
+// transactions, and therefore conflicts.
+var conflictingBackends = []struct {
+	Name string
+	New  func(testing.TB) kv.DB
+}{
+	{"memory", func(testing.TB) kv.DB { return memory.NewDB() }},
+	{"badger", kvtest.NewDB},
+}
+
+// get decodes the counter, which is zero if it has never been set.
+func get(txn kv.Txn, n *kv.Entity) error {
+	return txn.Get([]byte("counter"), func(bs []byte) error {
+		if len(bs) == 0 {
+			return nil
+		}
+		return n.Decode(bs)
+	})
+}
+
+// increment reads and increments the counter, and makes a conflicting
+// change to it in another transaction before returning if conflict is true.
+func increment(t *testing.T, db kv.DB, txn kv.Txn, conflict bool) error {
+	var n kv.Entity
+	if err := get(txn, &n); err != nil {
+		return err
+	}
+	if conflict {
+		other := db.NewTxn(true)
+		defer other.Discard()
+		if err := other.Set([]byte("counter"), kv.Entity(100).Encode()); err != nil {
+			return err
+		}
+		if err := other.Commit(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return txn.Set([]byte("counter"), (n + 1).Encode())
+}
+
+func counter(t *testing.T, db kv.DB) kv.Entity {
+	var n kv.Entity
+	if err := kv.View(context.Background(), db, func(txn kv.Txn) error {
+		return get(txn, &n)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestUpdateRetriesConflict(t *testing.T) {
+	for _, backend := range conflictingBackends {
+		t.Run(backend.Name, func(t *testing.T) {
+			db := backend.New(t)
+			defer db.Close()
+			attempts := 0
+			err := kv.Update(context.Background(), db, func(txn kv.Txn) error {
+				attempts++
+				return increment(t, db, txn, attempts == 1)
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if attempts != 2 {
+				t.Errorf("want 2 attempts, got %v", attempts)
+			}
+			if got := counter(t, db); got != 101 {
+				t.Errorf("want counter 101, got %v", got)
+			}
+		})
+	}
+}
+
+func TestUpdateGivesUp(t *testing.T) {
+	for _, backend := range conflictingBackends {
+		t.Run(backend.Name, func(t *testing.T) {
+			db := backend.New(t)
+			defer db.Close()
+			attempts := 0
+			err := kv.Update(context.Background(), db, func(txn kv.Txn) error {
+				attempts++
+				return increment(t, db, txn, true)
+			})
+			if err != kv.ErrConflict {
+				t.Errorf("want %v, got %v", kv.ErrConflict, err)
+			}
+			if attempts != kv.MaxUpdateAttempts {
+				t.Errorf("want %v attempts, got %v", kv.MaxUpdateAttempts, attempts)
+			}
+		})
+	}
+}
+
+func TestUpdateContext(t *testing.T) {
+	db := memory.NewDB()
+	defer db.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	err := kv.Update(ctx, db, func(txn kv.Txn) error {
+		cancel()
+		return increment(t, db, txn, true)
+	})
+	if err != context.Canceled {
+		t.Errorf("want %v, got %v", context.Canceled, err)
+	}
+	if got := counter(t, db); got != 100 {
+		t.Errorf("want only the conflicting change to be committed, got counter %v", got)
+	}
+	ran := false
+	for _, f := range []func(context.Context, kv.DB, func(kv.Txn) error) error{kv.Update, kv.View} {
+		if err := f(ctx, db, func(kv.Txn) error {
+			ran = true
+			return nil
+		}); err != context.Canceled {
+			t.Errorf("want %v, got %v", context.Canceled, err)
+		}
+	}
+	if ran {
+		t.Error("want no calls after ctx is done")
+	}
+}
+
+func TestUpdateError(t *testing.T) {
+	db := memory.NewDB()
+	defer db.Close()
+	want := errors.New("deliberate error")
+	attempts := 0
+	err := kv.Update(context.Background(), db, func(txn kv.Txn) error {
+		attempts++
+		if err := increment(t, db, txn, false); err != nil {
+			return err
+		}
+		return want
+	})
+	if err != want {
+		t.Errorf("want %v, got %v", want, err)
+	}
+	if attempts != 1 {
+		t.Errorf("want 1 attempt, got %v", attempts)
+	}
+	if got := counter(t, db); got != 0 {
+		t.Errorf("want nothing committed, got counter %v", got)
+	}
+}
@@ -0,0 +1,211 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// IndexProblem describes an index row that differs from the row called for by
+// the component values it indexes.
+type IndexProblem struct {
+	// Component and Index identify the index.
+	Component, Index Component
+
+	// Key is the key of the row, relative to the prefix of the index.
+	Key []byte
+
+	// Want is the value the row should have, or nil if the row should not
+	// exist.
+	Want []byte
+
+	// Got is the value the row has, or nil if the row does not exist.
+	Got []byte
+}
+
+// Missing returns true if the row should exist but does not.
+func (p IndexProblem) Missing() bool { return p.Got == nil }
+
+// Orphaned returns true if the row exists but should not.
+func (p IndexProblem) Orphaned() bool { return p.Want == nil }
+
+func (p IndexProblem) String() string {
+	switch {
+	case p.Missing():
+		return fmt.Sprintf("missing row %x of index %v of component %v", p.Key, p.Index, p.Component)
+	case p.Orphaned():
+		return fmt.Sprintf("orphaned row %x of index %v of component %v", p.Key, p.Index, p.Component)
+	default:
+		return fmt.Sprintf("row %x of index %v of component %v has value %x, want %x",
+			p.Key, p.Index, p.Component, p.Got, p.Want)
+	}
+}
+
+// VerifyComponentIndexes compares the index rows of component c in
+// s.Partition with the rows written by index, and returns the differences in
+// order of their keys.
+//
+// Since index rows are written only when values change, index is passed a
+// Partitioned for the same partition backed by a new, empty Txn, and must
+// write the index rows for every c value as if each were set in turn. Code
+// generated by kvschema does this in its Verify methods.
+//
+// All the rows written by index are held in memory.
+func (s Partitioned) VerifyComponentIndexes(c Component, index func(Partitioned) error) ([]IndexProblem, error) {
+	scratch := make(rowTxn)
+	if err := index(Partitioned{scratch, s.Partition}); err != nil {
+		return nil, err
+	}
+	prefix := s.indexPrefix(c, 0)[:18]
+	want := scratch.rows(prefix)
+	var problems []IndexProblem
+	problem := func(key, want, got []byte) {
+		p := IndexProblem{Component: c, Want: want, Got: got}
+		if len(key) >= 2 {
+			p.Index = Component(binary.BigEndian.Uint16(key))
+			p.Key = key[2:]
+		} else {
+			p.Key = key
+		}
+		problems = append(problems, p)
+	}
+	iter := s.PrefixIterator(prefix)
+	defer iter.Discard()
+	for iter.Seek(nil); iter.Valid(); iter.Next() {
+		key := append([]byte(nil), iter.Key()...)
+		for len(want) > 0 && bytes.Compare(want[0].key, key) < 0 {
+			problem(want[0].key, want[0].value, nil)
+			want = want[1:]
+		}
+		got := []byte{}
+		if err := iter.Value(func(v []byte) error {
+			got = append(got, v...)
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+		if len(want) > 0 && bytes.Equal(want[0].key, key) {
+			if !bytes.Equal(want[0].value, got) {
+				problem(key, want[0].value, got)
+			}
+			want = want[1:]
+		} else {
+			problem(key, nil, got)
+		}
+	}
+	for _, row := range want {
+		problem(row.key, row.value, nil)
+	}
+	return problems, nil
+}
+
+// RebuildComponentIndexes deletes the index rows of component c in
+// s.Partition, and then calls index to write them again.
+//
+// As for VerifyComponentIndexes, index must write the index rows for every c
+// value as if each were set in turn. If s.Txn is a *Batch,
+// RebuildComponentIndexes may commit its writes in several transactions.
+func (s Partitioned) RebuildComponentIndexes(c Component, index func(Partitioned) error) error {
+	if err := s.deleteAll(s.indexPrefix(c, 0)[:18]); err != nil {
+		return err
+	}
+	return index(s)
+}
+
+// errRowTxnAlloc is returned by rowTxn.Alloc.
+var errRowTxnAlloc = errors.New("kv: cannot allocate entities while verifying indexes")
+
+// rowTxn is a Txn that holds rows in memory for VerifyComponentIndexes.
+type rowTxn map[string][]byte
+
+type row struct{ key, value []byte }
+
+func (t rowTxn) Alloc() (Entity, error) { return 0, errRowTxnAlloc }
+
+func (t rowTxn) Set(key, value []byte) error {
+	t[string(key)] = append([]byte{}, value...)
+	return nil
+}
+
+func (t rowTxn) Delete(key []byte) error {
+	delete(t, string(key))
+	return nil
+}
+
+func (t rowTxn) Get(key []byte, f func([]byte) error) error {
+	return f(t[string(key)])
+}
+
+func (t rowTxn) PrefixIterator(prefix []byte) Iterator {
+	return &rowIterator{rows: t.rows(prefix), i: -1}
+}
+
+func (t rowTxn) ReversePrefixIterator(prefix []byte) Iterator {
+	return &rowIterator{rows: t.rows(prefix), i: -1, reverse: true}
+}
+
+// rows returns the rows with keys that begin with prefix, in order of their
+// keys, where keys do not include prefix.
+func (t rowTxn) rows(prefix []byte) []row {
+	var rows []row
+	for k, v := range t {
+		if bytes.HasPrefix([]byte(k), prefix) {
+			rows = append(rows, row{[]byte(k[len(prefix):]), v})
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool { return bytes.Compare(rows[i].key, rows[j].key) < 0 })
+	return rows
+}
+
+// rowIterator iterates over a snapshot of the rows of a rowTxn.
+type rowIterator struct {
+	rows    []row
+	i       int
+	reverse bool
+}
+
+func (it *rowIterator) Discard() {}
+
+func (it *rowIterator) Seek(key []byte) {
+	if !it.reverse {
+		it.i = sort.Search(len(it.rows), func(i int) bool {
+			return bytes.Compare(it.rows[i].key, key) >= 0
+		})
+	} else if len(key) == 0 {
+		it.i = len(it.rows) - 1
+	} else {
+		it.i = sort.Search(len(it.rows), func(i int) bool {
+			return bytes.Compare(it.rows[i].key, key) > 0
+		}) - 1
+	}
+}
+
+func (it *rowIterator) Next() {
+	if it.reverse {
+		it.i--
+	} else {
+		it.i++
+	}
+}
+
+func (it *rowIterator) Valid() bool { return it.i >= 0 && it.i < len(it.rows) }
+
+func (it *rowIterator) Key() []byte { return it.rows[it.i].key }
+
+func (it *rowIterator) Value(f func([]byte) error) error { return f(it.rows[it.i].value) }
@@ -0,0 +1,135 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command nmfsck checks the indexes of a Note Maps database for rows that
+// differ from the values they index, such as rows left behind or lost by a
+// crash, and optionally repairs them.
+//
+//   nmfsck [-repair] directory
+//
+// The directory must hold a badger database written by package models. Each
+// index problem is reported on its own line. Partitions that have not been
+// migrated to the latest schema are reported and skipped, since their indexes
+// may be laid out differently.
+//
+// With -repair, the indexes of each partition with problems are rebuilt from
+// the values they index. nmfsck exits with status 1 if any problems remain.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/google/note-maps/kv"
+	"github.com/google/note-maps/kv/badger"
+	"github.com/google/note-maps/kv/migrate"
+	"github.com/google/note-maps/store/models"
+)
+
+var repair = flag.Bool("repair", false, "rebuild the indexes of partitions with problems")
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage of nmfsck:\n")
+	fmt.Fprintf(os.Stderr, "\tnmfsck [flags] directory\n")
+	fmt.Fprintf(os.Stderr, "Flags:\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	db, err := badger.Open(badger.DefaultOptions(flag.Arg(0)))
+	if err != nil {
+		log.Fatal(err)
+	}
+	remaining, err := check(db)
+	if cerr := db.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	if remaining > 0 {
+		os.Exit(1)
+	}
+}
+
+// check reports the index problems in each partition of db, repairs them if
+// requested, and returns the number of partitions with problems that remain.
+func check(db kv.DB) (int, error) {
+	var partitions []kv.Entity
+	if err := kv.View(context.Background(), db, func(txn kv.Txn) error {
+		tms, err := models.New(txn).AllTopicMapInfoEntities(nil, 0)
+		partitions = append([]kv.Entity{0}, tms...)
+		return err
+	}); err != nil {
+		return 0, err
+	}
+	remaining := 0
+	for _, p := range partitions {
+		var (
+			version  int
+			problems []kv.IndexProblem
+		)
+		if err := kv.View(context.Background(), db, func(txn kv.Txn) error {
+			m := models.New(txn)
+			m.Partition = p
+			var err error
+			if version, err = migrate.Version(m.Partitioned); err != nil {
+				return err
+			} else if version != models.Migrations.Latest() {
+				return nil
+			}
+			problems, err = m.VerifyIndexes()
+			return err
+		}); err != nil {
+			return 0, fmt.Errorf("partition %v: %v", p, err)
+		}
+		if version != models.Migrations.Latest() {
+			fmt.Printf("partition %v: schema version %v, want %v: skipped\n",
+				p, version, models.Migrations.Latest())
+			remaining++
+			continue
+		}
+		for _, problem := range problems {
+			fmt.Printf("partition %v: %v\n", p, problem)
+		}
+		if len(problems) == 0 {
+			continue
+		} else if !*repair {
+			remaining++
+			continue
+		}
+		b := kv.NewBatch(db)
+		m := models.New(b)
+		m.Partition = p
+		err := m.RebuildIndexes()
+		if err == nil {
+			err = b.Flush()
+		}
+		b.Discard()
+		if err != nil {
+			return 0, fmt.Errorf("partition %v: %v", p, err)
+		}
+		fmt.Printf("partition %v: rebuilt indexes\n", p)
+	}
+	return remaining, nil
+}
@@ -0,0 +1,72 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/note-maps/kv"
+	"github.com/google/note-maps/kv/kvtest"
+	"github.com/google/note-maps/store/models"
+)
+
+func TestCheck(t *testing.T) {
+	db := kvtest.NewDB(t)
+	defer db.Close()
+	const tm kv.Entity = 42
+	if err := kv.Update(context.Background(), db, func(txn kv.Txn) error {
+		m := models.New(txn)
+		var info models.TopicMapInfo
+		info.TopicMap = uint64(tm)
+		if err := m.SetTopicMapInfo(tm, &info); err != nil {
+			return err
+		}
+		for _, p := range []kv.Entity{0, tm} {
+			m.Partition = p
+			if err := models.Migrations.Stamp(m.Partitioned); err != nil {
+				return err
+			}
+		}
+		var n models.Name
+		n.Topic, n.Value = uint64(tm), "Ada Lovelace"
+		if err := m.SetName(10, &n); err != nil {
+			return err
+		}
+		// Leave behind a row for a name that no longer exists.
+		key := kv.Prefix(tm.Encode()).AppendComponent(models.NamePrefix).ConcatEntityComponent(0, models.ValuePrefix)
+		key = append(kv.AppendKeyedIndexValue(key, kv.String("Lovelace").Encode()), kv.Entity(11).Encode()...)
+		return txn.Set(key, []byte{})
+	}); err != nil {
+		t.Fatal(err)
+	}
+	defer func(r bool) { *repair = r }(*repair)
+	for _, test := range []struct {
+		Repair    bool
+		Remaining int
+	}{
+		{false, 1},
+		{true, 0},
+		{false, 0},
+	} {
+		*repair = test.Repair
+		if remaining, err := check(db); err != nil {
+			t.Fatal(err)
+		} else if remaining != test.Remaining {
+			t.Errorf("repair=%v: want %v partitions with problems, got %v",
+				test.Repair, test.Remaining, remaining)
+		}
+	}
+}
@@ -27,6 +27,7 @@ import (
 
 	"github.com/golang/protobuf/proto"
 	"github.com/google/note-maps/kv/badger"
+	"github.com/google/note-maps/store/models"
 	"github.com/google/note-maps/store/pb"
 	"github.com/google/note-maps/store/pbapi"
 )
@@ -66,6 +67,18 @@ func Query(method string, bs []byte) ([]byte, error) {
 		}
 		log.Printf("%s(%s)=>%s", method, query.String(), response.String())
 		return proto.Marshal(response)
+	case "GetTopicMapStats":
+		var query pb.GetTopicMapStatsRequest
+		err := proto.Unmarshal(bs, &query)
+		if err != nil {
+			return nil, err
+		}
+		response, err := g.GetTopicMapStats(&query)
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("%s(%s)=>%s", method, query.String(), response.String())
+		return proto.Marshal(response)
 	default:
 		return nil, fmt.Errorf("unrecognized query: %#v", method)
 	}
@@ -89,6 +102,30 @@ func Command(method string, bs []byte) ([]byte, error) {
 		}
 		log.Printf("%s(%s)=>%s", method, cmd.String(), response.String())
 		return proto.Marshal(response)
+	case "DuplicateTopicMap":
+		var cmd pb.DuplicateTopicMapRequest
+		err := proto.Unmarshal(bs, &cmd)
+		if err != nil {
+			return nil, err
+		}
+		response, err := g.DuplicateTopicMap(&cmd)
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("%s(%s)=>%s", method, cmd.String(), response.String())
+		return proto.Marshal(response)
+	case "DeleteTopicMap":
+		var cmd pb.DeleteTopicMapRequest
+		err := proto.Unmarshal(bs, &cmd)
+		if err != nil {
+			return nil, err
+		}
+		response, err := g.DeleteTopicMap(&cmd)
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("%s(%s)=>%s", method, cmd.String(), response.String())
+		return proto.Marshal(response)
 	default:
 		return nil, fmt.Errorf("unrecognized command: %#v", method)
 	}
@@ -130,6 +167,13 @@ func gateway() (*pbapi.Gateway, error) {
 		if err != nil {
 			return nil, err
 		}
+
+		// Upgrade notes written by earlier versions before using them.
+		if err = models.Migrate(db); err != nil {
+			db.Close()
+			db = nil
+			return nil, err
+		}
 	}
 
 	return pbapi.NewGateway(db), nil
@@ -3,7 +3,13 @@
 package models
 
 import (
+	"context"
+
 	"github.com/google/note-maps/kv"
+	"github.com/google/note-maps/kv/collation"
+	"github.com/google/note-maps/kv/fulltext"
+	"github.com/google/note-maps/kv/query"
+	"github.com/google/note-maps/kv/trigram"
 )
 
 // Txn provides entities, components, and indexes backed by a key-value store.
@@ -11,6 +17,54 @@ type Txn struct{ kv.Partitioned }
 
 func New(t kv.Txn) Txn { return Txn{kv.Partitioned{t, 0}} }
 
+// Query returns the first n entities that satisfy p, beginning with the first
+// entity greater than or equal to *start.
+//
+// A nil start value will be interpreted as a pointer to zero.
+//
+// A value of n less than or equal to zero will be interpretted as the largest
+// possible value.
+func (s Txn) Query(p query.Predicate, start *kv.Entity, n int) ([]kv.Entity, error) {
+	return query.Entities(s.Partitioned, p, start, n)
+}
+
+// VerifyIndexes calls the Verify method for the indexes of each component
+// type, and returns all of their differences.
+func (s Txn) VerifyIndexes() ([]kv.IndexProblem, error) {
+	var problems []kv.IndexProblem
+	for _, verify := range []func() ([]kv.IndexProblem, error){
+		s.VerifyIIsIndexes,
+		s.VerifyNameIndexes,
+		s.VerifyOccurrenceIndexes,
+		s.VerifySIsIndexes,
+		s.VerifySLsIndexes,
+	} {
+		ps, err := verify()
+		if err != nil {
+			return nil, err
+		}
+		problems = append(problems, ps...)
+	}
+	return problems, nil
+}
+
+// RebuildIndexes calls the Rebuild method for the indexes of each component
+// type.
+func (s Txn) RebuildIndexes() error {
+	for _, rebuild := range []func() error{
+		s.RebuildIIsIndexes,
+		s.RebuildNameIndexes,
+		s.RebuildOccurrenceIndexes,
+		s.RebuildSIsIndexes,
+		s.RebuildSLsIndexes,
+	} {
+		if err := rebuild(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // SetIIs sets the IIs associated with e to v.
 //
 // Corresponding indexes are updated.
@@ -26,41 +80,104 @@ func (s Txn) SetIIs(e kv.Entity, v IIs) error {
 	if err := s.Set(key, v.Encode()); err != nil {
 		return err
 	}
+	if err := s.updateIIsIndexes(e, &old, &v); err != nil {
+		return err
+	}
+	return kv.Checkpoint(s.Txn)
+}
+
+// DeleteIIs removes the IIs associated with e.
+//
+// Corresponding indexes are updated.
+func (s Txn) DeleteIIs(e kv.Entity) error {
+	key := make(kv.Prefix, 8+2+8)
+	s.Partition.EncodeAt(key)
+	IIsPrefix.EncodeAt(key[8:])
+	e.EncodeAt(key[10:])
+	var old IIs
+	if err := s.Get(key, old.Decode); err != nil {
+		return err
+	}
+	if err := s.Delete(key); err != nil {
+		return err
+	}
+	if err := s.updateIIsIndexes(e, &old, nil); err != nil {
+		return err
+	}
+	return kv.Checkpoint(s.Txn)
+}
+
+// updateIIsIndexes updates the index rows of e, which used to have the
+// IIs old and now has v, where either may be nil if e had or has none.
+func (s Txn) updateIIsIndexes(e kv.Entity, old, v *IIs) error {
+	key := make(kv.Prefix, 8+2+8)
+	s.Partition.EncodeAt(key)
+	IIsPrefix.EncodeAt(key[8:])
 	lek := len(key)
-	kv.Entity(0).EncodeAt(key[10:])
 	key = append(key, kv.Component(0).Encode()...)
-	var (
-		lik = len(key)
-		es  kv.EntitySlice
-	)
+	// Each row is written with a key of its own, since a Txn may keep the
+	// keys passed to it.
+	lik := len(key)
 
 	// Update Literal index
-	key = key[:lek].AppendComponent(LiteralPrefix)
-	for _, iv := range old.IndexLiteral() {
-		key = append(key[:lik], iv.Encode()...)
-		if err := s.Get(key, es.Decode); err != nil {
-			return err
+	key = key[:lek:lek].AppendComponent(LiteralPrefix)
+	if old != nil {
+		for _, iv := range old.IndexLiteral() {
+			key = append(kv.AppendKeyedIndexValue(key[:lik:lik], iv.Encode()), e.Encode()...)
+			if err := s.Delete(key); err != nil {
+				return err
+			}
 		}
-		if es.Remove(e) {
-			if err := s.Set(key, es.Encode()); err != nil {
+	}
+	if v != nil {
+		for _, iv := range v.IndexLiteral() {
+			key = append(kv.AppendKeyedIndexValue(key[:lik:lik], iv.Encode()), e.Encode()...)
+			if err := s.Set(key, []byte{}); err != nil {
 				return err
 			}
 		}
 	}
-	for _, iv := range v.IndexLiteral() {
-		key = append(key[:lik], iv.Encode()...)
-		if err := s.Get(key, es.Decode); err != nil {
+	return nil
+}
+
+// indexIIsValues writes the index rows of each IIs in src to
+// s, as if each were set in turn.
+func (s Txn) indexIIsValues(src Txn) error {
+	es, err := src.AllIIsEntities(nil, 0)
+	if err != nil {
+		return err
+	}
+	for _, e := range es {
+		v, err := src.GetIIs(e)
+		if err != nil {
 			return err
 		}
-		if es.Insert(e) {
-			if err := s.Set(key, es.Encode()); err != nil {
-				return err
-			}
+		if err := s.updateIIsIndexes(e, nil, &v); err != nil {
+			return err
+		}
+		if err := kv.Checkpoint(s.Txn); err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
+// VerifyIIsIndexes compares the index rows of IIs values with the
+// rows called for by the values themselves, and returns the differences.
+func (s Txn) VerifyIIsIndexes() ([]kv.IndexProblem, error) {
+	return s.VerifyComponentIndexes(IIsPrefix, func(t kv.Partitioned) error {
+		return Txn{t}.indexIIsValues(s)
+	})
+}
+
+// RebuildIIsIndexes replaces the index rows of IIs values with the
+// rows called for by the values themselves.
+func (s Txn) RebuildIIsIndexes() error {
+	return s.RebuildComponentIndexes(IIsPrefix, func(t kv.Partitioned) error {
+		return Txn{t}.indexIIsValues(s)
+	})
+}
+
 // GetIIs returns the IIs associated with e.
 //
 // If no IIs has been explicitly set for e, and GetIIs will return
@@ -104,18 +221,64 @@ func (s Txn) AllIIsEntities(start *kv.Entity, n int) (es []kv.Entity, err error)
 	return s.AllComponentEntities(IIsPrefix, start, n)
 }
 
+// WatchIIs passes each committed change to a IIs in partition to f,
+// along with the entity it is associated with, until ctx is done or f returns
+// an error.
+//
+// Like GetIIs, WatchIIs reports a deleted IIs as the result
+// of decoding a IIs from an empty slice of bytes.
+func WatchIIs(ctx context.Context, db kv.DB, partition kv.Entity, f func(kv.Entity, IIs) error) error {
+	prefix := make(kv.Prefix, 8+2)
+	partition.EncodeAt(prefix)
+	IIsPrefix.EncodeAt(prefix[8:])
+	return db.Watch(ctx, prefix, func(cs []kv.Change) error {
+		for _, c := range cs {
+			var e kv.Entity
+			if err := e.Decode(c.Key[len(prefix):]); err != nil {
+				return err
+			}
+			if e == 0 {
+				// Entity zero holds index rows rather than values.
+				continue
+			}
+			var v IIs
+			if err := v.Decode(c.Value); err != nil {
+				return err
+			}
+			if err := f(e, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// HasIIs returns a query.Predicate satisfied by entities that have a
+// IIs.
+func HasIIs() query.Predicate { return query.Has(IIsPrefix) }
+
+// MatchingIIsLiteral returns a query.Predicate satisfied by
+// entities with IIs values that return a matching kv.String
+// from their IndexLiteral method.
+func MatchingIIsLiteral(v kv.String) query.Predicate {
+	return query.MatchKeyed(IIsPrefix, LiteralPrefix, v.Encode())
+}
+
 // EntitiesMatchingIIsLiteral returns entities with IIs values that return a matching kv.String from their IndexLiteral method.
 //
 // The returned EntitySlice is already sorted.
 func (s Txn) EntitiesMatchingIIsLiteral(v kv.String) (kv.EntitySlice, error) {
-	key := make(kv.Prefix, 8+2+8+2)
-	s.Partition.EncodeAt(key)
-	IIsPrefix.EncodeAt(key[8:])
-	kv.Entity(0).EncodeAt(key[10:])
-	LiteralPrefix.EncodeAt(key[18:])
-	key = append(key, v.Encode()...)
-	var es kv.EntitySlice
-	return es, s.Get(key, es.Decode)
+	return s.EntitiesMatchingKeyedIndex(IIsPrefix, LiteralPrefix, v.Encode())
+}
+
+// EntitiesWithPrefixIIsLiteral returns up to n entities with
+// IIs values that return a kv.String starting with prefix
+// from their IndexLiteral method, ordered by those kv.String values.
+//
+// A value of n less than or equal to zero will be interpretted as the largest
+// possible value.
+func (s Txn) EntitiesWithPrefixIIsLiteral(prefix kv.String, n int) ([]kv.Entity, error) {
+	return s.EntitiesWithComponentKeyedIndexPrefix(IIsPrefix, LiteralPrefix, prefix.Encode(), n)
 }
 
 // EntitiesByIIsLiteral returns entities with
@@ -127,7 +290,24 @@ func (s Txn) EntitiesMatchingIIsLiteral(v kv.String) (kv.EntitySlice, error) {
 // that using it in a subequent call to ByLiteral would return next n
 // entities.
 func (s Txn) EntitiesByIIsLiteral(cursor *kv.IndexCursor, n int) (es []kv.Entity, err error) {
-	return s.EntitiesByComponentIndex(IIsPrefix, LiteralPrefix, cursor, n)
+	return s.EntitiesByComponentKeyedIndex(IIsPrefix, LiteralPrefix, cursor, n)
+}
+
+// EntitiesByIIsLiteralRange is like
+// EntitiesByIIsLiteral, except that it only returns entities
+// with a kv.String value v such that lo <= v < hi, and that if reverse is
+// true it returns entities in descending order.
+//
+// A nil lo or hi leaves the range unbounded in that direction.
+func (s Txn) EntitiesByIIsLiteralRange(lo, hi *kv.String, reverse bool, cursor *kv.IndexCursor, n int) (es []kv.Entity, err error) {
+	var blo, bhi []byte
+	if lo != nil {
+		blo = append([]byte{}, lo.Encode()...)
+	}
+	if hi != nil {
+		bhi = append([]byte{}, hi.Encode()...)
+	}
+	return s.EntitiesByComponentKeyedIndexRange(IIsPrefix, LiteralPrefix, blo, bhi, reverse, cursor, n)
 }
 
 // SetName sets the Name associated with e to v.
@@ -145,34 +325,102 @@ func (s Txn) SetName(e kv.Entity, v *Name) error {
 	if err := s.Set(key, v.Encode()); err != nil {
 		return err
 	}
+	if err := s.updateNameIndexes(e, &old, v); err != nil {
+		return err
+	}
+	return kv.Checkpoint(s.Txn)
+}
+
+// DeleteName removes the Name associated with e.
+//
+// Corresponding indexes are updated.
+func (s Txn) DeleteName(e kv.Entity) error {
+	key := make(kv.Prefix, 8+2+8)
+	s.Partition.EncodeAt(key)
+	NamePrefix.EncodeAt(key[8:])
+	e.EncodeAt(key[10:])
+	var old Name
+	if err := s.Get(key, old.Decode); err != nil {
+		return err
+	}
+	if err := s.Delete(key); err != nil {
+		return err
+	}
+	if err := s.updateNameIndexes(e, &old, nil); err != nil {
+		return err
+	}
+	return kv.Checkpoint(s.Txn)
+}
+
+// updateNameIndexes updates the index rows of e, which used to have the
+// Name old and now has v, where either may be nil if e had or has none.
+func (s Txn) updateNameIndexes(e kv.Entity, old, v *Name) error {
+	key := make(kv.Prefix, 8+2+8)
+	s.Partition.EncodeAt(key)
+	NamePrefix.EncodeAt(key[8:])
 	lek := len(key)
-	kv.Entity(0).EncodeAt(key[10:])
 	key = append(key, kv.Component(0).Encode()...)
-	var (
-		lik = len(key)
-		es  kv.EntitySlice
-	)
+	// Each row is written with a key of its own, since a Txn may keep the
+	// keys passed to it.
+	lik := len(key)
 
-	// Update Value index
-	key = key[:lek].AppendComponent(ValuePrefix)
-	for _, iv := range old.IndexValue() {
-		key = append(key[:lik], iv.Encode()...)
-		if err := s.Get(key, es.Decode); err != nil {
-			return err
+	// Update SortKey index
+	key = key[:lek:lek].AppendComponent(SortKeyPrefix)
+	if old != nil {
+		for _, iv := range old.IndexSortKey() {
+			key = append(kv.AppendKeyedIndexValue(key[:lik:lik], iv.Encode()), e.Encode()...)
+			if err := s.Delete(key); err != nil {
+				return err
+			}
 		}
-		if es.Remove(e) {
-			if err := s.Set(key, es.Encode()); err != nil {
+	}
+	if v != nil {
+		for _, iv := range v.IndexSortKey() {
+			key = append(kv.AppendKeyedIndexValue(key[:lik:lik], iv.Encode()), e.Encode()...)
+			if err := s.Set(key, []byte{}); err != nil {
 				return err
 			}
 		}
 	}
-	for _, iv := range v.IndexValue() {
-		key = append(key[:lik], iv.Encode()...)
-		if err := s.Get(key, es.Decode); err != nil {
-			return err
+
+	// Update Spelling index
+	var oldSpelling, newSpelling []kv.Fuzzy
+	if old != nil {
+		oldSpelling = old.IndexSpelling()
+	}
+	if v != nil {
+		newSpelling = v.IndexSpelling()
+	}
+	if err := trigram.Update(s.Partitioned, NamePrefix, SpellingPrefix, e, oldSpelling, newSpelling); err != nil {
+		return err
+	}
+
+	// Update Text index
+	var oldText, newText []kv.Text
+	if old != nil {
+		oldText = old.IndexText()
+	}
+	if v != nil {
+		newText = v.IndexText()
+	}
+	if err := fulltext.Update(s.Partitioned, NamePrefix, TextPrefix, e, oldText, newText); err != nil {
+		return err
+	}
+
+	// Update Value index
+	key = key[:lek:lek].AppendComponent(ValuePrefix)
+	if old != nil {
+		for _, iv := range old.IndexValue() {
+			key = append(kv.AppendKeyedIndexValue(key[:lik:lik], iv.Encode()), e.Encode()...)
+			if err := s.Delete(key); err != nil {
+				return err
+			}
 		}
-		if es.Insert(e) {
-			if err := s.Set(key, es.Encode()); err != nil {
+	}
+	if v != nil {
+		for _, iv := range v.IndexValue() {
+			key = append(kv.AppendKeyedIndexValue(key[:lik:lik], iv.Encode()), e.Encode()...)
+			if err := s.Set(key, []byte{}); err != nil {
 				return err
 			}
 		}
@@ -180,6 +428,44 @@ func (s Txn) SetName(e kv.Entity, v *Name) error {
 	return nil
 }
 
+// indexNameValues writes the index rows of each Name in src to
+// s, as if each were set in turn.
+func (s Txn) indexNameValues(src Txn) error {
+	es, err := src.AllNameEntities(nil, 0)
+	if err != nil {
+		return err
+	}
+	for _, e := range es {
+		v, err := src.GetName(e)
+		if err != nil {
+			return err
+		}
+		if err := s.updateNameIndexes(e, nil, &v); err != nil {
+			return err
+		}
+		if err := kv.Checkpoint(s.Txn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// VerifyNameIndexes compares the index rows of Name values with the
+// rows called for by the values themselves, and returns the differences.
+func (s Txn) VerifyNameIndexes() ([]kv.IndexProblem, error) {
+	return s.VerifyComponentIndexes(NamePrefix, func(t kv.Partitioned) error {
+		return Txn{t}.indexNameValues(s)
+	})
+}
+
+// RebuildNameIndexes replaces the index rows of Name values with the
+// rows called for by the values themselves.
+func (s Txn) RebuildNameIndexes() error {
+	return s.RebuildComponentIndexes(NamePrefix, func(t kv.Partitioned) error {
+		return Txn{t}.indexNameValues(s)
+	})
+}
+
 // GetName returns the Name associated with e.
 //
 // If no Name has been explicitly set for e, and GetName will return
@@ -223,18 +509,154 @@ func (s Txn) AllNameEntities(start *kv.Entity, n int) (es []kv.Entity, err error
 	return s.AllComponentEntities(NamePrefix, start, n)
 }
 
+// WatchName passes each committed change to a Name in partition to f,
+// along with the entity it is associated with, until ctx is done or f returns
+// an error.
+//
+// Like GetName, WatchName reports a deleted Name as the result
+// of decoding a Name from an empty slice of bytes.
+func WatchName(ctx context.Context, db kv.DB, partition kv.Entity, f func(kv.Entity, Name) error) error {
+	prefix := make(kv.Prefix, 8+2)
+	partition.EncodeAt(prefix)
+	NamePrefix.EncodeAt(prefix[8:])
+	return db.Watch(ctx, prefix, func(cs []kv.Change) error {
+		for _, c := range cs {
+			var e kv.Entity
+			if err := e.Decode(c.Key[len(prefix):]); err != nil {
+				return err
+			}
+			if e == 0 {
+				// Entity zero holds index rows rather than values.
+				continue
+			}
+			var v Name
+			if err := v.Decode(c.Value); err != nil {
+				return err
+			}
+			if err := f(e, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// HasName returns a query.Predicate satisfied by entities that have a
+// Name.
+func HasName() query.Predicate { return query.Has(NamePrefix) }
+
+// NameTopicOf returns a query.Predicate satisfied by the
+// entities that the Name values of entities satisfying p refer
+// to through their RefTopic method.
+func NameTopicOf(p query.Predicate) query.Predicate {
+	return query.Project(p, func(t kv.Partitioned, e kv.Entity) ([]kv.Entity, error) {
+		v, err := Txn{t}.GetName(e)
+		if err != nil {
+			return nil, err
+		}
+		return v.RefTopic(), nil
+	})
+}
+
+// MatchingNameSortKey returns a query.Predicate satisfied by
+// entities with Name values that return a matching collation.Key
+// from their IndexSortKey method.
+func MatchingNameSortKey(v collation.Key) query.Predicate {
+	return query.MatchKeyed(NamePrefix, SortKeyPrefix, v.Encode())
+}
+
+// EntitiesMatchingNameSortKey returns entities with Name values that return a matching collation.Key from their IndexSortKey method.
+//
+// The returned EntitySlice is already sorted.
+func (s Txn) EntitiesMatchingNameSortKey(v collation.Key) (kv.EntitySlice, error) {
+	return s.EntitiesMatchingKeyedIndex(NamePrefix, SortKeyPrefix, v.Encode())
+}
+
+// EntitiesWithPrefixNameSortKey returns up to n entities with
+// Name values that return a collation.Key starting with prefix
+// from their IndexSortKey method, ordered by those collation.Key values.
+//
+// Matching is at the primary level, ignoring case, accents and width, and
+// prefix should be made by Collator.Prefix: a byte prefix of a collation.Key
+// is not in general the Key of a prefix of the original string.
+//
+// A value of n less than or equal to zero will be interpretted as the largest
+// possible value.
+func (s Txn) EntitiesWithPrefixNameSortKey(prefix collation.Key, n int) ([]kv.Entity, error) {
+	return s.EntitiesWithComponentKeyedIndexPrefix(NamePrefix, SortKeyPrefix, prefix.Encode(), n)
+}
+
+// EntitiesByNameSortKey returns entities with
+// Name values ordered by the collation.Key values from their
+// IndexSortKey method.
+//
+// Reading begins at cursor, and ends when the length of the returned Entity
+// slice is less than n. When reading is not complete, cursor is updated such
+// that using it in a subequent call to BySortKey would return next n
+// entities.
+func (s Txn) EntitiesByNameSortKey(cursor *kv.IndexCursor, n int) (es []kv.Entity, err error) {
+	return s.EntitiesByComponentKeyedIndex(NamePrefix, SortKeyPrefix, cursor, n)
+}
+
+// EntitiesByNameSortKeyRange is like
+// EntitiesByNameSortKey, except that it only returns entities
+// with a collation.Key value v such that lo <= v < hi, and that if reverse is
+// true it returns entities in descending order.
+//
+// A nil lo or hi leaves the range unbounded in that direction.
+func (s Txn) EntitiesByNameSortKeyRange(lo, hi *collation.Key, reverse bool, cursor *kv.IndexCursor, n int) (es []kv.Entity, err error) {
+	var blo, bhi []byte
+	if lo != nil {
+		blo = append([]byte{}, lo.Encode()...)
+	}
+	if hi != nil {
+		bhi = append([]byte{}, hi.Encode()...)
+	}
+	return s.EntitiesByComponentKeyedIndexRange(NamePrefix, SortKeyPrefix, blo, bhi, reverse, cursor, n)
+}
+
+// SimilarNameSpelling returns up to n entities with
+// Name values that return a kv.Fuzzy similar to v from
+// their IndexSpelling method, with the most similar entities first.
+//
+// A value of n less than or equal to zero will be interpretted as the largest
+// possible value.
+func (s Txn) SimilarNameSpelling(v string, n int) ([]trigram.Result, error) {
+	return trigram.Search(s.Partitioned, NamePrefix, SpellingPrefix, v, n)
+}
+
+// SearchNameText returns up to n entities with
+// Name values whose texts from their IndexText method
+// match q, with the most relevant entities first.
+//
+// See fulltext.ParseQuery for the syntax of q. A value of n less than or equal
+// to zero will be interpretted as the largest possible value.
+func (s Txn) SearchNameText(q string, n int) ([]fulltext.Result, error) {
+	return fulltext.Search(s.Partitioned, NamePrefix, TextPrefix, fulltext.ParseQuery(q), n)
+}
+
+// MatchingNameValue returns a query.Predicate satisfied by
+// entities with Name values that return a matching kv.String
+// from their IndexValue method.
+func MatchingNameValue(v kv.String) query.Predicate {
+	return query.MatchKeyed(NamePrefix, ValuePrefix, v.Encode())
+}
+
 // EntitiesMatchingNameValue returns entities with Name values that return a matching kv.String from their IndexValue method.
 //
 // The returned EntitySlice is already sorted.
 func (s Txn) EntitiesMatchingNameValue(v kv.String) (kv.EntitySlice, error) {
-	key := make(kv.Prefix, 8+2+8+2)
-	s.Partition.EncodeAt(key)
-	NamePrefix.EncodeAt(key[8:])
-	kv.Entity(0).EncodeAt(key[10:])
-	ValuePrefix.EncodeAt(key[18:])
-	key = append(key, v.Encode()...)
-	var es kv.EntitySlice
-	return es, s.Get(key, es.Decode)
+	return s.EntitiesMatchingKeyedIndex(NamePrefix, ValuePrefix, v.Encode())
+}
+
+// EntitiesWithPrefixNameValue returns up to n entities with
+// Name values that return a kv.String starting with prefix
+// from their IndexValue method, ordered by those kv.String values.
+//
+// A value of n less than or equal to zero will be interpretted as the largest
+// possible value.
+func (s Txn) EntitiesWithPrefixNameValue(prefix kv.String, n int) ([]kv.Entity, error) {
+	return s.EntitiesWithComponentKeyedIndexPrefix(NamePrefix, ValuePrefix, prefix.Encode(), n)
 }
 
 // EntitiesByNameValue returns entities with
@@ -246,7 +668,24 @@ func (s Txn) EntitiesMatchingNameValue(v kv.String) (kv.EntitySlice, error) {
 // that using it in a subequent call to ByValue would return next n
 // entities.
 func (s Txn) EntitiesByNameValue(cursor *kv.IndexCursor, n int) (es []kv.Entity, err error) {
-	return s.EntitiesByComponentIndex(NamePrefix, ValuePrefix, cursor, n)
+	return s.EntitiesByComponentKeyedIndex(NamePrefix, ValuePrefix, cursor, n)
+}
+
+// EntitiesByNameValueRange is like
+// EntitiesByNameValue, except that it only returns entities
+// with a kv.String value v such that lo <= v < hi, and that if reverse is
+// true it returns entities in descending order.
+//
+// A nil lo or hi leaves the range unbounded in that direction.
+func (s Txn) EntitiesByNameValueRange(lo, hi *kv.String, reverse bool, cursor *kv.IndexCursor, n int) (es []kv.Entity, err error) {
+	var blo, bhi []byte
+	if lo != nil {
+		blo = append([]byte{}, lo.Encode()...)
+	}
+	if hi != nil {
+		bhi = append([]byte{}, hi.Encode()...)
+	}
+	return s.EntitiesByComponentKeyedIndexRange(NamePrefix, ValuePrefix, blo, bhi, reverse, cursor, n)
 }
 
 // SetOccurrence sets the Occurrence associated with e to v.
@@ -264,41 +703,116 @@ func (s Txn) SetOccurrence(e kv.Entity, v *Occurrence) error {
 	if err := s.Set(key, v.Encode()); err != nil {
 		return err
 	}
+	if err := s.updateOccurrenceIndexes(e, &old, v); err != nil {
+		return err
+	}
+	return kv.Checkpoint(s.Txn)
+}
+
+// DeleteOccurrence removes the Occurrence associated with e.
+//
+// Corresponding indexes are updated.
+func (s Txn) DeleteOccurrence(e kv.Entity) error {
+	key := make(kv.Prefix, 8+2+8)
+	s.Partition.EncodeAt(key)
+	OccurrencePrefix.EncodeAt(key[8:])
+	e.EncodeAt(key[10:])
+	var old Occurrence
+	if err := s.Get(key, old.Decode); err != nil {
+		return err
+	}
+	if err := s.Delete(key); err != nil {
+		return err
+	}
+	if err := s.updateOccurrenceIndexes(e, &old, nil); err != nil {
+		return err
+	}
+	return kv.Checkpoint(s.Txn)
+}
+
+// updateOccurrenceIndexes updates the index rows of e, which used to have the
+// Occurrence old and now has v, where either may be nil if e had or has none.
+func (s Txn) updateOccurrenceIndexes(e kv.Entity, old, v *Occurrence) error {
+	key := make(kv.Prefix, 8+2+8)
+	s.Partition.EncodeAt(key)
+	OccurrencePrefix.EncodeAt(key[8:])
 	lek := len(key)
-	kv.Entity(0).EncodeAt(key[10:])
 	key = append(key, kv.Component(0).Encode()...)
-	var (
-		lik = len(key)
-		es  kv.EntitySlice
-	)
+	// Each row is written with a key of its own, since a Txn may keep the
+	// keys passed to it.
+	lik := len(key)
+
+	// Update Text index
+	var oldText, newText []kv.Text
+	if old != nil {
+		oldText = old.IndexText()
+	}
+	if v != nil {
+		newText = v.IndexText()
+	}
+	if err := fulltext.Update(s.Partitioned, OccurrencePrefix, TextPrefix, e, oldText, newText); err != nil {
+		return err
+	}
 
 	// Update Value index
-	key = key[:lek].AppendComponent(ValuePrefix)
-	for _, iv := range old.IndexValue() {
-		key = append(key[:lik], iv.Encode()...)
-		if err := s.Get(key, es.Decode); err != nil {
-			return err
+	key = key[:lek:lek].AppendComponent(ValuePrefix)
+	if old != nil {
+		for _, iv := range old.IndexValue() {
+			key = append(kv.AppendKeyedIndexValue(key[:lik:lik], iv.Encode()), e.Encode()...)
+			if err := s.Delete(key); err != nil {
+				return err
+			}
 		}
-		if es.Remove(e) {
-			if err := s.Set(key, es.Encode()); err != nil {
+	}
+	if v != nil {
+		for _, iv := range v.IndexValue() {
+			key = append(kv.AppendKeyedIndexValue(key[:lik:lik], iv.Encode()), e.Encode()...)
+			if err := s.Set(key, []byte{}); err != nil {
 				return err
 			}
 		}
 	}
-	for _, iv := range v.IndexValue() {
-		key = append(key[:lik], iv.Encode()...)
-		if err := s.Get(key, es.Decode); err != nil {
+	return nil
+}
+
+// indexOccurrenceValues writes the index rows of each Occurrence in src to
+// s, as if each were set in turn.
+func (s Txn) indexOccurrenceValues(src Txn) error {
+	es, err := src.AllOccurrenceEntities(nil, 0)
+	if err != nil {
+		return err
+	}
+	for _, e := range es {
+		v, err := src.GetOccurrence(e)
+		if err != nil {
 			return err
 		}
-		if es.Insert(e) {
-			if err := s.Set(key, es.Encode()); err != nil {
-				return err
-			}
+		if err := s.updateOccurrenceIndexes(e, nil, &v); err != nil {
+			return err
+		}
+		if err := kv.Checkpoint(s.Txn); err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
+// VerifyOccurrenceIndexes compares the index rows of Occurrence values with the
+// rows called for by the values themselves, and returns the differences.
+func (s Txn) VerifyOccurrenceIndexes() ([]kv.IndexProblem, error) {
+	return s.VerifyComponentIndexes(OccurrencePrefix, func(t kv.Partitioned) error {
+		return Txn{t}.indexOccurrenceValues(s)
+	})
+}
+
+// RebuildOccurrenceIndexes replaces the index rows of Occurrence values with the
+// rows called for by the values themselves.
+func (s Txn) RebuildOccurrenceIndexes() error {
+	return s.RebuildComponentIndexes(OccurrencePrefix, func(t kv.Partitioned) error {
+		return Txn{t}.indexOccurrenceValues(s)
+	})
+}
+
 // GetOccurrence returns the Occurrence associated with e.
 //
 // If no Occurrence has been explicitly set for e, and GetOccurrence will return
@@ -342,18 +856,87 @@ func (s Txn) AllOccurrenceEntities(start *kv.Entity, n int) (es []kv.Entity, err
 	return s.AllComponentEntities(OccurrencePrefix, start, n)
 }
 
+// WatchOccurrence passes each committed change to a Occurrence in partition to f,
+// along with the entity it is associated with, until ctx is done or f returns
+// an error.
+//
+// Like GetOccurrence, WatchOccurrence reports a deleted Occurrence as the result
+// of decoding a Occurrence from an empty slice of bytes.
+func WatchOccurrence(ctx context.Context, db kv.DB, partition kv.Entity, f func(kv.Entity, Occurrence) error) error {
+	prefix := make(kv.Prefix, 8+2)
+	partition.EncodeAt(prefix)
+	OccurrencePrefix.EncodeAt(prefix[8:])
+	return db.Watch(ctx, prefix, func(cs []kv.Change) error {
+		for _, c := range cs {
+			var e kv.Entity
+			if err := e.Decode(c.Key[len(prefix):]); err != nil {
+				return err
+			}
+			if e == 0 {
+				// Entity zero holds index rows rather than values.
+				continue
+			}
+			var v Occurrence
+			if err := v.Decode(c.Value); err != nil {
+				return err
+			}
+			if err := f(e, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// HasOccurrence returns a query.Predicate satisfied by entities that have a
+// Occurrence.
+func HasOccurrence() query.Predicate { return query.Has(OccurrencePrefix) }
+
+// OccurrenceTopicOf returns a query.Predicate satisfied by the
+// entities that the Occurrence values of entities satisfying p refer
+// to through their RefTopic method.
+func OccurrenceTopicOf(p query.Predicate) query.Predicate {
+	return query.Project(p, func(t kv.Partitioned, e kv.Entity) ([]kv.Entity, error) {
+		v, err := Txn{t}.GetOccurrence(e)
+		if err != nil {
+			return nil, err
+		}
+		return v.RefTopic(), nil
+	})
+}
+
+// SearchOccurrenceText returns up to n entities with
+// Occurrence values whose texts from their IndexText method
+// match q, with the most relevant entities first.
+//
+// See fulltext.ParseQuery for the syntax of q. A value of n less than or equal
+// to zero will be interpretted as the largest possible value.
+func (s Txn) SearchOccurrenceText(q string, n int) ([]fulltext.Result, error) {
+	return fulltext.Search(s.Partitioned, OccurrencePrefix, TextPrefix, fulltext.ParseQuery(q), n)
+}
+
+// MatchingOccurrenceValue returns a query.Predicate satisfied by
+// entities with Occurrence values that return a matching kv.String
+// from their IndexValue method.
+func MatchingOccurrenceValue(v kv.String) query.Predicate {
+	return query.MatchKeyed(OccurrencePrefix, ValuePrefix, v.Encode())
+}
+
 // EntitiesMatchingOccurrenceValue returns entities with Occurrence values that return a matching kv.String from their IndexValue method.
 //
 // The returned EntitySlice is already sorted.
 func (s Txn) EntitiesMatchingOccurrenceValue(v kv.String) (kv.EntitySlice, error) {
-	key := make(kv.Prefix, 8+2+8+2)
-	s.Partition.EncodeAt(key)
-	OccurrencePrefix.EncodeAt(key[8:])
-	kv.Entity(0).EncodeAt(key[10:])
-	ValuePrefix.EncodeAt(key[18:])
-	key = append(key, v.Encode()...)
-	var es kv.EntitySlice
-	return es, s.Get(key, es.Decode)
+	return s.EntitiesMatchingKeyedIndex(OccurrencePrefix, ValuePrefix, v.Encode())
+}
+
+// EntitiesWithPrefixOccurrenceValue returns up to n entities with
+// Occurrence values that return a kv.String starting with prefix
+// from their IndexValue method, ordered by those kv.String values.
+//
+// A value of n less than or equal to zero will be interpretted as the largest
+// possible value.
+func (s Txn) EntitiesWithPrefixOccurrenceValue(prefix kv.String, n int) ([]kv.Entity, error) {
+	return s.EntitiesWithComponentKeyedIndexPrefix(OccurrencePrefix, ValuePrefix, prefix.Encode(), n)
 }
 
 // EntitiesByOccurrenceValue returns entities with
@@ -365,12 +948,31 @@ func (s Txn) EntitiesMatchingOccurrenceValue(v kv.String) (kv.EntitySlice, error
 // that using it in a subequent call to ByValue would return next n
 // entities.
 func (s Txn) EntitiesByOccurrenceValue(cursor *kv.IndexCursor, n int) (es []kv.Entity, err error) {
-	return s.EntitiesByComponentIndex(OccurrencePrefix, ValuePrefix, cursor, n)
+	return s.EntitiesByComponentKeyedIndex(OccurrencePrefix, ValuePrefix, cursor, n)
+}
+
+// EntitiesByOccurrenceValueRange is like
+// EntitiesByOccurrenceValue, except that it only returns entities
+// with a kv.String value v such that lo <= v < hi, and that if reverse is
+// true it returns entities in descending order.
+//
+// A nil lo or hi leaves the range unbounded in that direction.
+func (s Txn) EntitiesByOccurrenceValueRange(lo, hi *kv.String, reverse bool, cursor *kv.IndexCursor, n int) (es []kv.Entity, err error) {
+	var blo, bhi []byte
+	if lo != nil {
+		blo = append([]byte{}, lo.Encode()...)
+	}
+	if hi != nil {
+		bhi = append([]byte{}, hi.Encode()...)
+	}
+	return s.EntitiesByComponentKeyedIndexRange(OccurrencePrefix, ValuePrefix, blo, bhi, reverse, cursor, n)
 }
 
 // SetSIs sets the SIs associated with e to v.
 //
-// Corresponding indexes are updated.
+// Corresponding indexes are updated. If a value for a unique index
+// already belongs to another entity, SetSIs returns a kv.UniqueViolation
+// and changes nothing.
 func (s Txn) SetSIs(e kv.Entity, v SIs) error {
 	key := make(kv.Prefix, 8+2+8)
 	s.Partition.EncodeAt(key)
@@ -380,44 +982,112 @@ func (s Txn) SetSIs(e kv.Entity, v SIs) error {
 	if err := s.Get(key, old.Decode); err != nil {
 		return err
 	}
+	for _, iv := range v.IndexUniqueLiteral() {
+		if err := s.CheckUnique(kv.KeyedLayout, SIsPrefix, LiteralPrefix, iv.Encode(), e); err != nil {
+			return err
+		}
+	}
 	if err := s.Set(key, v.Encode()); err != nil {
 		return err
 	}
+	if err := s.updateSIsIndexes(e, &old, &v); err != nil {
+		return err
+	}
+	return kv.Checkpoint(s.Txn)
+}
+
+// DeleteSIs removes the SIs associated with e.
+//
+// Corresponding indexes are updated.
+func (s Txn) DeleteSIs(e kv.Entity) error {
+	key := make(kv.Prefix, 8+2+8)
+	s.Partition.EncodeAt(key)
+	SIsPrefix.EncodeAt(key[8:])
+	e.EncodeAt(key[10:])
+	var old SIs
+	if err := s.Get(key, old.Decode); err != nil {
+		return err
+	}
+	if err := s.Delete(key); err != nil {
+		return err
+	}
+	if err := s.updateSIsIndexes(e, &old, nil); err != nil {
+		return err
+	}
+	return kv.Checkpoint(s.Txn)
+}
+
+// updateSIsIndexes updates the index rows of e, which used to have the
+// SIs old and now has v, where either may be nil if e had or has none.
+func (s Txn) updateSIsIndexes(e kv.Entity, old, v *SIs) error {
+	key := make(kv.Prefix, 8+2+8)
+	s.Partition.EncodeAt(key)
+	SIsPrefix.EncodeAt(key[8:])
 	lek := len(key)
-	kv.Entity(0).EncodeAt(key[10:])
 	key = append(key, kv.Component(0).Encode()...)
-	var (
-		lik = len(key)
-		es  kv.EntitySlice
-	)
+	// Each row is written with a key of its own, since a Txn may keep the
+	// keys passed to it.
+	lik := len(key)
 
 	// Update Literal index
-	key = key[:lek].AppendComponent(LiteralPrefix)
-	for _, iv := range old.IndexLiteral() {
-		key = append(key[:lik], iv.Encode()...)
-		if err := s.Get(key, es.Decode); err != nil {
-			return err
+	key = key[:lek:lek].AppendComponent(LiteralPrefix)
+	if old != nil {
+		for _, iv := range old.IndexUniqueLiteral() {
+			key = append(kv.AppendKeyedIndexValue(key[:lik:lik], iv.Encode()), e.Encode()...)
+			if err := s.Delete(key); err != nil {
+				return err
+			}
 		}
-		if es.Remove(e) {
-			if err := s.Set(key, es.Encode()); err != nil {
+	}
+	if v != nil {
+		for _, iv := range v.IndexUniqueLiteral() {
+			key = append(kv.AppendKeyedIndexValue(key[:lik:lik], iv.Encode()), e.Encode()...)
+			if err := s.Set(key, []byte{}); err != nil {
 				return err
 			}
 		}
 	}
-	for _, iv := range v.IndexLiteral() {
-		key = append(key[:lik], iv.Encode()...)
-		if err := s.Get(key, es.Decode); err != nil {
+	return nil
+}
+
+// indexSIsValues writes the index rows of each SIs in src to
+// s, as if each were set in turn.
+func (s Txn) indexSIsValues(src Txn) error {
+	es, err := src.AllSIsEntities(nil, 0)
+	if err != nil {
+		return err
+	}
+	for _, e := range es {
+		v, err := src.GetSIs(e)
+		if err != nil {
 			return err
 		}
-		if es.Insert(e) {
-			if err := s.Set(key, es.Encode()); err != nil {
-				return err
-			}
+		if err := s.updateSIsIndexes(e, nil, &v); err != nil {
+			return err
+		}
+		if err := kv.Checkpoint(s.Txn); err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
+// VerifySIsIndexes compares the index rows of SIs values with the
+// rows called for by the values themselves, and returns the differences.
+func (s Txn) VerifySIsIndexes() ([]kv.IndexProblem, error) {
+	return s.VerifyComponentIndexes(SIsPrefix, func(t kv.Partitioned) error {
+		return Txn{t}.indexSIsValues(s)
+	})
+}
+
+// RebuildSIsIndexes replaces the index rows of SIs values with the
+// rows called for by the values themselves.
+func (s Txn) RebuildSIsIndexes() error {
+	return s.RebuildComponentIndexes(SIsPrefix, func(t kv.Partitioned) error {
+		return Txn{t}.indexSIsValues(s)
+	})
+}
+
 // GetSIs returns the SIs associated with e.
 //
 // If no SIs has been explicitly set for e, and GetSIs will return
@@ -461,30 +1131,93 @@ func (s Txn) AllSIsEntities(start *kv.Entity, n int) (es []kv.Entity, err error)
 	return s.AllComponentEntities(SIsPrefix, start, n)
 }
 
-// EntitiesMatchingSIsLiteral returns entities with SIs values that return a matching kv.String from their IndexLiteral method.
+// WatchSIs passes each committed change to a SIs in partition to f,
+// along with the entity it is associated with, until ctx is done or f returns
+// an error.
+//
+// Like GetSIs, WatchSIs reports a deleted SIs as the result
+// of decoding a SIs from an empty slice of bytes.
+func WatchSIs(ctx context.Context, db kv.DB, partition kv.Entity, f func(kv.Entity, SIs) error) error {
+	prefix := make(kv.Prefix, 8+2)
+	partition.EncodeAt(prefix)
+	SIsPrefix.EncodeAt(prefix[8:])
+	return db.Watch(ctx, prefix, func(cs []kv.Change) error {
+		for _, c := range cs {
+			var e kv.Entity
+			if err := e.Decode(c.Key[len(prefix):]); err != nil {
+				return err
+			}
+			if e == 0 {
+				// Entity zero holds index rows rather than values.
+				continue
+			}
+			var v SIs
+			if err := v.Decode(c.Value); err != nil {
+				return err
+			}
+			if err := f(e, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// HasSIs returns a query.Predicate satisfied by entities that have a
+// SIs.
+func HasSIs() query.Predicate { return query.Has(SIsPrefix) }
+
+// MatchingSIsLiteral returns a query.Predicate satisfied by
+// entities with SIs values that return a matching kv.String
+// from their IndexUniqueLiteral method.
+func MatchingSIsLiteral(v kv.String) query.Predicate {
+	return query.MatchKeyed(SIsPrefix, LiteralPrefix, v.Encode())
+}
+
+// EntitiesMatchingSIsLiteral returns entities with SIs values that return a matching kv.String from their IndexUniqueLiteral method.
 //
 // The returned EntitySlice is already sorted.
 func (s Txn) EntitiesMatchingSIsLiteral(v kv.String) (kv.EntitySlice, error) {
-	key := make(kv.Prefix, 8+2+8+2)
-	s.Partition.EncodeAt(key)
-	SIsPrefix.EncodeAt(key[8:])
-	kv.Entity(0).EncodeAt(key[10:])
-	LiteralPrefix.EncodeAt(key[18:])
-	key = append(key, v.Encode()...)
-	var es kv.EntitySlice
-	return es, s.Get(key, es.Decode)
+	return s.EntitiesMatchingKeyedIndex(SIsPrefix, LiteralPrefix, v.Encode())
+}
+
+// EntitiesWithPrefixSIsLiteral returns up to n entities with
+// SIs values that return a kv.String starting with prefix
+// from their IndexUniqueLiteral method, ordered by those kv.String values.
+//
+// A value of n less than or equal to zero will be interpretted as the largest
+// possible value.
+func (s Txn) EntitiesWithPrefixSIsLiteral(prefix kv.String, n int) ([]kv.Entity, error) {
+	return s.EntitiesWithComponentKeyedIndexPrefix(SIsPrefix, LiteralPrefix, prefix.Encode(), n)
 }
 
 // EntitiesBySIsLiteral returns entities with
 // SIs values ordered by the kv.String values from their
-// IndexLiteral method.
+// IndexUniqueLiteral method.
 //
 // Reading begins at cursor, and ends when the length of the returned Entity
 // slice is less than n. When reading is not complete, cursor is updated such
 // that using it in a subequent call to ByLiteral would return next n
 // entities.
 func (s Txn) EntitiesBySIsLiteral(cursor *kv.IndexCursor, n int) (es []kv.Entity, err error) {
-	return s.EntitiesByComponentIndex(SIsPrefix, LiteralPrefix, cursor, n)
+	return s.EntitiesByComponentKeyedIndex(SIsPrefix, LiteralPrefix, cursor, n)
+}
+
+// EntitiesBySIsLiteralRange is like
+// EntitiesBySIsLiteral, except that it only returns entities
+// with a kv.String value v such that lo <= v < hi, and that if reverse is
+// true it returns entities in descending order.
+//
+// A nil lo or hi leaves the range unbounded in that direction.
+func (s Txn) EntitiesBySIsLiteralRange(lo, hi *kv.String, reverse bool, cursor *kv.IndexCursor, n int) (es []kv.Entity, err error) {
+	var blo, bhi []byte
+	if lo != nil {
+		blo = append([]byte{}, lo.Encode()...)
+	}
+	if hi != nil {
+		bhi = append([]byte{}, hi.Encode()...)
+	}
+	return s.EntitiesByComponentKeyedIndexRange(SIsPrefix, LiteralPrefix, blo, bhi, reverse, cursor, n)
 }
 
 // SetSLs sets the SLs associated with e to v.
@@ -502,41 +1235,104 @@ func (s Txn) SetSLs(e kv.Entity, v SLs) error {
 	if err := s.Set(key, v.Encode()); err != nil {
 		return err
 	}
+	if err := s.updateSLsIndexes(e, &old, &v); err != nil {
+		return err
+	}
+	return kv.Checkpoint(s.Txn)
+}
+
+// DeleteSLs removes the SLs associated with e.
+//
+// Corresponding indexes are updated.
+func (s Txn) DeleteSLs(e kv.Entity) error {
+	key := make(kv.Prefix, 8+2+8)
+	s.Partition.EncodeAt(key)
+	SLsPrefix.EncodeAt(key[8:])
+	e.EncodeAt(key[10:])
+	var old SLs
+	if err := s.Get(key, old.Decode); err != nil {
+		return err
+	}
+	if err := s.Delete(key); err != nil {
+		return err
+	}
+	if err := s.updateSLsIndexes(e, &old, nil); err != nil {
+		return err
+	}
+	return kv.Checkpoint(s.Txn)
+}
+
+// updateSLsIndexes updates the index rows of e, which used to have the
+// SLs old and now has v, where either may be nil if e had or has none.
+func (s Txn) updateSLsIndexes(e kv.Entity, old, v *SLs) error {
+	key := make(kv.Prefix, 8+2+8)
+	s.Partition.EncodeAt(key)
+	SLsPrefix.EncodeAt(key[8:])
 	lek := len(key)
-	kv.Entity(0).EncodeAt(key[10:])
 	key = append(key, kv.Component(0).Encode()...)
-	var (
-		lik = len(key)
-		es  kv.EntitySlice
-	)
+	// Each row is written with a key of its own, since a Txn may keep the
+	// keys passed to it.
+	lik := len(key)
 
 	// Update Literal index
-	key = key[:lek].AppendComponent(LiteralPrefix)
-	for _, iv := range old.IndexLiteral() {
-		key = append(key[:lik], iv.Encode()...)
-		if err := s.Get(key, es.Decode); err != nil {
-			return err
+	key = key[:lek:lek].AppendComponent(LiteralPrefix)
+	if old != nil {
+		for _, iv := range old.IndexLiteral() {
+			key = append(kv.AppendKeyedIndexValue(key[:lik:lik], iv.Encode()), e.Encode()...)
+			if err := s.Delete(key); err != nil {
+				return err
+			}
 		}
-		if es.Remove(e) {
-			if err := s.Set(key, es.Encode()); err != nil {
+	}
+	if v != nil {
+		for _, iv := range v.IndexLiteral() {
+			key = append(kv.AppendKeyedIndexValue(key[:lik:lik], iv.Encode()), e.Encode()...)
+			if err := s.Set(key, []byte{}); err != nil {
 				return err
 			}
 		}
 	}
-	for _, iv := range v.IndexLiteral() {
-		key = append(key[:lik], iv.Encode()...)
-		if err := s.Get(key, es.Decode); err != nil {
+	return nil
+}
+
+// indexSLsValues writes the index rows of each SLs in src to
+// s, as if each were set in turn.
+func (s Txn) indexSLsValues(src Txn) error {
+	es, err := src.AllSLsEntities(nil, 0)
+	if err != nil {
+		return err
+	}
+	for _, e := range es {
+		v, err := src.GetSLs(e)
+		if err != nil {
 			return err
 		}
-		if es.Insert(e) {
-			if err := s.Set(key, es.Encode()); err != nil {
-				return err
-			}
+		if err := s.updateSLsIndexes(e, nil, &v); err != nil {
+			return err
+		}
+		if err := kv.Checkpoint(s.Txn); err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
+// VerifySLsIndexes compares the index rows of SLs values with the
+// rows called for by the values themselves, and returns the differences.
+func (s Txn) VerifySLsIndexes() ([]kv.IndexProblem, error) {
+	return s.VerifyComponentIndexes(SLsPrefix, func(t kv.Partitioned) error {
+		return Txn{t}.indexSLsValues(s)
+	})
+}
+
+// RebuildSLsIndexes replaces the index rows of SLs values with the
+// rows called for by the values themselves.
+func (s Txn) RebuildSLsIndexes() error {
+	return s.RebuildComponentIndexes(SLsPrefix, func(t kv.Partitioned) error {
+		return Txn{t}.indexSLsValues(s)
+	})
+}
+
 // GetSLs returns the SLs associated with e.
 //
 // If no SLs has been explicitly set for e, and GetSLs will return
@@ -580,18 +1376,64 @@ func (s Txn) AllSLsEntities(start *kv.Entity, n int) (es []kv.Entity, err error)
 	return s.AllComponentEntities(SLsPrefix, start, n)
 }
 
+// WatchSLs passes each committed change to a SLs in partition to f,
+// along with the entity it is associated with, until ctx is done or f returns
+// an error.
+//
+// Like GetSLs, WatchSLs reports a deleted SLs as the result
+// of decoding a SLs from an empty slice of bytes.
+func WatchSLs(ctx context.Context, db kv.DB, partition kv.Entity, f func(kv.Entity, SLs) error) error {
+	prefix := make(kv.Prefix, 8+2)
+	partition.EncodeAt(prefix)
+	SLsPrefix.EncodeAt(prefix[8:])
+	return db.Watch(ctx, prefix, func(cs []kv.Change) error {
+		for _, c := range cs {
+			var e kv.Entity
+			if err := e.Decode(c.Key[len(prefix):]); err != nil {
+				return err
+			}
+			if e == 0 {
+				// Entity zero holds index rows rather than values.
+				continue
+			}
+			var v SLs
+			if err := v.Decode(c.Value); err != nil {
+				return err
+			}
+			if err := f(e, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// HasSLs returns a query.Predicate satisfied by entities that have a
+// SLs.
+func HasSLs() query.Predicate { return query.Has(SLsPrefix) }
+
+// MatchingSLsLiteral returns a query.Predicate satisfied by
+// entities with SLs values that return a matching kv.String
+// from their IndexLiteral method.
+func MatchingSLsLiteral(v kv.String) query.Predicate {
+	return query.MatchKeyed(SLsPrefix, LiteralPrefix, v.Encode())
+}
+
 // EntitiesMatchingSLsLiteral returns entities with SLs values that return a matching kv.String from their IndexLiteral method.
 //
 // The returned EntitySlice is already sorted.
 func (s Txn) EntitiesMatchingSLsLiteral(v kv.String) (kv.EntitySlice, error) {
-	key := make(kv.Prefix, 8+2+8+2)
-	s.Partition.EncodeAt(key)
-	SLsPrefix.EncodeAt(key[8:])
-	kv.Entity(0).EncodeAt(key[10:])
-	LiteralPrefix.EncodeAt(key[18:])
-	key = append(key, v.Encode()...)
-	var es kv.EntitySlice
-	return es, s.Get(key, es.Decode)
+	return s.EntitiesMatchingKeyedIndex(SLsPrefix, LiteralPrefix, v.Encode())
+}
+
+// EntitiesWithPrefixSLsLiteral returns up to n entities with
+// SLs values that return a kv.String starting with prefix
+// from their IndexLiteral method, ordered by those kv.String values.
+//
+// A value of n less than or equal to zero will be interpretted as the largest
+// possible value.
+func (s Txn) EntitiesWithPrefixSLsLiteral(prefix kv.String, n int) ([]kv.Entity, error) {
+	return s.EntitiesWithComponentKeyedIndexPrefix(SLsPrefix, LiteralPrefix, prefix.Encode(), n)
 }
 
 // EntitiesBySLsLiteral returns entities with
@@ -603,7 +1445,24 @@ func (s Txn) EntitiesMatchingSLsLiteral(v kv.String) (kv.EntitySlice, error) {
 // that using it in a subequent call to ByLiteral would return next n
 // entities.
 func (s Txn) EntitiesBySLsLiteral(cursor *kv.IndexCursor, n int) (es []kv.Entity, err error) {
-	return s.EntitiesByComponentIndex(SLsPrefix, LiteralPrefix, cursor, n)
+	return s.EntitiesByComponentKeyedIndex(SLsPrefix, LiteralPrefix, cursor, n)
+}
+
+// EntitiesBySLsLiteralRange is like
+// EntitiesBySLsLiteral, except that it only returns entities
+// with a kv.String value v such that lo <= v < hi, and that if reverse is
+// true it returns entities in descending order.
+//
+// A nil lo or hi leaves the range unbounded in that direction.
+func (s Txn) EntitiesBySLsLiteralRange(lo, hi *kv.String, reverse bool, cursor *kv.IndexCursor, n int) (es []kv.Entity, err error) {
+	var blo, bhi []byte
+	if lo != nil {
+		blo = append([]byte{}, lo.Encode()...)
+	}
+	if hi != nil {
+		bhi = append([]byte{}, hi.Encode()...)
+	}
+	return s.EntitiesByComponentKeyedIndexRange(SLsPrefix, LiteralPrefix, blo, bhi, reverse, cursor, n)
 }
 
 // SetTopicMapInfo sets the TopicMapInfo associated with e to v.
@@ -614,7 +1473,24 @@ func (s Txn) SetTopicMapInfo(e kv.Entity, v *TopicMapInfo) error {
 	s.Partition.EncodeAt(key)
 	TopicMapInfoPrefix.EncodeAt(key[8:])
 	e.EncodeAt(key[10:])
-	return s.Set(key, v.Encode())
+	if err := s.Set(key, v.Encode()); err != nil {
+		return err
+	}
+	return kv.Checkpoint(s.Txn)
+}
+
+// DeleteTopicMapInfo removes the TopicMapInfo associated with e.
+//
+// Corresponding indexes are updated.
+func (s Txn) DeleteTopicMapInfo(e kv.Entity) error {
+	key := make(kv.Prefix, 8+2+8)
+	s.Partition.EncodeAt(key)
+	TopicMapInfoPrefix.EncodeAt(key[8:])
+	e.EncodeAt(key[10:])
+	if err := s.Delete(key); err != nil {
+		return err
+	}
+	return kv.Checkpoint(s.Txn)
 }
 
 // GetTopicMapInfo returns the TopicMapInfo associated with e.
@@ -660,6 +1536,42 @@ func (s Txn) AllTopicMapInfoEntities(start *kv.Entity, n int) (es []kv.Entity, e
 	return s.AllComponentEntities(TopicMapInfoPrefix, start, n)
 }
 
+// WatchTopicMapInfo passes each committed change to a TopicMapInfo in partition to f,
+// along with the entity it is associated with, until ctx is done or f returns
+// an error.
+//
+// Like GetTopicMapInfo, WatchTopicMapInfo reports a deleted TopicMapInfo as the result
+// of decoding a TopicMapInfo from an empty slice of bytes.
+func WatchTopicMapInfo(ctx context.Context, db kv.DB, partition kv.Entity, f func(kv.Entity, TopicMapInfo) error) error {
+	prefix := make(kv.Prefix, 8+2)
+	partition.EncodeAt(prefix)
+	TopicMapInfoPrefix.EncodeAt(prefix[8:])
+	return db.Watch(ctx, prefix, func(cs []kv.Change) error {
+		for _, c := range cs {
+			var e kv.Entity
+			if err := e.Decode(c.Key[len(prefix):]); err != nil {
+				return err
+			}
+			if e == 0 {
+				// Entity zero holds index rows rather than values.
+				continue
+			}
+			var v TopicMapInfo
+			if err := v.Decode(c.Value); err != nil {
+				return err
+			}
+			if err := f(e, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// HasTopicMapInfo returns a query.Predicate satisfied by entities that have a
+// TopicMapInfo.
+func HasTopicMapInfo() query.Predicate { return query.Has(TopicMapInfoPrefix) }
+
 // SetTopicNames sets the TopicNames associated with e to v.
 //
 // Corresponding indexes are updated.
@@ -668,7 +1580,24 @@ func (s Txn) SetTopicNames(e kv.Entity, v TopicNames) error {
 	s.Partition.EncodeAt(key)
 	TopicNamesPrefix.EncodeAt(key[8:])
 	e.EncodeAt(key[10:])
-	return s.Set(key, v.Encode())
+	if err := s.Set(key, v.Encode()); err != nil {
+		return err
+	}
+	return kv.Checkpoint(s.Txn)
+}
+
+// DeleteTopicNames removes the TopicNames associated with e.
+//
+// Corresponding indexes are updated.
+func (s Txn) DeleteTopicNames(e kv.Entity) error {
+	key := make(kv.Prefix, 8+2+8)
+	s.Partition.EncodeAt(key)
+	TopicNamesPrefix.EncodeAt(key[8:])
+	e.EncodeAt(key[10:])
+	if err := s.Delete(key); err != nil {
+		return err
+	}
+	return kv.Checkpoint(s.Txn)
 }
 
 // GetTopicNames returns the TopicNames associated with e.
@@ -714,6 +1643,42 @@ func (s Txn) AllTopicNamesEntities(start *kv.Entity, n int) (es []kv.Entity, err
 	return s.AllComponentEntities(TopicNamesPrefix, start, n)
 }
 
+// WatchTopicNames passes each committed change to a TopicNames in partition to f,
+// along with the entity it is associated with, until ctx is done or f returns
+// an error.
+//
+// Like GetTopicNames, WatchTopicNames reports a deleted TopicNames as the result
+// of decoding a TopicNames from an empty slice of bytes.
+func WatchTopicNames(ctx context.Context, db kv.DB, partition kv.Entity, f func(kv.Entity, TopicNames) error) error {
+	prefix := make(kv.Prefix, 8+2)
+	partition.EncodeAt(prefix)
+	TopicNamesPrefix.EncodeAt(prefix[8:])
+	return db.Watch(ctx, prefix, func(cs []kv.Change) error {
+		for _, c := range cs {
+			var e kv.Entity
+			if err := e.Decode(c.Key[len(prefix):]); err != nil {
+				return err
+			}
+			if e == 0 {
+				// Entity zero holds index rows rather than values.
+				continue
+			}
+			var v TopicNames
+			if err := v.Decode(c.Value); err != nil {
+				return err
+			}
+			if err := f(e, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// HasTopicNames returns a query.Predicate satisfied by entities that have a
+// TopicNames.
+func HasTopicNames() query.Predicate { return query.Has(TopicNamesPrefix) }
+
 // SetTopicOccurrences sets the TopicOccurrences associated with e to v.
 //
 // Corresponding indexes are updated.
@@ -722,7 +1687,24 @@ func (s Txn) SetTopicOccurrences(e kv.Entity, v TopicOccurrences) error {
 	s.Partition.EncodeAt(key)
 	TopicOccurrencesPrefix.EncodeAt(key[8:])
 	e.EncodeAt(key[10:])
-	return s.Set(key, v.Encode())
+	if err := s.Set(key, v.Encode()); err != nil {
+		return err
+	}
+	return kv.Checkpoint(s.Txn)
+}
+
+// DeleteTopicOccurrences removes the TopicOccurrences associated with e.
+//
+// Corresponding indexes are updated.
+func (s Txn) DeleteTopicOccurrences(e kv.Entity) error {
+	key := make(kv.Prefix, 8+2+8)
+	s.Partition.EncodeAt(key)
+	TopicOccurrencesPrefix.EncodeAt(key[8:])
+	e.EncodeAt(key[10:])
+	if err := s.Delete(key); err != nil {
+		return err
+	}
+	return kv.Checkpoint(s.Txn)
 }
 
 // GetTopicOccurrences returns the TopicOccurrences associated with e.
@@ -767,3 +1749,39 @@ func (s Txn) GetTopicOccurrencesSlice(es []kv.Entity) ([]TopicOccurrences, error
 func (s Txn) AllTopicOccurrencesEntities(start *kv.Entity, n int) (es []kv.Entity, err error) {
 	return s.AllComponentEntities(TopicOccurrencesPrefix, start, n)
 }
+
+// WatchTopicOccurrences passes each committed change to a TopicOccurrences in partition to f,
+// along with the entity it is associated with, until ctx is done or f returns
+// an error.
+//
+// Like GetTopicOccurrences, WatchTopicOccurrences reports a deleted TopicOccurrences as the result
+// of decoding a TopicOccurrences from an empty slice of bytes.
+func WatchTopicOccurrences(ctx context.Context, db kv.DB, partition kv.Entity, f func(kv.Entity, TopicOccurrences) error) error {
+	prefix := make(kv.Prefix, 8+2)
+	partition.EncodeAt(prefix)
+	TopicOccurrencesPrefix.EncodeAt(prefix[8:])
+	return db.Watch(ctx, prefix, func(cs []kv.Change) error {
+		for _, c := range cs {
+			var e kv.Entity
+			if err := e.Decode(c.Key[len(prefix):]); err != nil {
+				return err
+			}
+			if e == 0 {
+				// Entity zero holds index rows rather than values.
+				continue
+			}
+			var v TopicOccurrences
+			if err := v.Decode(c.Value); err != nil {
+				return err
+			}
+			if err := f(e, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// HasTopicOccurrences returns a query.Predicate satisfied by entities that have a
+// TopicOccurrences.
+func HasTopicOccurrences() query.Predicate { return query.Has(TopicOccurrencesPrefix) }
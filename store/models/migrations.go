@@ -0,0 +1,135 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"github.com/google/note-maps/kv"
+	"github.com/google/note-maps/kv/fulltext"
+	"github.com/google/note-maps/kv/migrate"
+	"github.com/google/note-maps/kv/trigram"
+)
+
+// Migrations upgrades partitions written by earlier versions of this package.
+//
+// Partition zero holds TopicMapInfo values, and every other partition holds
+// the contents of a single topic map. Each is versioned independently.
+var Migrations migrate.Registry
+
+func init() {
+	// Version 1 is the storage format in use when schema versions were
+	// introduced, so there is nothing to change.
+	Migrations.Register(1, "initial schema", func(kv.Partitioned) error { return nil })
+
+	// Version 2 stores each index with one row per index value and entity,
+	// so that updating a popular value no longer rewrites a growing row.
+	Migrations.Register(2, "keyed index layout", func(p kv.Partitioned) error {
+		for _, ix := range []struct{ c, ix kv.Component }{
+			{IIsPrefix, LiteralPrefix},
+			{SIsPrefix, LiteralPrefix},
+			{SLsPrefix, LiteralPrefix},
+			{NamePrefix, ValuePrefix},
+			{OccurrencePrefix, ValuePrefix},
+		} {
+			if err := p.ChangeIndexLayout(ix.c, ix.ix, kv.SliceLayout, kv.KeyedLayout); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	// Version 3 adds full-text indexes of names and occurrences.
+	Migrations.Register(3, "full-text index", func(p kv.Partitioned) error {
+		m := Txn{p}
+		nes, err := m.AllNameEntities(nil, 0)
+		if err != nil {
+			return err
+		}
+		for _, e := range nes {
+			n, err := m.GetName(e)
+			if err != nil {
+				return err
+			}
+			if err := fulltext.Update(p, NamePrefix, TextPrefix, e, nil, n.IndexText()); err != nil {
+				return err
+			}
+		}
+		oes, err := m.AllOccurrenceEntities(nil, 0)
+		if err != nil {
+			return err
+		}
+		for _, e := range oes {
+			o, err := m.GetOccurrence(e)
+			if err != nil {
+				return err
+			}
+			if err := fulltext.Update(p, OccurrencePrefix, TextPrefix, e, nil, o.IndexText()); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	// Version 4 adds a trigram index of names for suggesting similar names.
+	Migrations.Register(4, "trigram index", func(p kv.Partitioned) error {
+		m := Txn{p}
+		nes, err := m.AllNameEntities(nil, 0)
+		if err != nil {
+			return err
+		}
+		for _, e := range nes {
+			n, err := m.GetName(e)
+			if err != nil {
+				return err
+			}
+			if err := trigram.Update(p, NamePrefix, SpellingPrefix, e, nil, n.IndexSpelling()); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	// Version 5 adds an index of names by collation key. Setting each name
+	// again adds its row to the new index and leaves its other rows as they
+	// are.
+	Migrations.Register(5, "collation index", func(p kv.Partitioned) error {
+		m := Txn{p}
+		nes, err := m.AllNameEntities(nil, 0)
+		if err != nil {
+			return err
+		}
+		for _, e := range nes {
+			n, err := m.GetName(e)
+			if err != nil {
+				return err
+			}
+			if err := m.SetName(e, &n); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Migrate applies Migrations to partition zero and to the partition of every
+// topic map in db.
+func Migrate(db kv.DB) error {
+	return Migrations.MigrateDB(db, func(t kv.Txn) ([]kv.Entity, error) {
+		tms, err := New(t).AllTopicMapInfoEntities(nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		return append([]kv.Entity{0}, tms...), nil
+	})
+}
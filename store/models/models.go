@@ -16,7 +16,7 @@
 // key-value store using package kv.
 package models
 
-//go:generate kvschema
+//go:generate kvschema -index_layout=keyed
 
 import (
 	"encoding/json"
@@ -26,6 +26,7 @@ import (
 
 	"github.com/golang/protobuf/proto"
 	"github.com/google/note-maps/kv"
+	"github.com/google/note-maps/kv/collation"
 	"github.com/google/note-maps/store/models/internal/pb"
 )
 
@@ -40,8 +41,19 @@ const (
 	NamePrefix             kv.Component = 0x0008
 	OccurrencePrefix       kv.Component = 0x0009
 	ValuePrefix            kv.Component = 0x000A
+	TextPrefix             kv.Component = 0x000B
+	SpellingPrefix         kv.Component = 0x000C
+	SortKeyPrefix          kv.Component = 0x000D
 )
 
+// Collator determines the order of names in the SortKey index, so that
+// listings sort the way a dictionary does.
+//
+// Index rows are made with the Collator in use when each name is set, so
+// Collator may be replaced to sort for a particular locale only before any
+// names are stored.
+var Collator = collation.Default
+
 // TopicMapInfo wraps pb.TopicMapInfo to implement kv.Encoder and kv.Decoder
 // interfaces.
 type TopicMapInfo struct{ pb.TopicMapInfo }
@@ -79,7 +91,12 @@ func (sls *SLs) Decode(bs []byte) error {
 }
 
 func (iis IIs) IndexLiteral() []kv.String { return literalStringSlice(iis) }
-func (sis SIs) IndexLiteral() []kv.String { return literalStringSlice(sis) }
+
+// IndexUniqueLiteral indexes subject identifiers, each of which may identify
+// at most one topic, so SetSIs returns a kv.UniqueViolation rather than let a
+// second topic claim one.
+func (sis SIs) IndexUniqueLiteral() []kv.String { return literalStringSlice(sis) }
+
 func (sls SLs) IndexLiteral() []kv.String { return literalStringSlice(sls) }
 
 // TopicNames holds a slice of all of a topic's names.
@@ -117,9 +134,18 @@ func (tos *TopicOccurrences) Decode(bs []byte) error {
 // Names wraps pb.Names to implement kv.Encoder and kv.Decoder interfaces.
 type Name struct{ pb.Name }
 
-func (n *Name) Encode() []byte          { return encodeProto(n) }
-func (n *Name) Decode(src []byte) error { return decodeProto(src, n) }
-func (n *Name) IndexValue() []kv.String { return []kv.String{kv.String(n.GetValue())} }
+func (n *Name) Encode() []byte            { return encodeProto(n) }
+func (n *Name) Decode(src []byte) error   { return decodeProto(src, n) }
+func (n *Name) IndexValue() []kv.String   { return []kv.String{kv.String(n.GetValue())} }
+func (n *Name) IndexText() []kv.Text      { return []kv.Text{kv.Text(n.GetValue())} }
+func (n *Name) IndexSpelling() []kv.Fuzzy { return []kv.Fuzzy{kv.Fuzzy(n.GetValue())} }
+func (n *Name) IndexSortKey() []collation.Key {
+	return []collation.Key{Collator.Key(n.GetValue())}
+}
+
+// RefTopic returns the topic of n, so that NameTopicOf maps names to their
+// topics in queries.
+func (n *Name) RefTopic() []kv.Entity { return []kv.Entity{kv.Entity(n.GetTopic())} }
 
 // Occurrence wraps pb.Names to implement kv.Encoder and kv.Decoder interfaces.
 type Occurrence struct{ pb.Occurrence }
@@ -127,6 +153,11 @@ type Occurrence struct{ pb.Occurrence }
 func (o *Occurrence) Encode() []byte          { return encodeProto(o) }
 func (o *Occurrence) Decode(src []byte) error { return decodeProto(src, o) }
 func (o *Occurrence) IndexValue() []kv.String { return []kv.String{kv.String(o.GetValue())} }
+func (o *Occurrence) IndexText() []kv.Text    { return []kv.Text{kv.Text(o.GetValue())} }
+
+// RefTopic returns the topic of o, so that OccurrenceTopicOf maps occurrences
+// to their topics in queries.
+func (o *Occurrence) RefTopic() []kv.Entity { return []kv.Entity{kv.Entity(o.GetTopic())} }
 
 // UnsupportedFormatError indicates that a value was found in the key-value
 // backing store with an unsupported format code, perhaps due to data
@@ -166,6 +197,10 @@ func encodeStringSlice(src []string) []byte {
 }
 
 func decodeStringSlice(dst *[]string, src []byte) error {
+	if len(src) == 0 {
+		*dst = nil
+		return nil
+	}
 	return json.Unmarshal(src, dst)
 }
 
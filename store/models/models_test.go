@@ -15,11 +15,21 @@
 package models
 
 import (
+	"context"
+	"fmt"
+	"math/rand"
+	"reflect"
 	"sort"
 	"testing"
 
 	"github.com/google/note-maps/kv"
+	"github.com/google/note-maps/kv/bolt/bolttest"
+	"github.com/google/note-maps/kv/fulltext"
+	"github.com/google/note-maps/kv/kvtest"
 	"github.com/google/note-maps/kv/memory"
+	"github.com/google/note-maps/kv/migrate"
+	"github.com/google/note-maps/kv/query"
+	"github.com/google/note-maps/kv/trigram"
 )
 
 func createTopicMap(s *Txn) (*TopicMapInfo, error) {
@@ -79,3 +89,776 @@ func TestCreateTopicMap(t *testing.T) {
 		}
 	}
 }
+
+func TestEntitiesWithPrefixNameValue(t *testing.T) {
+	txn := New(memory.New())
+	txn.Partition = 42
+	names := map[kv.Entity]string{
+		1: "Lennon",
+		2: "Lenin",
+		3: "McCartney",
+		4: "Lennox",
+		5: "len",
+	}
+	for e, v := range names {
+		var n Name
+		n.Value = v
+		if err := txn.SetName(e, &n); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, test := range []struct {
+		Prefix kv.String
+		N      int
+		Want   []kv.Entity
+	}{
+		{"Len", 0, []kv.Entity{2, 1, 4}},
+		{"Lenn", 0, []kv.Entity{1, 4}},
+		{"Len", 2, []kv.Entity{2, 1}},
+		{"", 0, []kv.Entity{2, 1, 4, 3, 5}},
+		{"Lennonx", 0, nil},
+	} {
+		got, err := txn.EntitiesWithPrefixNameValue(test.Prefix, test.N)
+		if err != nil {
+			t.Error(err)
+		} else if !kv.EntitySlice(test.Want).Equal(got) {
+			t.Errorf("%#v: want %v, got %v", test.Prefix, test.Want, got)
+		}
+	}
+}
+
+func TestRenameOnDisk(t *testing.T) {
+	db := kvtest.NewDB(t)
+	defer db.Close()
+	for _, v := range []string{"Ada", "Bob"} {
+		if err := kv.Update(context.Background(), db, func(t kv.Txn) error {
+			var n Name
+			n.Value = v
+			return New(t).SetName(10, &n)
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := kv.View(context.Background(), db, func(t kv.Txn) error {
+		txn := New(t)
+		for _, test := range []struct {
+			Value kv.String
+			Want  []kv.Entity
+		}{
+			{"Ada", nil},
+			{"Bob", []kv.Entity{10}},
+		} {
+			got, err := txn.EntitiesMatchingNameValue(test.Value)
+			if err != nil {
+				return err
+			} else if !kv.EntitySlice(test.Want).Equal(got) {
+				return fmt.Errorf("%#v: want %v, got %v", test.Value, test.Want, got)
+			}
+		}
+		problems, err := txn.VerifyNameIndexes()
+		if err != nil {
+			return err
+		}
+		if len(problems) > 0 {
+			return fmt.Errorf("want no index problems, got %v", problems)
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEntitiesWithPrefixIIsLiteralOnce(t *testing.T) {
+	txn := New(memory.New())
+	txn.Partition = 42
+	if err := txn.SetIIs(7, IIs{"http://a/1", "http://a/2", "http://b/1"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := txn.SetIIs(8, IIs{"http://a/3"}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := txn.EntitiesWithPrefixIIsLiteral("http://a/", 0)
+	if err != nil {
+		t.Fatal(err)
+	} else if want := (kv.EntitySlice{7, 8}); !want.Equal(got) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
+
+func TestQuery(t *testing.T) {
+	txn := New(memory.New())
+	txn.Partition = 42
+	// Topics 1 and 2 are both named "John", but only topic 2 has a
+	// "musician" occurrence. Names and occurrences are entities of their own
+	// that refer to their topics.
+	for _, topic := range []struct {
+		E           kv.Entity
+		Names       map[kv.Entity]string
+		Occurrences map[kv.Entity]string
+	}{
+		{1, map[kv.Entity]string{11: "John"}, map[kv.Entity]string{12: "carpenter"}},
+		{2, map[kv.Entity]string{21: "John", 22: "Johnny"}, map[kv.Entity]string{23: "musician"}},
+		{3, map[kv.Entity]string{31: "Paul"}, map[kv.Entity]string{32: "musician"}},
+	} {
+		var tns TopicNames
+		for e, v := range topic.Names {
+			var n Name
+			n.Topic, n.Value = uint64(topic.E), v
+			if err := txn.SetName(e, &n); err != nil {
+				t.Fatal(err)
+			}
+			tns = append(tns, e)
+		}
+		if err := txn.SetTopicNames(topic.E, tns); err != nil {
+			t.Fatal(err)
+		}
+		var tos TopicOccurrences
+		for e, v := range topic.Occurrences {
+			var o Occurrence
+			o.Topic, o.Value = uint64(topic.E), v
+			if err := txn.SetOccurrence(e, &o); err != nil {
+				t.Fatal(err)
+			}
+			tos = append(tos, e)
+		}
+		if err := txn.SetTopicOccurrences(topic.E, tos); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := txn.SetSIs(2, SIs{"https://example.com/john"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		Name string
+		P    query.Predicate
+		Want kv.EntitySlice
+	}{
+		{"names", HasName(), kv.EntitySlice{11, 21, 22, 31}},
+		{"or", query.Or(MatchingNameValue("John"), MatchingNameValue("Paul")), kv.EntitySlice{11, 21, 31}},
+		{"topics named", NameTopicOf(MatchingNameValue("John")), kv.EntitySlice{1, 2}},
+		{"join", query.And(
+			NameTopicOf(MatchingNameValue("John")),
+			OccurrenceTopicOf(MatchingOccurrenceValue("musician")),
+		), kv.EntitySlice{2}},
+		{"join and not", query.And(
+			OccurrenceTopicOf(MatchingOccurrenceValue("musician")),
+			query.Not(NameTopicOf(MatchingNameValue("John"))),
+		), kv.EntitySlice{3}},
+		{"and", query.And(NameTopicOf(MatchingNameValue("John")), HasSIs()), kv.EntitySlice{2}},
+		{"and not", query.And(
+			NameTopicOf(MatchingNameValue("John")),
+			query.Not(MatchingSIsLiteral("https://example.com/john")),
+		), kv.EntitySlice{1}},
+	} {
+		got, err := txn.Query(test.P, nil, 0)
+		if err != nil {
+			t.Errorf("%s: %v", test.Name, err)
+		} else if !test.Want.Equal(got) {
+			t.Errorf("%s: want %v, got %v", test.Name, test.Want, got)
+		}
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	db := memory.NewDB()
+	defer db.Close()
+	txn := db.NewTxn(true)
+	var info TopicMapInfo
+	info.TopicMap = 9
+	if err := New(txn).SetTopicMapInfo(9, &info); err != nil {
+		t.Fatal(err)
+	}
+	if err := txn.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if err := Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	txn = db.NewTxn(false)
+	defer txn.Discard()
+	for _, p := range []kv.Entity{0, 9} {
+		v, err := migrate.Version(kv.Partitioned{Txn: txn, Partition: p})
+		if err != nil {
+			t.Error(err)
+		} else if v != Migrations.Latest() {
+			t.Errorf("partition %v: want version %v, got %v", p, Migrations.Latest(), v)
+		}
+	}
+}
+
+func TestMigrateKeyedIndexLayout(t *testing.T) {
+	txn := New(memory.New())
+	txn.Partition = 9
+	for e, v := range map[kv.Entity]string{1: "John", 2: "Paul", 3: "John"} {
+		var n Name
+		n.Value = v
+		if err := txn.SetName(e, &n); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := txn.SetSIs(3, SIs{"https://example.com/john"}); err != nil {
+		t.Fatal(err)
+	}
+	// Rewrite the indexes as they were stored at version 1.
+	for _, ix := range []struct{ c, ix kv.Component }{
+		{NamePrefix, ValuePrefix},
+		{SIsPrefix, LiteralPrefix},
+	} {
+		if err := txn.ChangeIndexLayout(ix.c, ix.ix, kv.KeyedLayout, kv.SliceLayout); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := migrate.SetVersion(txn.Partitioned, 1); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := txn.EntitiesMatchingNameValue("John"); err != nil {
+		t.Fatal(err)
+	} else if len(got) != 0 {
+		t.Fatalf("want no keyed index rows before migrating, got %v", got)
+	}
+	if err := Migrations.Migrate(txn.Partitioned); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := txn.EntitiesMatchingNameValue("John"); err != nil {
+		t.Error(err)
+	} else if want := (kv.EntitySlice{1, 3}); !want.Equal(got) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+	if got, err := txn.Query(MatchingSIsLiteral("https://example.com/john"), nil, 0); err != nil {
+		t.Error(err)
+	} else if want := (kv.EntitySlice{3}); !want.Equal(got) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
+
+func TestUniqueSIs(t *testing.T) {
+	txn := New(memory.New())
+	txn.Partition = 42
+	if err := txn.SetSIs(7, SIs{"http://a/1", "http://a/2"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := txn.SetSIs(7, SIs{"http://a/2", "http://a/3"}); err != nil {
+		t.Errorf("want the same topic to keep its own SI, got %v", err)
+	}
+	err := txn.SetSIs(8, SIs{"http://b/1", "http://a/3"})
+	if v, ok := err.(kv.UniqueViolation); !ok {
+		t.Fatalf("want a kv.UniqueViolation, got %v", err)
+	} else if v.Entity != 7 || string(v.Value) != "http://a/3" {
+		t.Errorf("want a violation for %q claimed by 7, got %q claimed by %v", "http://a/3", v.Value, v.Entity)
+	}
+	if got, err := txn.GetSIs(8); err != nil {
+		t.Fatal(err)
+	} else if len(got) != 0 {
+		t.Errorf("want no SIs stored for the violating topic, got %v", got)
+	}
+	if got, err := txn.EntitiesMatchingSIsLiteral("http://b/1"); err != nil {
+		t.Fatal(err)
+	} else if len(got) != 0 {
+		t.Errorf("want no index rows for the violating topic, got %v", got)
+	}
+	// SIs are not unique with respect to other components.
+	if err := txn.SetIIs(8, IIs{"http://a/3"}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	txn := New(memory.New())
+	txn.Partition = 42
+	for e, v := range map[kv.Entity]struct {
+		topic kv.Entity
+		value string
+	}{
+		10: {1, "Ada Lovelace"},
+		11: {2, "Charles Babbage"},
+		20: {1, "Wrote the first program for the Analytical Engine."},
+		21: {2, "Designed the Analytical Engine, with help from Ada Lovelace."},
+	} {
+		var err error
+		if e < 20 {
+			var n Name
+			n.Topic, n.Value = uint64(v.topic), v.value
+			err = txn.SetName(e, &n)
+		} else {
+			var o Occurrence
+			o.Topic, o.Value = uint64(v.topic), v.value
+			err = txn.SetOccurrence(e, &o)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, test := range []struct {
+		Query   string
+		Want    []kv.Entity
+		Matches []kv.EntitySlice
+	}{
+		{"analytical engine", []kv.Entity{1, 2}, []kv.EntitySlice{{20}, {21}}},
+		{"lovelace", []kv.Entity{1, 2}, []kv.EntitySlice{{10}, {21}}},
+		{`"ada lovelace"`, []kv.Entity{1, 2}, []kv.EntitySlice{{10}, {21}}},
+		{`"lovelace ada"`, nil, nil},
+		{"babbage", []kv.Entity{2}, []kv.EntitySlice{{11}}},
+	} {
+		rs, err := txn.Search(test.Query, 0)
+		if err != nil {
+			t.Errorf("%q: %v", test.Query, err)
+			continue
+		}
+		var (
+			got     []kv.Entity
+			matches []kv.EntitySlice
+		)
+		for _, r := range rs {
+			got = append(got, r.Topic)
+			matches = append(matches, r.Matches)
+		}
+		if !reflect.DeepEqual(test.Want, got) || !reflect.DeepEqual(test.Matches, matches) {
+			t.Errorf("%q: want %v with matches %v, got %v with matches %v",
+				test.Query, test.Want, test.Matches, got, matches)
+		}
+	}
+	if err := txn.DeleteName(10); err != nil {
+		t.Fatal(err)
+	}
+	if rs, err := txn.Search("lovelace", 0); err != nil {
+		t.Error(err)
+	} else if len(rs) != 1 || rs[0].Topic != 2 {
+		t.Errorf("after deleting a name, want only topic 2, got %v", rs)
+	}
+}
+
+func TestMigrateFullTextIndex(t *testing.T) {
+	txn := New(memory.New())
+	txn.Partition = 9
+	var n Name
+	n.Topic, n.Value = 1, "Ada Lovelace"
+	if err := txn.SetName(10, &n); err != nil {
+		t.Fatal(err)
+	}
+	// Remove the full-text index as it was absent at version 2.
+	if err := fulltext.Update(txn.Partitioned, NamePrefix, TextPrefix, 10, n.IndexText(), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := migrate.SetVersion(txn.Partitioned, 2); err != nil {
+		t.Fatal(err)
+	}
+	if rs, err := txn.Search("ada", 0); err != nil {
+		t.Fatal(err)
+	} else if len(rs) != 0 {
+		t.Fatalf("want no results before migrating, got %v", rs)
+	}
+	if err := Migrations.Migrate(txn.Partitioned); err != nil {
+		t.Fatal(err)
+	}
+	if rs, err := txn.Search("ada", 0); err != nil {
+		t.Error(err)
+	} else if len(rs) != 1 || rs[0].Topic != 1 {
+		t.Errorf("want topic 1, got %v", rs)
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	txn := New(memory.New())
+	txn.Partition = 42
+	for e, v := range map[kv.Entity]struct {
+		topic kv.Entity
+		value string
+	}{
+		10: {1, "John Lennon"},
+		11: {1, "Lennon"},
+		12: {2, "Jack Lemmon"},
+		13: {3, "Paul McCartney"},
+	} {
+		var n Name
+		n.Topic, n.Value = uint64(v.topic), v.value
+		if err := txn.SetName(e, &n); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ss, err := txn.Suggest("Lenon", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []kv.Entity
+	for _, s := range ss {
+		got = append(got, s.Topic)
+	}
+	if want := []kv.Entity{1, 2}; !reflect.DeepEqual(want, got) {
+		t.Fatalf("want topics %v, got %v", want, ss)
+	} else if ss[0].Name != 11 || ss[0].Value != "Lennon" {
+		t.Errorf("want the most similar name of topic 1, got %+v", ss[0])
+	}
+	if ss, err := txn.Suggest("Lenon", 1); err != nil {
+		t.Error(err)
+	} else if len(ss) != 1 {
+		t.Errorf("want one suggestion, got %v", ss)
+	}
+}
+
+func TestMigrateTrigramIndex(t *testing.T) {
+	txn := New(memory.New())
+	txn.Partition = 9
+	var n Name
+	n.Topic, n.Value = 1, "John Lennon"
+	if err := txn.SetName(10, &n); err != nil {
+		t.Fatal(err)
+	}
+	// Remove the trigram index as it was absent at version 3.
+	if err := trigram.Update(txn.Partitioned, NamePrefix, SpellingPrefix, 10, n.IndexSpelling(), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := migrate.SetVersion(txn.Partitioned, 3); err != nil {
+		t.Fatal(err)
+	}
+	if ss, err := txn.Suggest("Lenon", 0); err != nil {
+		t.Fatal(err)
+	} else if len(ss) != 0 {
+		t.Fatalf("want no suggestions before migrating, got %v", ss)
+	}
+	if err := Migrations.Migrate(txn.Partitioned); err != nil {
+		t.Fatal(err)
+	}
+	if ss, err := txn.Suggest("Lenon", 0); err != nil {
+		t.Error(err)
+	} else if len(ss) != 1 || ss[0].Topic != 1 {
+		t.Errorf("want topic 1, got %v", ss)
+	}
+}
+
+func TestEntitiesByNameSortKey(t *testing.T) {
+	txn := New(memory.New())
+	txn.Partition = 42
+	for e, v := range map[kv.Entity]string{1: "Zoe", 2: "apple", 3: "Émile", 4: "Zebra", 5: "Apple"} {
+		var n Name
+		n.Value = v
+		if err := txn.SetName(e, &n); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, err := txn.EntitiesByNameSortKey(&kv.IndexCursor{}, 10); err != nil {
+		t.Error(err)
+	} else if want := (kv.EntitySlice{2, 5, 3, 4, 1}); !want.Equal(got) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+	if got, err := txn.EntitiesMatchingNameSortKey(Collator.Key("APPLE")); err != nil {
+		t.Error(err)
+	} else if want := (kv.EntitySlice{2, 5}); !want.Equal(got) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+	for _, test := range []struct {
+		Prefix string
+		Want   kv.EntitySlice
+	}{
+		{"z", kv.EntitySlice{4, 1}},
+		{"ÉM", kv.EntitySlice{3}},
+		{"em", kv.EntitySlice{3}},
+		{"APP", kv.EntitySlice{2, 5}},
+		{"àp", kv.EntitySlice{2, 5}},
+	} {
+		if got, err := txn.EntitiesWithPrefixNameSortKey(Collator.Prefix(test.Prefix), 0); err != nil {
+			t.Error(err)
+		} else if !test.Want.Equal(got) {
+			t.Errorf("prefix %q: want %v, got %v", test.Prefix, test.Want, got)
+		}
+	}
+}
+
+func TestMigrateCollationIndex(t *testing.T) {
+	txn := New(memory.New())
+	txn.Partition = 9
+	var n Name
+	n.Value = "Émile"
+	if err := txn.SetName(10, &n); err != nil {
+		t.Fatal(err)
+	}
+	// Remove the collation index row as it was absent at version 4.
+	key := append(kv.Prefix(txn.Partition.Encode()).AppendComponent(NamePrefix).ConcatEntityComponent(0, SortKeyPrefix),
+		kv.AppendKeyedIndexValue(nil, Collator.Key("Émile"))...)
+	if err := txn.Delete(append(key, kv.Entity(10).Encode()...)); err != nil {
+		t.Fatal(err)
+	}
+	if err := migrate.SetVersion(txn.Partitioned, 4); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := txn.EntitiesMatchingNameSortKey(Collator.Key("emile")); err != nil {
+		t.Fatal(err)
+	} else if len(got) != 0 {
+		t.Fatalf("want no index rows before migrating, got %v", got)
+	}
+	if err := Migrations.Migrate(txn.Partitioned); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := txn.EntitiesMatchingNameSortKey(Collator.Key("emile")); err != nil {
+		t.Error(err)
+	} else if want := (kv.EntitySlice{10}); !want.Equal(got) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
+
+func TestDuplicateTopicMap(t *testing.T) {
+	txn := New(memory.New())
+	info, err := createTopicMap(&txn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := kv.Entity(info.TopicMap)
+	txn.Partition = src
+	// The reifier of the topic map shares its entity, and topic 7 does not.
+	var n Name
+	n.Topic, n.Value = uint64(src), "Ada Lovelace"
+	if err = txn.SetName(20, &n); err != nil {
+		t.Fatal(err)
+	} else if err = txn.SetTopicNames(src, TopicNames{20}); err != nil {
+		t.Fatal(err)
+	} else if err = txn.SetIIs(src, IIs{"http://a/map"}); err != nil {
+		t.Fatal(err)
+	} else if err = txn.SetSIs(7, SIs{"http://a/7"}); err != nil {
+		t.Fatal(err)
+	}
+	dst, err := txn.DuplicateTopicMap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	txn.Partition = 0
+	if got, err := txn.AllTopicMapInfoEntities(nil, 0); err != nil {
+		t.Fatal(err)
+	} else if want := []kv.Entity{src, dst}; !reflect.DeepEqual(want, got) {
+		t.Errorf("want topic maps %v, got %v", want, got)
+	}
+	for _, e := range []kv.Entity{src, dst} {
+		txn.Partition = e
+		if got, err := txn.GetTopicNames(e); err != nil {
+			t.Fatal(err)
+		} else if !reflect.DeepEqual(TopicNames{20}, got) {
+			t.Errorf("%v: want names %v, got %v", e, TopicNames{20}, got)
+		}
+		if got, err := txn.GetName(20); err != nil {
+			t.Fatal(err)
+		} else if kv.Entity(got.Topic) != e {
+			t.Errorf("%v: want name of topic %v, got %v", e, e, got.Topic)
+		}
+		if got, err := txn.EntitiesMatchingIIsLiteral("http://a/map"); err != nil {
+			t.Fatal(err)
+		} else if !reflect.DeepEqual(kv.EntitySlice{e}, got) {
+			t.Errorf("%v: want IIs of %v, got %v", e, e, got)
+		}
+		if got, err := txn.EntitiesMatchingSIsLiteral("http://a/7"); err != nil {
+			t.Fatal(err)
+		} else if !reflect.DeepEqual(kv.EntitySlice{7}, got) {
+			t.Errorf("%v: want SIs of 7, got %v", e, got)
+		}
+		if rs, err := txn.Search("lovelace", 0); err != nil {
+			t.Fatal(err)
+		} else if len(rs) != 1 || rs[0].Topic != e {
+			t.Errorf("%v: want to find topic %v, got %v", e, e, rs)
+		}
+	}
+}
+
+func TestDeleteTopicMap(t *testing.T) {
+	txn := New(memory.New())
+	var tms []kv.Entity
+	for i := 0; i < 2; i++ {
+		info, err := createTopicMap(&txn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tms = append(tms, kv.Entity(info.TopicMap))
+	}
+	txn.Partition = tms[0]
+	if err := txn.SetIIs(tms[0], IIs{"http://a/map"}); err != nil {
+		t.Fatal(err)
+	} else if err = txn.DeleteTopicMap(); err != nil {
+		t.Fatal(err)
+	}
+	if stats, err := txn.Stats(); err != nil {
+		t.Fatal(err)
+	} else if stats.Keys != 0 {
+		t.Errorf("want an empty partition, got %+v", stats)
+	}
+	txn.Partition = 0
+	if got, err := txn.AllTopicMapInfoEntities(nil, 0); err != nil {
+		t.Fatal(err)
+	} else if want := tms[1:]; !reflect.DeepEqual(want, got) {
+		t.Errorf("want topic maps %v, got %v", want, got)
+	}
+}
+
+// deleteIndexRows deletes every row of index ix of component c.
+func deleteIndexRows(t *testing.T, txn Txn, c, ix kv.Component) {
+	prefix := kv.Prefix(txn.Partition.Encode()).AppendComponent(c).ConcatEntityComponent(0, ix)
+	var keys [][]byte
+	iter := txn.PrefixIterator(prefix)
+	for iter.Seek(nil); iter.Valid(); iter.Next() {
+		keys = append(keys, append(append([]byte(nil), prefix...), iter.Key()...))
+	}
+	iter.Discard()
+	if len(keys) == 0 {
+		t.Fatalf("want rows in index %v of component %v, got none", ix, c)
+	}
+	for _, key := range keys {
+		if err := txn.Delete(key); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestVerifyRebuildIndexes(t *testing.T) {
+	db := kvtest.NewDB(t)
+	defer db.Close()
+	// Each step runs in a transaction of its own, so that the indexes are
+	// read back from storage.
+	step := func(f func(txn Txn) error) {
+		t.Helper()
+		if err := kv.Update(context.Background(), db, func(t kv.Txn) error {
+			txn := New(t)
+			txn.Partition = 42
+			return f(txn)
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	verify := func(txn Txn) error {
+		if ps, err := txn.VerifyIndexes(); err != nil {
+			return err
+		} else if len(ps) != 0 {
+			return fmt.Errorf("want no index problems, got %v", ps)
+		}
+		return nil
+	}
+	var (
+		n Name
+		o Occurrence
+	)
+	n.Topic, n.Value = 1, "Ada Byron"
+	o.Topic, o.Value = 1, "Wrote the first program."
+	step(func(txn Txn) error {
+		if err := txn.SetName(10, &n); err != nil {
+			return err
+		} else if err = txn.SetOccurrence(20, &o); err != nil {
+			return err
+		}
+		return txn.SetSIs(1, SIs{"http://a/1"})
+	})
+	step(verify)
+	// Renaming replaces rows in every index of Name.
+	n.Value = "Ada Lovelace"
+	step(func(txn Txn) error { return txn.SetName(10, &n) })
+	step(verify)
+	step(func(txn Txn) error {
+		deleteIndexRows(t, txn, NamePrefix, ValuePrefix)
+		deleteIndexRows(t, txn, NamePrefix, TextPrefix)
+		deleteIndexRows(t, txn, NamePrefix, SpellingPrefix)
+		orphan := kv.Prefix(txn.Partition.Encode()).AppendComponent(SIsPrefix).ConcatEntityComponent(0, LiteralPrefix)
+		orphan = append(kv.AppendKeyedIndexValue(orphan, kv.String("http://b/1").Encode()), kv.Entity(99).Encode()...)
+		return txn.Set(orphan, []byte{})
+	})
+	step(func(txn Txn) error {
+		ps, err := txn.VerifyIndexes()
+		if err != nil {
+			return err
+		}
+		type index struct{ c, ix kv.Component }
+		got := make(map[index]bool)
+		for _, p := range ps {
+			got[index{p.Component, p.Index}] = true
+			if p.Component == SIsPrefix && !p.Orphaned() {
+				t.Errorf("want only an orphaned SIs row, got %v", p)
+			} else if p.Component == NamePrefix && !p.Missing() {
+				t.Errorf("want only missing Name rows, got %v", p)
+			}
+		}
+		want := map[index]bool{
+			{NamePrefix, ValuePrefix}:    true,
+			{NamePrefix, TextPrefix}:     true,
+			{NamePrefix, SpellingPrefix}: true,
+			{SIsPrefix, LiteralPrefix}:   true,
+		}
+		if !reflect.DeepEqual(want, got) {
+			t.Errorf("want problems with indexes %v, got %v", want, ps)
+		}
+		return nil
+	})
+	b := kv.NewBatch(db)
+	txn := New(b)
+	txn.Partition = 42
+	if err := txn.RebuildIndexes(); err != nil {
+		t.Fatal(err)
+	} else if err = b.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	b.Discard()
+	step(verify)
+	step(func(txn Txn) error {
+		if rs, err := txn.Search("lovelace", 0); err != nil {
+			return err
+		} else if len(rs) != 1 || rs[0].Topic != 1 {
+			t.Errorf("want to find topic 1 after rebuilding indexes, got %v", rs)
+		}
+		if rs, err := txn.Search("byron", 0); err != nil {
+			return err
+		} else if len(rs) != 0 {
+			t.Errorf("want not to find the old name after rebuilding indexes, got %v", rs)
+		}
+		if ss, err := txn.Suggest("Lovelase", 0); err != nil {
+			return err
+		} else if len(ss) != 1 || ss[0].Name != 10 {
+			t.Errorf("want to suggest name 10 after rebuilding indexes, got %v", ss)
+		}
+		if es, err := txn.EntitiesMatchingSIsLiteral("http://b/1"); err != nil {
+			return err
+		} else if len(es) != 0 {
+			t.Errorf("want no orphaned SIs after rebuilding indexes, got %v", es)
+		}
+		return nil
+	})
+}
+
+func TestModel(t *testing.T) {
+	literals := []string{"a", "b", "c", "A"}
+	strings := func(r *rand.Rand) []string {
+		ss := make([]string, r.Intn(3))
+		for i := range ss {
+			ss[i] = literals[r.Intn(len(literals))]
+		}
+		return ss
+	}
+	m := kvtest.Model{
+		New: New,
+		Values: map[string]func(*rand.Rand) interface{}{
+			"IIs": func(r *rand.Rand) interface{} { return IIs(strings(r)) },
+			"SIs": func(r *rand.Rand) interface{} { return SIs(strings(r)) },
+			"SLs": func(r *rand.Rand) interface{} { return SLs(strings(r)) },
+			"Name": func(r *rand.Rand) interface{} {
+				n := &Name{}
+				n.Value = literals[r.Intn(len(literals))]
+				return n
+			},
+			"Occurrence": func(r *rand.Rand) interface{} {
+				o := &Occurrence{}
+				o.Value = literals[r.Intn(len(literals))]
+				return o
+			},
+			"TopicNames": func(r *rand.Rand) interface{} {
+				return TopicNames{kv.Entity(r.Intn(3)), kv.Entity(r.Intn(3))}
+			},
+		},
+	}
+	for _, backend := range []struct {
+		Name  string
+		NewDB func(testing.TB) kv.DB
+	}{
+		{"memory", nil},
+		{"badger", kvtest.NewDB},
+		{"bolt", bolttest.NewDB},
+	} {
+		backend := backend
+		t.Run(backend.Name, func(t *testing.T) {
+			m := m
+			if backend.NewDB != nil {
+				m.NewDB = func() kv.DB { return backend.NewDB(t) }
+			}
+			kvtest.RunModel(t, m)
+		})
+	}
+}
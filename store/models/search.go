@@ -0,0 +1,151 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"sort"
+
+	"github.com/google/note-maps/kv"
+)
+
+// NameWeight is the factor by which Search multiplies the scores of matching
+// names, relative to those of matching occurrences.
+const NameWeight = 2
+
+// SearchResult is a topic found by Search.
+type SearchResult struct {
+	Topic kv.Entity
+
+	// Score is greater for topics that are more relevant to the query.
+	Score float64
+
+	// Matches holds the names and occurrences of Topic that match the query.
+	Matches kv.EntitySlice
+}
+
+// Search returns up to n topics with names or occurrences that match query,
+// with the most relevant topics first.
+//
+// Multiple words in query must all be found in the same name or occurrence,
+// and words enclosed in double quotes must be found together as a phrase.
+// The score of each topic is the sum of the scores of its matching names and
+// occurrences, where names are weighted by NameWeight.
+//
+// A value of n less than or equal to zero will be interpretted as the largest
+// possible value.
+func (s Txn) Search(query string, n int) ([]SearchResult, error) {
+	names, err := s.SearchNameText(query, 0)
+	if err != nil {
+		return nil, err
+	}
+	occurrences, err := s.SearchOccurrenceText(query, 0)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		results []SearchResult
+		byTopic = make(map[kv.Entity]int)
+	)
+	add := func(topic, match kv.Entity, score float64) {
+		i, ok := byTopic[topic]
+		if !ok {
+			i = len(results)
+			byTopic[topic] = i
+			results = append(results, SearchResult{Topic: topic})
+		}
+		results[i].Score += score
+		results[i].Matches.Insert(match)
+	}
+	for _, r := range names {
+		name, err := s.GetName(r.Entity)
+		if err != nil {
+			return nil, err
+		}
+		add(kv.Entity(name.GetTopic()), r.Entity, r.Score*NameWeight)
+	}
+	for _, r := range occurrences {
+		occurrence, err := s.GetOccurrence(r.Entity)
+		if err != nil {
+			return nil, err
+		}
+		add(kv.Entity(occurrence.GetTopic()), r.Entity, r.Score)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Topic < results[j].Topic
+	})
+	if n > 0 && len(results) > n {
+		results = results[:n]
+	}
+	return results, nil
+}
+
+// Suggestion is a topic with a name similar to the one passed to Suggest.
+type Suggestion struct {
+	Topic kv.Entity
+
+	// Name is the name of Topic that is most similar, and Value is its
+	// value.
+	Name  kv.Entity
+	Value string
+
+	// Score is the similarity of Value to the name passed to Suggest, from
+	// zero to one for names with the same normalized form.
+	Score float64
+}
+
+// Suggest returns up to n topics with names similar to name, with the most
+// similar first, so that a misspelled name can be answered with "did you
+// mean" suggestions.
+//
+// Names are ranked by trigram.Similarity, which combines the trigrams they
+// share with name and their edit distance from it. Each topic is suggested
+// once, for its most similar name.
+//
+// A value of n less than or equal to zero will be interpretted as the largest
+// possible value.
+func (s Txn) Suggest(name string, n int) ([]Suggestion, error) {
+	similar, err := s.SimilarNameSpelling(name, 0)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		suggestions []Suggestion
+		seen        = make(map[kv.Entity]bool)
+	)
+	for _, r := range similar {
+		if n > 0 && len(suggestions) == n {
+			break
+		}
+		v, err := s.GetName(r.Entity)
+		if err != nil {
+			return nil, err
+		}
+		topic := kv.Entity(v.GetTopic())
+		if seen[topic] {
+			continue
+		}
+		seen[topic] = true
+		suggestions = append(suggestions, Suggestion{
+			Topic: topic,
+			Name:  r.Entity,
+			Value: r.Value,
+			Score: r.Score,
+		})
+	}
+	return suggestions, nil
+}
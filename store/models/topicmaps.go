@@ -0,0 +1,100 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/google/note-maps/kv"
+)
+
+// DuplicateTopicMap copies the topic map in s.Partition into the partition of
+// a newly allocated entity, describes the copy with a TopicMapInfo in
+// partition zero, and returns the entity of the copy.
+//
+// The topic that reifies a topic map shares its entity, so references to it
+// are rewritten to refer to the copy. Other topics, names and occurrences keep
+// their entities, which are unique only within their partitions.
+func (s Txn) DuplicateTopicMap() (kv.Entity, error) {
+	src := s.Partition
+	dst, err := s.Alloc()
+	if err != nil {
+		return 0, err
+	}
+	if err = s.CopyTo(dst, rewriteTopicMap(src, dst)); err != nil {
+		return 0, err
+	}
+	var info TopicMapInfo
+	info.TopicMap = uint64(dst)
+	if err = (Txn{kv.Partitioned{Txn: s.Txn, Partition: 0}}).SetTopicMapInfo(dst, &info); err != nil {
+		return 0, err
+	}
+	return dst, nil
+}
+
+// DeleteTopicMap deletes the topic map in s.Partition, along with its
+// TopicMapInfo in partition zero.
+func (s Txn) DeleteTopicMap() error {
+	if err := s.Clear(); err != nil {
+		return err
+	}
+	return Txn{kv.Partitioned{Txn: s.Txn, Partition: 0}}.DeleteTopicMapInfo(s.Partition)
+}
+
+// rewriteTopicMap returns a function for use with kv.Partitioned.CopyTo that
+// replaces references to the reifier of topic map src with references to the
+// reifier of topic map dst.
+func rewriteTopicMap(src, dst kv.Entity) func(key, value []byte) ([]byte, []byte, error) {
+	from, to := src.Encode(), dst.Encode()
+	zero := kv.Entity(0).Encode()
+	return func(key, value []byte) ([]byte, []byte, error) {
+		if len(key) < 10 {
+			return key, value, nil
+		}
+		switch kv.Component(binary.BigEndian.Uint16(key)) {
+		case IIsPrefix, SIsPrefix, SLsPrefix, TopicNamesPrefix, TopicOccurrencesPrefix:
+			switch {
+			case bytes.Equal(key[2:10], from):
+				// A component value of the reifier.
+				key = append(append(append([]byte(nil), key[:2]...), to...), key[10:]...)
+			case bytes.Equal(key[2:10], zero) && len(key) > 20 && bytes.HasSuffix(key, from):
+				// An index row of a component value of the reifier.
+				key = append(append([]byte(nil), key[:len(key)-8]...), to...)
+			}
+		case NamePrefix:
+			if len(key) == 10 && !bytes.Equal(key[2:10], zero) {
+				var n Name
+				if err := n.Decode(value); err != nil {
+					return nil, nil, err
+				} else if n.Topic == uint64(src) {
+					n.Topic = uint64(dst)
+					value = n.Encode()
+				}
+			}
+		case OccurrencePrefix:
+			if len(key) == 10 && !bytes.Equal(key[2:10], zero) {
+				var o Occurrence
+				if err := o.Decode(value); err != nil {
+					return nil, nil, err
+				} else if o.Topic == uint64(src) {
+					o.Topic = uint64(dst)
+					value = o.Encode()
+				}
+			}
+		}
+		return key, value, nil
+	}
+}
@@ -30,7 +30,7 @@ func (m *TopicMap) Reset()         { *m = TopicMap{} }
 func (m *TopicMap) String() string { return proto.CompactTextString(m) }
 func (*TopicMap) ProtoMessage()    {}
 func (*TopicMap) Descriptor() ([]byte, []int) {
-	return fileDescriptor_pb_6653ae2df246e174, []int{0}
+	return fileDescriptor_pb_f80abaa17e25ccc8, []int{0}
 }
 func (m *TopicMap) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_TopicMap.Unmarshal(m, b)
@@ -78,7 +78,7 @@ func (m *Topic) Reset()         { *m = Topic{} }
 func (m *Topic) String() string { return proto.CompactTextString(m) }
 func (*Topic) ProtoMessage()    {}
 func (*Topic) Descriptor() ([]byte, []int) {
-	return fileDescriptor_pb_6653ae2df246e174, []int{1}
+	return fileDescriptor_pb_f80abaa17e25ccc8, []int{1}
 }
 func (m *Topic) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_Topic.Unmarshal(m, b)
@@ -139,7 +139,7 @@ func (m *Name) Reset()         { *m = Name{} }
 func (m *Name) String() string { return proto.CompactTextString(m) }
 func (*Name) ProtoMessage()    {}
 func (*Name) Descriptor() ([]byte, []int) {
-	return fileDescriptor_pb_6653ae2df246e174, []int{2}
+	return fileDescriptor_pb_f80abaa17e25ccc8, []int{2}
 }
 func (m *Name) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_Name.Unmarshal(m, b)
@@ -193,7 +193,7 @@ func (m *Occurrence) Reset()         { *m = Occurrence{} }
 func (m *Occurrence) String() string { return proto.CompactTextString(m) }
 func (*Occurrence) ProtoMessage()    {}
 func (*Occurrence) Descriptor() ([]byte, []int) {
-	return fileDescriptor_pb_6653ae2df246e174, []int{3}
+	return fileDescriptor_pb_f80abaa17e25ccc8, []int{3}
 }
 func (m *Occurrence) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_Occurrence.Unmarshal(m, b)
@@ -244,7 +244,7 @@ func (m *GetTopicMapsRequest) Reset()         { *m = GetTopicMapsRequest{} }
 func (m *GetTopicMapsRequest) String() string { return proto.CompactTextString(m) }
 func (*GetTopicMapsRequest) ProtoMessage()    {}
 func (*GetTopicMapsRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_pb_6653ae2df246e174, []int{4}
+	return fileDescriptor_pb_f80abaa17e25ccc8, []int{4}
 }
 func (m *GetTopicMapsRequest) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_GetTopicMapsRequest.Unmarshal(m, b)
@@ -275,7 +275,7 @@ func (m *GetTopicMapsResponse) Reset()         { *m = GetTopicMapsResponse{} }
 func (m *GetTopicMapsResponse) String() string { return proto.CompactTextString(m) }
 func (*GetTopicMapsResponse) ProtoMessage()    {}
 func (*GetTopicMapsResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_pb_6653ae2df246e174, []int{5}
+	return fileDescriptor_pb_f80abaa17e25ccc8, []int{5}
 }
 func (m *GetTopicMapsResponse) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_GetTopicMapsResponse.Unmarshal(m, b)
@@ -312,7 +312,7 @@ func (m *CreateTopicMapRequest) Reset()         { *m = CreateTopicMapRequest{} }
 func (m *CreateTopicMapRequest) String() string { return proto.CompactTextString(m) }
 func (*CreateTopicMapRequest) ProtoMessage()    {}
 func (*CreateTopicMapRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_pb_6653ae2df246e174, []int{6}
+	return fileDescriptor_pb_f80abaa17e25ccc8, []int{6}
 }
 func (m *CreateTopicMapRequest) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_CreateTopicMapRequest.Unmarshal(m, b)
@@ -343,7 +343,7 @@ func (m *CreateTopicMapResponse) Reset()         { *m = CreateTopicMapResponse{}
 func (m *CreateTopicMapResponse) String() string { return proto.CompactTextString(m) }
 func (*CreateTopicMapResponse) ProtoMessage()    {}
 func (*CreateTopicMapResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_pb_6653ae2df246e174, []int{7}
+	return fileDescriptor_pb_f80abaa17e25ccc8, []int{7}
 }
 func (m *CreateTopicMapResponse) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_CreateTopicMapResponse.Unmarshal(m, b)
@@ -370,6 +370,242 @@ func (m *CreateTopicMapResponse) GetTopicMap() *TopicMap {
 	return nil
 }
 
+type DuplicateTopicMapRequest struct {
+	TopicMapId           uint64   `protobuf:"varint,1,opt,name=topic_map_id,json=topicMapId,proto3" json:"topic_map_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DuplicateTopicMapRequest) Reset()         { *m = DuplicateTopicMapRequest{} }
+func (m *DuplicateTopicMapRequest) String() string { return proto.CompactTextString(m) }
+func (*DuplicateTopicMapRequest) ProtoMessage()    {}
+func (*DuplicateTopicMapRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_pb_f80abaa17e25ccc8, []int{8}
+}
+func (m *DuplicateTopicMapRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DuplicateTopicMapRequest.Unmarshal(m, b)
+}
+func (m *DuplicateTopicMapRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DuplicateTopicMapRequest.Marshal(b, m, deterministic)
+}
+func (dst *DuplicateTopicMapRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DuplicateTopicMapRequest.Merge(dst, src)
+}
+func (m *DuplicateTopicMapRequest) XXX_Size() int {
+	return xxx_messageInfo_DuplicateTopicMapRequest.Size(m)
+}
+func (m *DuplicateTopicMapRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_DuplicateTopicMapRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DuplicateTopicMapRequest proto.InternalMessageInfo
+
+func (m *DuplicateTopicMapRequest) GetTopicMapId() uint64 {
+	if m != nil {
+		return m.TopicMapId
+	}
+	return 0
+}
+
+type DuplicateTopicMapResponse struct {
+	TopicMap             *TopicMap `protobuf:"bytes,1,opt,name=topic_map,json=topicMap,proto3" json:"topic_map,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *DuplicateTopicMapResponse) Reset()         { *m = DuplicateTopicMapResponse{} }
+func (m *DuplicateTopicMapResponse) String() string { return proto.CompactTextString(m) }
+func (*DuplicateTopicMapResponse) ProtoMessage()    {}
+func (*DuplicateTopicMapResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_pb_f80abaa17e25ccc8, []int{9}
+}
+func (m *DuplicateTopicMapResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DuplicateTopicMapResponse.Unmarshal(m, b)
+}
+func (m *DuplicateTopicMapResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DuplicateTopicMapResponse.Marshal(b, m, deterministic)
+}
+func (dst *DuplicateTopicMapResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DuplicateTopicMapResponse.Merge(dst, src)
+}
+func (m *DuplicateTopicMapResponse) XXX_Size() int {
+	return xxx_messageInfo_DuplicateTopicMapResponse.Size(m)
+}
+func (m *DuplicateTopicMapResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_DuplicateTopicMapResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DuplicateTopicMapResponse proto.InternalMessageInfo
+
+func (m *DuplicateTopicMapResponse) GetTopicMap() *TopicMap {
+	if m != nil {
+		return m.TopicMap
+	}
+	return nil
+}
+
+type DeleteTopicMapRequest struct {
+	TopicMapId           uint64   `protobuf:"varint,1,opt,name=topic_map_id,json=topicMapId,proto3" json:"topic_map_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DeleteTopicMapRequest) Reset()         { *m = DeleteTopicMapRequest{} }
+func (m *DeleteTopicMapRequest) String() string { return proto.CompactTextString(m) }
+func (*DeleteTopicMapRequest) ProtoMessage()    {}
+func (*DeleteTopicMapRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_pb_f80abaa17e25ccc8, []int{10}
+}
+func (m *DeleteTopicMapRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DeleteTopicMapRequest.Unmarshal(m, b)
+}
+func (m *DeleteTopicMapRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DeleteTopicMapRequest.Marshal(b, m, deterministic)
+}
+func (dst *DeleteTopicMapRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DeleteTopicMapRequest.Merge(dst, src)
+}
+func (m *DeleteTopicMapRequest) XXX_Size() int {
+	return xxx_messageInfo_DeleteTopicMapRequest.Size(m)
+}
+func (m *DeleteTopicMapRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_DeleteTopicMapRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DeleteTopicMapRequest proto.InternalMessageInfo
+
+func (m *DeleteTopicMapRequest) GetTopicMapId() uint64 {
+	if m != nil {
+		return m.TopicMapId
+	}
+	return 0
+}
+
+type DeleteTopicMapResponse struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DeleteTopicMapResponse) Reset()         { *m = DeleteTopicMapResponse{} }
+func (m *DeleteTopicMapResponse) String() string { return proto.CompactTextString(m) }
+func (*DeleteTopicMapResponse) ProtoMessage()    {}
+func (*DeleteTopicMapResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_pb_f80abaa17e25ccc8, []int{11}
+}
+func (m *DeleteTopicMapResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DeleteTopicMapResponse.Unmarshal(m, b)
+}
+func (m *DeleteTopicMapResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DeleteTopicMapResponse.Marshal(b, m, deterministic)
+}
+func (dst *DeleteTopicMapResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DeleteTopicMapResponse.Merge(dst, src)
+}
+func (m *DeleteTopicMapResponse) XXX_Size() int {
+	return xxx_messageInfo_DeleteTopicMapResponse.Size(m)
+}
+func (m *DeleteTopicMapResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_DeleteTopicMapResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DeleteTopicMapResponse proto.InternalMessageInfo
+
+type GetTopicMapStatsRequest struct {
+	TopicMapId           uint64   `protobuf:"varint,1,opt,name=topic_map_id,json=topicMapId,proto3" json:"topic_map_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetTopicMapStatsRequest) Reset()         { *m = GetTopicMapStatsRequest{} }
+func (m *GetTopicMapStatsRequest) String() string { return proto.CompactTextString(m) }
+func (*GetTopicMapStatsRequest) ProtoMessage()    {}
+func (*GetTopicMapStatsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_pb_f80abaa17e25ccc8, []int{12}
+}
+func (m *GetTopicMapStatsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetTopicMapStatsRequest.Unmarshal(m, b)
+}
+func (m *GetTopicMapStatsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetTopicMapStatsRequest.Marshal(b, m, deterministic)
+}
+func (dst *GetTopicMapStatsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetTopicMapStatsRequest.Merge(dst, src)
+}
+func (m *GetTopicMapStatsRequest) XXX_Size() int {
+	return xxx_messageInfo_GetTopicMapStatsRequest.Size(m)
+}
+func (m *GetTopicMapStatsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetTopicMapStatsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetTopicMapStatsRequest proto.InternalMessageInfo
+
+func (m *GetTopicMapStatsRequest) GetTopicMapId() uint64 {
+	if m != nil {
+		return m.TopicMapId
+	}
+	return 0
+}
+
+type GetTopicMapStatsResponse struct {
+	Keys                 uint64   `protobuf:"varint,1,opt,name=keys,proto3" json:"keys,omitempty"`
+	KeyBytes             uint64   `protobuf:"varint,2,opt,name=key_bytes,json=keyBytes,proto3" json:"key_bytes,omitempty"`
+	ValueBytes           uint64   `protobuf:"varint,3,opt,name=value_bytes,json=valueBytes,proto3" json:"value_bytes,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetTopicMapStatsResponse) Reset()         { *m = GetTopicMapStatsResponse{} }
+func (m *GetTopicMapStatsResponse) String() string { return proto.CompactTextString(m) }
+func (*GetTopicMapStatsResponse) ProtoMessage()    {}
+func (*GetTopicMapStatsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_pb_f80abaa17e25ccc8, []int{13}
+}
+func (m *GetTopicMapStatsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetTopicMapStatsResponse.Unmarshal(m, b)
+}
+func (m *GetTopicMapStatsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetTopicMapStatsResponse.Marshal(b, m, deterministic)
+}
+func (dst *GetTopicMapStatsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetTopicMapStatsResponse.Merge(dst, src)
+}
+func (m *GetTopicMapStatsResponse) XXX_Size() int {
+	return xxx_messageInfo_GetTopicMapStatsResponse.Size(m)
+}
+func (m *GetTopicMapStatsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetTopicMapStatsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetTopicMapStatsResponse proto.InternalMessageInfo
+
+func (m *GetTopicMapStatsResponse) GetKeys() uint64 {
+	if m != nil {
+		return m.Keys
+	}
+	return 0
+}
+
+func (m *GetTopicMapStatsResponse) GetKeyBytes() uint64 {
+	if m != nil {
+		return m.KeyBytes
+	}
+	return 0
+}
+
+func (m *GetTopicMapStatsResponse) GetValueBytes() uint64 {
+	if m != nil {
+		return m.ValueBytes
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterType((*TopicMap)(nil), "TopicMap")
 	proto.RegisterType((*Topic)(nil), "Topic")
@@ -379,29 +615,41 @@ func init() {
 	proto.RegisterType((*GetTopicMapsResponse)(nil), "GetTopicMapsResponse")
 	proto.RegisterType((*CreateTopicMapRequest)(nil), "CreateTopicMapRequest")
 	proto.RegisterType((*CreateTopicMapResponse)(nil), "CreateTopicMapResponse")
-}
-
-func init() { proto.RegisterFile("pb.proto", fileDescriptor_pb_6653ae2df246e174) }
-
-var fileDescriptor_pb_6653ae2df246e174 = []byte{
-	// 289 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x92, 0x3f, 0x4f, 0xc3, 0x30,
-	0x10, 0xc5, 0xe5, 0x26, 0xa9, 0x92, 0x0b, 0x62, 0x30, 0x2d, 0x58, 0x2a, 0x43, 0xe4, 0x01, 0x65,
-	0x21, 0x43, 0x59, 0x18, 0x91, 0x18, 0x50, 0x06, 0xa8, 0x64, 0xb1, 0x57, 0x6e, 0x72, 0x43, 0x24,
-	0x92, 0x18, 0xdb, 0xe1, 0x1b, 0xf0, 0xbd, 0x51, 0x9d, 0xc6, 0xa5, 0x74, 0x65, 0xf4, 0x7b, 0xef,
-	0x7e, 0xf7, 0x47, 0x86, 0x58, 0xed, 0x0a, 0xa5, 0x7b, 0xdb, 0xf3, 0x47, 0x88, 0xdf, 0x7b, 0xd5,
-	0x54, 0xaf, 0x52, 0xd1, 0x4b, 0x98, 0x35, 0x35, 0x23, 0x19, 0xc9, 0x43, 0x31, 0x6b, 0x6a, 0x7a,
-	0x0b, 0x91, 0xdd, 0x7b, 0x6c, 0x96, 0x91, 0x3c, 0x5d, 0xcf, 0x0b, 0x97, 0x14, 0xa3, 0xc8, 0xbf,
-	0x09, 0x44, 0x4e, 0x38, 0xab, 0xcb, 0xe0, 0xc2, 0x45, 0xb6, 0xad, 0x54, 0xdb, 0xa6, 0x76, 0xe5,
-	0xa1, 0x00, 0x7b, 0xe8, 0x53, 0xd6, 0x74, 0x05, 0x51, 0x27, 0x5b, 0x34, 0x2c, 0xc8, 0x82, 0x3c,
-	0x5d, 0x47, 0xc5, 0x9b, 0x6c, 0x51, 0x8c, 0x1a, 0xbd, 0x87, 0xb4, 0xaf, 0xaa, 0x41, 0x6b, 0xec,
-	0x2a, 0x34, 0x2c, 0x74, 0x91, 0xb4, 0xd8, 0x78, 0x4d, 0xfc, 0xf6, 0x79, 0x09, 0xe1, 0xbe, 0xfa,
-	0x6c, 0x8a, 0x15, 0x24, 0x4a, 0x6a, 0xec, 0xec, 0x71, 0x84, 0x78, 0x14, 0xca, 0x9a, 0x2e, 0x20,
-	0xfa, 0x92, 0x1f, 0x03, 0xb2, 0x20, 0x23, 0x79, 0x22, 0xc6, 0x07, 0xdf, 0x00, 0x1c, 0xbb, 0xfc,
-	0x07, 0x70, 0x09, 0x57, 0x2f, 0x68, 0xa7, 0x03, 0x1b, 0x81, 0x9f, 0x03, 0x1a, 0xcb, 0x9f, 0x60,
-	0x71, 0x2a, 0x1b, 0xd5, 0x77, 0x06, 0x69, 0x0e, 0xe0, 0x0f, 0x67, 0x18, 0x71, 0x8b, 0x27, 0xc5,
-	0x94, 0x13, 0xc9, 0x74, 0x41, 0xc3, 0x6f, 0x60, 0xf9, 0xac, 0x51, 0x5a, 0xf4, 0xa6, 0x47, 0x5f,
-	0xff, 0x35, 0x0e, 0xf0, 0x3b, 0x48, 0x3c, 0xdc, 0x6d, 0x75, 0xc2, 0x8e, 0x27, 0xf6, 0x6e, 0xee,
-	0x3e, 0xc6, 0xc3, 0x4f, 0x00, 0x00, 0x00, 0xff, 0xff, 0x24, 0xa8, 0xd8, 0x80, 0x24, 0x02, 0x00,
+	proto.RegisterType((*DuplicateTopicMapRequest)(nil), "DuplicateTopicMapRequest")
+	proto.RegisterType((*DuplicateTopicMapResponse)(nil), "DuplicateTopicMapResponse")
+	proto.RegisterType((*DeleteTopicMapRequest)(nil), "DeleteTopicMapRequest")
+	proto.RegisterType((*DeleteTopicMapResponse)(nil), "DeleteTopicMapResponse")
+	proto.RegisterType((*GetTopicMapStatsRequest)(nil), "GetTopicMapStatsRequest")
+	proto.RegisterType((*GetTopicMapStatsResponse)(nil), "GetTopicMapStatsResponse")
+}
+
+func init() { proto.RegisterFile("pb.proto", fileDescriptor_pb_f80abaa17e25ccc8) }
+
+var fileDescriptor_pb_f80abaa17e25ccc8 = []byte{
+	// 385 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x53, 0x4b, 0xaf, 0x93, 0x40,
+	0x14, 0x0e, 0x05, 0x6e, 0xe0, 0x60, 0x5c, 0x8c, 0xb7, 0xb7, 0x63, 0x6a, 0x22, 0x99, 0x85, 0x61,
+	0x23, 0x8b, 0xba, 0xd1, 0xe8, 0xc2, 0xd8, 0x26, 0xa6, 0x0b, 0x6d, 0x32, 0xba, 0x6f, 0xa6, 0x70,
+	0x16, 0xa4, 0x14, 0x46, 0x66, 0x30, 0xe1, 0x07, 0xf8, 0xbf, 0x0d, 0xc3, 0xa3, 0x0f, 0x5c, 0xa8,
+	0xb9, 0x3b, 0xe6, 0x3b, 0xdf, 0x63, 0xce, 0x99, 0x03, 0x78, 0xf2, 0x10, 0xcb, 0xaa, 0xd4, 0x25,
+	0x7b, 0x0b, 0xde, 0xf7, 0x52, 0x66, 0xc9, 0x17, 0x21, 0xc9, 0x53, 0x98, 0x65, 0x29, 0xb5, 0x42,
+	0x2b, 0x72, 0xf8, 0x2c, 0x4b, 0xc9, 0x0b, 0x70, 0x75, 0x5b, 0xa3, 0xb3, 0xd0, 0x8a, 0x82, 0xd5,
+	0x5d, 0x6c, 0x98, 0xbc, 0x03, 0xd9, 0x2f, 0x0b, 0x5c, 0x03, 0x4c, 0x74, 0x21, 0x3c, 0x31, 0x94,
+	0xfd, 0x49, 0xc8, 0x7d, 0x96, 0x1a, 0xb9, 0xc3, 0x41, 0xf7, 0x39, 0xdb, 0x94, 0x2c, 0xc1, 0x2d,
+	0xc4, 0x09, 0x15, 0xb5, 0x43, 0x3b, 0x0a, 0x56, 0x6e, 0xfc, 0x55, 0x9c, 0x90, 0x77, 0x18, 0x79,
+	0x0d, 0x41, 0x99, 0x24, 0x75, 0x55, 0x61, 0x91, 0xa0, 0xa2, 0x8e, 0xa1, 0x04, 0xf1, 0x6e, 0xc4,
+	0xf8, 0x65, 0x9d, 0x6d, 0xc1, 0x69, 0xd5, 0x93, 0x5b, 0x2c, 0xc1, 0x97, 0xa2, 0xc2, 0x42, 0x9f,
+	0xaf, 0xe0, 0x75, 0xc0, 0x36, 0x25, 0xf7, 0xe0, 0xfe, 0x14, 0x79, 0x8d, 0xd4, 0x0e, 0xad, 0xc8,
+	0xe7, 0xdd, 0x81, 0xed, 0x00, 0xce, 0x29, 0x8f, 0x61, 0x38, 0x87, 0x67, 0x9f, 0x51, 0x0f, 0x03,
+	0x56, 0x1c, 0x7f, 0xd4, 0xa8, 0x34, 0xfb, 0x08, 0xf7, 0xd7, 0xb0, 0x92, 0x65, 0xa1, 0x90, 0x44,
+	0x00, 0xe3, 0xe0, 0x14, 0xb5, 0x4c, 0xe3, 0x7e, 0x3c, 0xf0, 0xb8, 0x3f, 0x4c, 0x50, 0xb1, 0x05,
+	0xcc, 0xd7, 0x15, 0x0a, 0x8d, 0x63, 0x71, 0xb4, 0x7e, 0xb8, 0x2d, 0xf4, 0xe6, 0xaf, 0xc0, 0x1f,
+	0xcd, 0x4d, 0x57, 0x57, 0xde, 0xde, 0xe0, 0xcd, 0x3e, 0x00, 0xdd, 0xd4, 0x32, 0xcf, 0x92, 0xa9,
+	0xfb, 0xe4, 0x65, 0xad, 0xdb, 0x97, 0x65, 0x6b, 0x78, 0xfe, 0x07, 0xf5, 0x3f, 0x5e, 0xe1, 0x1d,
+	0xcc, 0x37, 0x98, 0xe3, 0xff, 0xe4, 0x53, 0x78, 0xb8, 0x95, 0x76, 0xe1, 0xec, 0x3d, 0x2c, 0x2e,
+	0x86, 0xfe, 0x4d, 0x0b, 0xad, 0xfe, 0xde, 0x36, 0x07, 0x3a, 0x15, 0xf7, 0x5d, 0x11, 0x70, 0x8e,
+	0xd8, 0xa8, 0x5e, 0x65, 0xbe, 0xdb, 0x5d, 0x39, 0x62, 0xb3, 0x3f, 0x34, 0x1a, 0xd5, 0xb0, 0x2b,
+	0x47, 0x6c, 0x3e, 0xb5, 0x67, 0xf2, 0x12, 0x02, 0xb3, 0x1e, 0x7d, 0xd9, 0xee, 0xd2, 0x0c, 0x64,
+	0x08, 0x87, 0x3b, 0xf3, 0x6f, 0xbe, 0xf9, 0x3d, 0x00, 0x2e, 0x0c, 0x9c, 0x72, 0xa7, 0x03, 0x00,
 	0x00,
 }
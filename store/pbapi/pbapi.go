@@ -16,8 +16,10 @@
 package pbapi
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"sort"
 
 	"github.com/google/note-maps/kv"
 	"github.com/google/note-maps/store/models"
@@ -31,74 +33,178 @@ type Gateway struct {
 func NewGateway(db kv.DB) *Gateway { return &Gateway{db} }
 
 func (g Gateway) CreateTopicMap(_ *pb.CreateTopicMapRequest) (*pb.CreateTopicMapResponse, error) {
-	txn := g.db.NewTxn(true)
-	defer txn.Discard()
-	m := models.New(txn)
-	m.Partition = 0
+	var response pb.CreateTopicMapResponse
+	err := kv.Update(context.Background(), g.db, func(txn kv.Txn) error {
+		m := models.New(txn)
+		m.Partition = 0
 
-	// Allocate an entity to identify the new topic map.
-	tm, err := m.Alloc()
+		// Allocate an entity to identify the new topic map.
+		tm, err := m.Alloc()
+		if err != nil {
+			return err
+		}
+
+		// Describe the new topic map by creating metadata for it.
+		var info models.TopicMapInfo
+		info.TopicMap = uint64(tm)
+		if err = m.SetTopicMapInfo(tm, &info); err != nil {
+			return err
+		}
+
+		// Mark the new partition as already using the latest schema.
+		m.Partition = tm
+		if err = models.Migrations.Stamp(m.Partitioned); err != nil {
+			return err
+		}
+
+		topic, err := loadTopic(m, tm, maskNames|maskOccurrences)
+		if err != nil {
+			return err
+		}
+		response.TopicMap = &pb.TopicMap{
+			Id:    uint64(tm),
+			Topic: topic,
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
+	return &response, nil
+}
 
-	// Describe the new topic map by creating metadata for it.
-	var info models.TopicMapInfo
-	info.TopicMap = uint64(tm)
-	if err = m.SetTopicMapInfo(tm, &info); err != nil {
-		return nil, err
-	}
+func (g Gateway) GetTopicMaps(_ *pb.GetTopicMapsRequest) (*pb.GetTopicMapsResponse, error) {
+	var response pb.GetTopicMapsResponse
+	err := kv.View(context.Background(), g.db, func(txn kv.Txn) error {
+		m := models.New(txn)
+		m.Partition = 0
+
+		// Get a slice of entities representing all known topic maps.
+		es, err := m.AllTopicMapInfoEntities(nil, 0)
+		if err != nil {
+			return err
+		}
+		log.Println("found", len(es), "topic maps")
+
+		for _, e := range es {
+			m.Partition = e
+
+			// For each topic map, load the topic that reifies it.
+			topic, err := loadTopic(m, e, maskNames|maskOccurrences)
+			if err != nil {
+				return err
+			}
 
-	m.Partition = tm
-	topic, err := loadTopic(m, tm, maskNames|maskOccurrences)
+			tm := &pb.TopicMap{
+				Id:    uint64(e),
+				Topic: topic,
+			}
+			response.TopicMaps = append(response.TopicMaps, tm)
+		}
+
+		// List topic maps in dictionary order of their first names.
+		sort.SliceStable(response.TopicMaps, func(i, j int) bool {
+			return models.Collator.Compare(
+				firstName(response.TopicMaps[i].Topic),
+				firstName(response.TopicMaps[j].Topic)) < 0
+		})
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
+	return &response, nil
+}
 
-	if err = txn.Commit(); err != nil {
-		return nil, err
-	}
+func (g Gateway) DuplicateTopicMap(request *pb.DuplicateTopicMapRequest) (*pb.DuplicateTopicMapResponse, error) {
+	var response pb.DuplicateTopicMapResponse
+	err := kv.Update(context.Background(), g.db, func(txn kv.Txn) error {
+		m, err := topicMap(txn, request.GetTopicMapId())
+		if err != nil {
+			return err
+		}
+
+		// Copy the topic map into a new partition, keeping its schema version.
+		tm, err := m.DuplicateTopicMap()
+		if err != nil {
+			return err
+		}
 
-	return &pb.CreateTopicMapResponse{
-		TopicMap: &pb.TopicMap{
+		m.Partition = tm
+		topic, err := loadTopic(m, tm, maskNames|maskOccurrences)
+		if err != nil {
+			return err
+		}
+		response.TopicMap = &pb.TopicMap{
 			Id:    uint64(tm),
 			Topic: topic,
-		},
-	}, nil
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
 }
 
-func (g Gateway) GetTopicMaps(_ *pb.GetTopicMapsRequest) (*pb.GetTopicMapsResponse, error) {
-	txn := g.db.NewTxn(false)
-	defer txn.Discard()
-	m := models.New(txn)
-	m.Partition = 0
-
-	// Get a slice of entities representing all known topic maps.
-	es, err := m.AllTopicMapInfoEntities(nil, 0)
+func (g Gateway) DeleteTopicMap(request *pb.DeleteTopicMapRequest) (*pb.DeleteTopicMapResponse, error) {
+	err := kv.Update(context.Background(), g.db, func(txn kv.Txn) error {
+		m, err := topicMap(txn, request.GetTopicMapId())
+		if err != nil {
+			return err
+		}
+		return m.DeleteTopicMap()
+	})
 	if err != nil {
 		return nil, err
 	}
-	log.Println("found", len(es), "topic maps")
-
-	var response pb.GetTopicMapsResponse
-	for _, e := range es {
-		m.Partition = e
+	return &pb.DeleteTopicMapResponse{}, nil
+}
 
-		// For each topic map, load the topic that reifies it.
-		topic, err := loadTopic(m, e, maskNames|maskOccurrences)
+func (g Gateway) GetTopicMapStats(request *pb.GetTopicMapStatsRequest) (*pb.GetTopicMapStatsResponse, error) {
+	var response pb.GetTopicMapStatsResponse
+	err := kv.View(context.Background(), g.db, func(txn kv.Txn) error {
+		m, err := topicMap(txn, request.GetTopicMapId())
 		if err != nil {
-			return nil, err
+			return err
 		}
-
-		tm := &pb.TopicMap{
-			Id:    uint64(e),
-			Topic: topic,
+		stats, err := m.Stats()
+		if err != nil {
+			return err
 		}
-		response.TopicMaps = append(response.TopicMaps, tm)
+		response.Keys = uint64(stats.Keys)
+		response.KeyBytes = uint64(stats.KeyBytes)
+		response.ValueBytes = uint64(stats.ValueBytes)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 	return &response, nil
 }
 
+// topicMap returns a models.Txn for the partition of the topic map identified
+// by id, or an error if there is no such topic map.
+func topicMap(txn kv.Txn, id uint64) (models.Txn, error) {
+	m := models.New(txn)
+	if info, err := m.GetTopicMapInfo(kv.Entity(id)); err != nil {
+		return m, err
+	} else if id == 0 || info.TopicMap != id {
+		return m, fmt.Errorf("no such topic map: %v", id)
+	}
+	m.Partition = kv.Entity(id)
+	return m, nil
+}
+
+// firstName returns the value of the first name of topic, or an empty string
+// if it has no names.
+func firstName(topic *pb.Topic) string {
+	if names := topic.GetNames(); len(names) > 0 {
+		return names[0].GetValue()
+	}
+	return ""
+}
+
 // mask describes which fields should be included in a response.
 type mask int
 
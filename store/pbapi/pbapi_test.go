@@ -15,11 +15,15 @@
 package pbapi
 
 import (
+	"context"
 	"io/ioutil"
 	"os"
 	"testing"
 
+	"github.com/google/note-maps/kv"
 	"github.com/google/note-maps/kv/badger"
+	"github.com/google/note-maps/kv/memory"
+	"github.com/google/note-maps/store/models"
 	"github.com/google/note-maps/store/pb"
 )
 
@@ -96,3 +100,66 @@ func TestCreateGetTopicMap(t *testing.T) {
 		}
 	}()
 }
+
+func TestDuplicateDeleteTopicMap(t *testing.T) {
+	db := memory.NewDB()
+	defer db.Close()
+	g := NewGateway(db)
+	createResponse, err := g.CreateTopicMap(&pb.CreateTopicMapRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := createResponse.TopicMap.Id
+	if err = kv.Update(context.Background(), db, func(txn kv.Txn) error {
+		m := models.New(txn)
+		m.Partition = kv.Entity(src)
+		var n models.Name
+		n.Topic, n.Value = src, "Ada Lovelace"
+		if err := m.SetName(20, &n); err != nil {
+			return err
+		}
+		return m.SetTopicNames(kv.Entity(src), models.TopicNames{20})
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	duplicateResponse, err := g.DuplicateTopicMap(&pb.DuplicateTopicMapRequest{TopicMapId: src})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst := duplicateResponse.TopicMap.GetId()
+	if dst == 0 || dst == src {
+		t.Fatalf("want a new topic map, got %v", dst)
+	} else if topic := duplicateResponse.TopicMap.GetTopic(); topic.GetId() != dst {
+		t.Errorf("want TopicMap.Id==TopicMap.Topic.Id, got %v!=%v", dst, topic.GetId())
+	} else if got := firstName(topic); got != "Ada Lovelace" {
+		t.Errorf("want the name of the original topic map, got %q", got)
+	}
+	var stats []*pb.GetTopicMapStatsResponse
+	for _, tm := range []uint64{src, dst} {
+		response, err := g.GetTopicMapStats(&pb.GetTopicMapStatsRequest{TopicMapId: tm})
+		if err != nil {
+			t.Fatal(err)
+		}
+		stats = append(stats, response)
+	}
+	if stats[0].Keys == 0 || stats[0].Keys != stats[1].Keys || stats[0].ValueBytes != stats[1].ValueBytes {
+		t.Errorf("want equal non-zero stats for a copy, got %v and %v", stats[0], stats[1])
+	}
+
+	if _, err = g.DeleteTopicMap(&pb.DeleteTopicMapRequest{TopicMapId: src}); err != nil {
+		t.Fatal(err)
+	}
+	getResponse, err := g.GetTopicMaps(&pb.GetTopicMapsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(getResponse.TopicMaps) != 1 || getResponse.TopicMaps[0].Id != dst {
+		t.Errorf("want only topic map %v, got %v", dst, getResponse.TopicMaps)
+	}
+	if _, err = g.GetTopicMapStats(&pb.GetTopicMapStatsRequest{TopicMapId: src}); err == nil {
+		t.Error("want an error for stats of a deleted topic map, got nil")
+	}
+	if _, err = g.DeleteTopicMap(&pb.DeleteTopicMapRequest{TopicMapId: src}); err == nil {
+		t.Error("want an error for deleting a deleted topic map, got nil")
+	}
+}